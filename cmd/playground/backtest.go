@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"playground/internal/cli"
+	"playground/internal/pipeline"
+	"playground/internal/utils/collector"
+	"playground/internal/utils/outfile"
+	"syscall"
+)
+
+// backtest evaluates the models on the source LTV history and writes error metrics per key and model
+// Models are fitted on the history up to the cutoff day and predict the known values of the later days
+func backtest(args []string) {
+	// Errors are collected during the run and reported at the end, exit code depends on error class
+	errs := collector.NewCollector()
+	var outFile *outfile.AtomicFile
+
+	// Output file must not be left half-written, drop it and report errors before exit
+	exit := func() {
+		if outFile != nil {
+			outFile.Abort()
+		}
+		fmt.Fprintln(os.Stderr, errs.Report())
+		os.Exit(errs.ExitCode())
+	}
+	fatal := func(err error) {
+		errs.Add(err)
+		exit()
+	}
+
+	// Get parsed backtest command flags
+	flags, err := cli.NewBacktestFlags(args)
+	if err != nil {
+		fatal(err)
+	}
+
+	// Create results writer according to output format
+	writer, outFile, err := newOutput(flags.Out(), flags.OutputFormat())
+	if err != nil {
+		fatal(err)
+	}
+
+	// Build backtest pipeline, invalid input rows abort the run
+	opts := []pipeline.Option{
+		pipeline.WithCsvAliases(flags.CsvAliases()),
+		pipeline.WithErrorCollector(errs),
+		pipeline.WithWorkers(flags.Workers()),
+	}
+	if flags.Unweighted() {
+		opts = append(opts, pipeline.WithUnweighted())
+	}
+	p := pipeline.NewPipeline(opts...).
+		Source(flags.Sources(), flags.SourceFormat()).
+		Aggregator(flags.Aggregate()).
+		Backtest(flags.Models(), flags.Cutoff(), flags.Days())
+
+	// SIGINT and SIGTERM cancel the run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	results, _ := p.Run(ctx)
+	stop()
+
+	// Nil results mean the pipeline failed, errors are already collected
+	if results == nil {
+		exit()
+	}
+
+	// Write results
+	if err := writeResults(writer, results); err != nil {
+		fatal(err)
+	}
+	if outFile != nil {
+		if err := outFile.Commit(); err != nil {
+			fatal(err)
+		}
+	}
+
+	// Errors which didn't stop the pipeline, results are written without the affected keys
+	if errs.Len() != 0 {
+		fmt.Fprintln(os.Stderr, errs.Report())
+		os.Exit(errs.ExitCode())
+	}
+}
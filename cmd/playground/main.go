@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
 	"playground/internal/cli"
 	cnst "playground/internal/constants"
-	"playground/internal/runners/aggregator/aggregator_factory"
-	"playground/internal/runners/common"
-	"playground/internal/runners/datasource/datasource_factory"
-	"playground/internal/runners/postprocessor/postprocessor_factory"
-	"playground/internal/runners/predictor/predictor_factory"
+	"playground/internal/pipeline"
 	"playground/internal/types"
-	"sync"
+	"playground/internal/utils/collector"
+	"playground/internal/utils/outfile"
+	"playground/internal/utils/rejects"
+	"playground/internal/writers/common"
+	"playground/internal/writers/writer_factory"
+	"syscall"
 )
 
 func init() {
@@ -20,87 +23,139 @@ func init() {
 }
 
 func main() {
-	// Get parsed user input flags
-	flags, err := cli.NewFlags()
-	if err != nil {
-		log.Fatalln(err.Error())
+	// Subcommands are dispatched by the first argument, prediction is run otherwise
+	if len(os.Args) > 1 && os.Args[1] == cnst.BacktestCommand {
+		backtest(os.Args[2:])
+		return
 	}
 
-	// Create channels storage
-	ch := types.NewChannels(
-		cnst.RecordChannelBuffer,
-		cnst.ErrorChannelBuffer,
-		cnst.AggregateChannelBuffer,
-		cnst.PredictChannelBuffer,
-		cnst.PostProcessorChannelBuffer,
-	)
+	// Errors are collected during the run and reported at the end, exit code depends on error class
+	errs := collector.NewCollector()
+	var outFile *outfile.AtomicFile
+	var rowErrors *rejects.Handler
 
-	// Prepare input params
-	wg := &sync.WaitGroup{}
-	ctx, cancel := context.WithCancel(context.Background())
+	// Output file must not be left half-written, drop it and report errors before exit
+	exit := func() {
+		if outFile != nil {
+			outFile.Abort()
+		}
+		errs.Add(rowErrors.Close())
+		fmt.Fprintln(os.Stderr, errs.Report())
+		os.Exit(errs.ExitCode())
+	}
+	fatal := func(err error) {
+		errs.Add(err)
+		exit()
+	}
 
-	// Create datasource runner (Pipeline entry point)
-	sourceRunner, err := datasource_factory.NewRunner(ctx, wg, flags.Source(), ch.RecordCh, ch.ErrorCh)
+	// Get parsed user input flags
+	flags, err := cli.NewFlags()
 	if err != nil {
-		log.Fatalln(err.Error())
+		fatal(err)
 	}
 
-	// Create aggregator runner
-	aggregatorRunner, err := aggregator_factory.NewRunner(wg, flags.Aggregate(), ch.RecordCh, ch.AggregateCh)
+	// Create invalid input rows handler
+	rowErrors, err = rejects.NewHandler(flags.OnError(), flags.MaxErrors(), flags.Rejects())
 	if err != nil {
-		log.Fatalln(err.Error())
+		fatal(err)
 	}
 
-	// Create predictor runner
-	predictorRunner, err := predictor_factory.NewRunner(wg, flags.Model(), ch.AggregateCh, ch.PredictCh)
+	// Create results writer according to output format
+	writer, outFile, err := newOutput(flags.Out(), flags.OutputFormat())
 	if err != nil {
-		log.Fatalln(err.Error())
+		fatal(err)
+	}
+
+	// Build prediction pipeline from the command line parameters
+	opts := []pipeline.Option{
+		pipeline.WithCsvAliases(flags.CsvAliases()),
+		pipeline.WithRowErrors(rowErrors),
+		pipeline.WithErrorCollector(errs),
+		pipeline.WithTimeout(flags.Timeout()),
+		pipeline.WithStageTimeouts(flags.StageTimeouts()),
+		pipeline.WithWorkers(flags.Workers()),
+		pipeline.WithPredictorPool(flags.PredictorPool()),
+		pipeline.WithSort(flags.Sort()),
+		pipeline.WithTop(flags.Top()),
+		pipeline.WithHoldout(flags.Holdout(), flags.HoldoutMetric()),
+		pipeline.WithConfidence(flags.Confidence()),
+	}
+	if flags.Partial() {
+		opts = append(opts, pipeline.WithPartialResults())
+	}
+	if flags.Unweighted() {
+		opts = append(opts, pipeline.WithUnweighted())
+	}
+	p := pipeline.NewPipeline(opts...).
+		Source(flags.Sources(), flags.SourceFormat()).
+		Aggregator(flags.Aggregate()).
+		Predictor(flags.Model(), flags.Days()).
+		PostProcessor(flags.Aggregate())
+
+	// SIGINT and SIGTERM cancel the run, runners drain and stop
+	// Signal handling is reset after the run, so the second signal kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	results, _ := p.Run(ctx)
+	stop()
+
+	// Nil results mean the pipeline failed, errors are already collected
+	if results == nil {
+		exit()
+	}
+	// Interrupted or timed out run results are partial
+	if len(results) != 0 && results[0].Partial() {
+		log.Warningf("run stopped, %d partial results written", len(results))
 	}
 
-	// Create postprocessor runner
-	postProcessorRunner, err := postprocessor_factory.NewRunner(wg, flags.Aggregate(), ch.PredictCh, ch.PostProcCh)
+	// Write results
+	if err := writeResults(writer, results); err != nil {
+		fatal(err)
+	}
+	if err := rowErrors.Close(); err != nil {
+		fatal(err)
+	}
+	if outFile != nil {
+		if err := outFile.Commit(); err != nil {
+			fatal(err)
+		}
+	}
+	if count := rowErrors.Count(); count > 0 {
+		log.Warningf("%d invalid rows skipped", count)
+	}
+
+	// Errors which didn't stop the pipeline, results are written without the affected keys
+	if errs.Len() != 0 {
+		fmt.Fprintln(os.Stderr, errs.Report())
+		os.Exit(errs.ExitCode())
+	}
+}
+
+// newOutput creates results writer according to output format
+// Results are written to stdout, or atomically to the output file if provided, the file is returned then
+func newOutput(path, format string) (common.IWriter, *outfile.AtomicFile, error) {
+	if path == "" {
+		writer, err := writer_factory.NewWriter(format, os.Stdout)
+		return writer, nil, err
+	}
+
+	outFile, err := outfile.NewAtomicFile(outfile.ResolvePath(path, format))
+	if err != nil {
+		return nil, nil, err
+	}
+	writer, err := writer_factory.NewWriter(format, outFile)
 	if err != nil {
-		log.Fatalln(err.Error())
-	}
-	runners := []common.IRunner{
-		sourceRunner,
-		aggregatorRunner,
-		predictorRunner,
-		postProcessorRunner,
-	}
-
-	// Set wait group and launch runners
-	wg.Add(len(runners))
-	for _, runner := range runners {
-		go runner.Run()
-	}
-
-	for {
-		select {
-		// Something went wrong, shutdown runners and show error message
-		case err, ok := <-ch.ErrorCh:
-			if ok {
-				cancel()
-				wg.Wait()
-				log.Fatalln(err)
-			} else {
-				ch.ErrorCh = nil
-			}
-			// Read and print result
-		case result, ok := <-ch.PostProcCh:
-			if ok {
-				fmt.Println(result)
-			} else {
-				return
-			}
-
-			// Simulate cancel event
-			//case <-time.After(2 * time.Millisecond):
-			//	log.Info("cancel )")
-			//	cancel()
-			//	wg.Wait()
-			//	log.Info("application finished")
-			//	return
+		outFile.Abort()
+		return nil, nil, err
+	}
+	return writer, outFile, nil
+}
+
+// writeResults writes results and flushes the writer
+func writeResults(writer common.IWriter, results []*types.Result) error {
+	for _, result := range results {
+		if err := writer.Write(result); err != nil {
+			return err
 		}
 	}
+	return writer.Flush()
 }
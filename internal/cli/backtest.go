@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	cnst "playground/internal/constants"
+	err "playground/internal/utils/cerror"
+	"playground/internal/utils/outfile"
+	"strconv"
+	"strings"
+)
+
+// nameList is a flag.Value that collects comma separated names.
+type nameList []string
+
+// String returns comma separated names representation.
+func (n *nameList) String() string {
+	return strings.Join(*n, cnst.CliListSeparator)
+}
+
+// Set parses comma separated names and appends them to the list, empty names are ignored.
+func (n *nameList) Set(value string) error {
+	for _, item := range strings.Split(value, cnst.CliListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			*n = append(*n, item)
+		}
+	}
+	return nil
+}
+
+// backtestParams holds the parameters of the backtest command parsed from the command line.
+type backtestParams struct {
+	sources      sourceList
+	sourceFormat string
+	aggregate    string
+	csvAliases   aliasMap
+	models       nameList
+	cutoff       uint
+	days         dayList
+	outputFormat string
+	out          string
+	workers      int
+	unweighted   bool
+}
+
+// validateParams checks the fields of the backtestParams for any missing or invalid values.
+func (c *backtestParams) validateParams(flags *flag.FlagSet) error {
+	if len(c.sources) == 0 {
+		flags.Usage()
+		return err.NewConfigError(cnst.CliSourceParam, "", fmt.Sprintf("%q is required", cnst.CliSourceParam))
+	}
+	if c.aggregate == "" {
+		flags.Usage()
+		return err.NewConfigError(cnst.CliAggregateParam, "", fmt.Sprintf("%q is required", cnst.CliAggregateParam))
+	}
+	if c.cutoff == 0 {
+		flags.Usage()
+		return err.NewConfigError(cnst.CliCutoffParam, "", fmt.Sprintf("%q is required", cnst.CliCutoffParam))
+	}
+	if len(c.days) == 0 {
+		flags.Usage()
+		return err.NewConfigError(cnst.CliDaysParam, "", fmt.Sprintf("%q is required", cnst.CliDaysParam))
+	}
+	if c.workers < 1 {
+		return err.NewConfigError(cnst.CliWorkersParam, strconv.Itoa(c.workers), fmt.Sprintf("%d invalid workers number", c.workers))
+	}
+	return nil
+}
+
+// Sources returns the source parameters, each one is a file path, glob pattern or "-" for stdin.
+func (c *backtestParams) Sources() []string {
+	return c.sources
+}
+
+// SourceFormat returns the source format parameter, empty means inferred from the file extension.
+func (c *backtestParams) SourceFormat() string {
+	return c.sourceFormat
+}
+
+// Aggregate returns the aggregate parameter.
+func (c *backtestParams) Aggregate() string {
+	return c.aggregate
+}
+
+// CsvAliases returns alternative CSV column names mapped to canonical ones.
+func (c *backtestParams) CsvAliases() map[string]string {
+	return c.csvAliases
+}
+
+// Models returns the evaluated models, empty means all of them.
+func (c *backtestParams) Models() []string {
+	return c.models
+}
+
+// Cutoff returns the last LTV day the models are fitted on.
+func (c *backtestParams) Cutoff() uint {
+	return c.cutoff
+}
+
+// Days returns the days the predictions are evaluated on, in increasing order.
+func (c *backtestParams) Days() []uint {
+	return c.days
+}
+
+// OutputFormat returns the output format parameter.
+func (c *backtestParams) OutputFormat() string {
+	return c.outputFormat
+}
+
+// Out returns the results output file path, empty means stdout.
+func (c *backtestParams) Out() string {
+	return c.out
+}
+
+// Workers returns the number of goroutines parsing and aggregating records.
+func (c *backtestParams) Workers() int {
+	return c.workers
+}
+
+// Unweighted reports whether each record weighs 1, instead of the number of its users.
+func (c *backtestParams) Unweighted() bool {
+	return c.unweighted
+}
+
+// NewBacktestFlags parses the backtest command flags and returns a populated backtestParams instance.
+// It returns an error if any required fields are missing.
+func NewBacktestFlags(args []string) (backtestParams, error) {
+	cmd := backtestParams{}
+	flags := flag.NewFlagSet(cnst.BacktestCommand, flag.ExitOnError)
+
+	flags.Var(&cmd.sources, cnst.CliSourceParam,
+		"Path or glob pattern of the data source files with LTV history after the cutoff day, \"-\" reads from stdin, could be repeated")
+
+	flags.StringVar(&cmd.sourceFormat, cnst.CliSourceFormatParam, "",
+		fmt.Sprintf("Data source format of stdin and files without a known extension, required for stdin, example: [%s, %s, %s, %s]",
+			strings.TrimPrefix(cnst.CsvDataSource, cnst.SourceFormatExtPrefix),
+			strings.TrimPrefix(cnst.JsonDataSource, cnst.SourceFormatExtPrefix),
+			strings.TrimPrefix(cnst.JsonlDataSource, cnst.SourceFormatExtPrefix),
+			strings.TrimPrefix(cnst.NdjsonDataSource, cnst.SourceFormatExtPrefix)))
+
+	flags.StringVar(&cmd.aggregate, cnst.CliAggregateParam, "",
+		fmt.Sprintf("Data aggregation sign, example: [%s, %s]", cnst.AggregateCountry, cnst.AggregateCampaign))
+
+	flags.Var(&cmd.csvAliases, cnst.CliCsvAliasParam,
+		"Comma separated CSV column aliases, example: campaign_uuid=CampaignId,geo=Country")
+
+	flags.Var(&cmd.models, cnst.CliModelParam,
+		fmt.Sprintf("Comma separated models to evaluate, all of them by default, example: %s,%s,%s,%s",
+			cnst.LinearExtrapolationPredictorModel, cnst.AveragePredictorModel,
+			cnst.PowerLawPredictorModel, cnst.LogarithmicPredictorModel))
+
+	flags.UintVar(&cmd.cutoff, cnst.CliCutoffParam, 0,
+		"The last LTV day the models are fitted on, example: 7")
+
+	var day uint
+	flags.UintVar(&day, cnst.CliDayParam, 0, "The known day after the cutoff day to evaluate predictions on")
+
+	flags.Var(&cmd.days, cnst.CliDaysParam,
+		fmt.Sprintf("Comma separated known days after the cutoff day to evaluate predictions on, overrides %q, example: 14,30", cnst.CliDayParam))
+
+	flags.StringVar(&cmd.outputFormat, cnst.CliOutputFormat, cnst.TableOutputFormat,
+		fmt.Sprintf("Results output format, example: [%s, %s, %s, %s, %s]", cnst.TableOutputFormat,
+			cnst.JsonOutputFormat, cnst.JsonlOutputFormat, cnst.CsvOutputFormat, cnst.TextOutputFormat))
+
+	flags.StringVar(&cmd.out, cnst.CliOutParam, "",
+		"Path to the results output file or directory, format is inferred from the file extension, example: backtest.json")
+
+	flags.IntVar(&cmd.workers, cnst.CliWorkersParam, 1,
+		"The number of goroutines parsing and aggregating records, JSON arrays are parsed serially, example: 8")
+
+	flags.BoolVar(&cmd.unweighted, cnst.CliUnweightedParam, false,
+		"Average records LTV with equal weights, by default JSON records are weighted by their Users and CSV rows weigh 1")
+
+	_ = flags.Parse(args)
+
+	// Output format is inferred from the output file extension unless provided explicitly
+	if cmd.out != "" && !isFlagSet(flags, cnst.CliOutputFormat) {
+		if format, ok := outfile.FormatFromPath(cmd.out); ok {
+			cmd.outputFormat = format
+		}
+	}
+
+	// Single day is used unless days list is provided
+	days, dayErr := withSingleDay(flags, cmd.days, day)
+	if dayErr != nil {
+		return backtestParams{}, dayErr
+	}
+	cmd.days = days
+
+	// Flags validation logic
+	if err := cmd.validateParams(flags); err != nil {
+		return backtestParams{}, err
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	cnst "playground/internal/constants"
+	err "playground/internal/utils/cerror"
+	"reflect"
+	"testing"
+)
+
+func TestNewBacktestFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedResult backtestParams
+		expectedError  bool
+		errorStr       string
+	}{
+		{
+			name:          "emptySource",
+			args:          []string{fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam},
+			expectedError: true,
+			errorStr:      err.NewCustomError(fmt.Sprintf("%q is required", cnst.CliSourceParam)).Error(),
+		},
+		{
+			name: "emptyCutoff",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliDayParam), "30",
+			},
+			expectedError: true,
+			errorStr:      err.NewCustomError(fmt.Sprintf("%q is required", cnst.CliCutoffParam)).Error(),
+		},
+		{
+			name: "emptyDays",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliCutoffParam), "7",
+			},
+			expectedError: true,
+			errorStr:      err.NewCustomError(fmt.Sprintf("%q is required", cnst.CliDaysParam)).Error(),
+		},
+		{
+			name: "zeroDay",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliCutoffParam), "7",
+				fmt.Sprintf("-%s", cnst.CliDayParam), "0",
+			},
+			expectedError: true,
+			errorStr:      err.NewCustomError("0 invalid prediction day").Error(),
+		},
+		{
+			name: "validParams",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliCutoffParam), "7",
+				fmt.Sprintf("-%s", cnst.CliDayParam), "30",
+			},
+			expectedResult: backtestParams{sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				cutoff: 7, days: dayList{30}, outputFormat: cnst.TableOutputFormat, workers: 1},
+		},
+		{
+			name: "validModelsDaysAndOutParams",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliModelParam), fmt.Sprintf("%s, %s", cnst.PowerLawPredictorModel, cnst.LinearExtrapolationPredictorModel),
+				fmt.Sprintf("-%s", cnst.CliCutoffParam), "3",
+				fmt.Sprintf("-%s", cnst.CliDaysParam), "14,7",
+				fmt.Sprintf("-%s", cnst.CliOutParam), "backtest.json",
+			},
+			expectedResult: backtestParams{sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				models: nameList{cnst.PowerLawPredictorModel, cnst.LinearExtrapolationPredictorModel}, cutoff: 3, days: dayList{7, 14},
+				outputFormat: cnst.JsonOutputFormat, out: "backtest.json", workers: 1},
+		},
+		{
+			name: "validUnweightedParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliCutoffParam), "7",
+				fmt.Sprintf("-%s", cnst.CliDayParam), "30",
+				fmt.Sprintf("-%s", cnst.CliUnweightedParam),
+			},
+			expectedResult: backtestParams{sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				cutoff: 7, days: dayList{30}, outputFormat: cnst.TableOutputFormat, workers: 1, unweighted: true},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			flags, err := NewBacktestFlags(testCase.args)
+
+			/* ASSERT */
+			// Assert expected error string
+			if (err != nil) && (err.Error() != testCase.errorStr) {
+				t.Fatalf("NewBacktestFlags() with args %v: expected error string [%s], got [%s]", testCase.args, testCase.errorStr, err.Error())
+			}
+
+			// Assert expected error
+			if (err != nil) != testCase.expectedError {
+				t.Fatalf("NewBacktestFlags() with args %v: expected error %v, got %v", testCase.args, testCase.expectedError, err != nil)
+			}
+
+			// Assert result
+			if !reflect.DeepEqual(flags, testCase.expectedResult) {
+				t.Fatalf("NewBacktestFlags() with args %v: expected %v, got %v", testCase.args, testCase.expectedResult, flags)
+			}
+		})
+	}
+}
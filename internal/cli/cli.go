@@ -5,13 +5,163 @@ import (
 	"fmt"
 	cnst "playground/internal/constants"
 	err "playground/internal/utils/cerror"
+	"playground/internal/utils/outfile"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// dayList is a flag.Value that collects comma separated prediction days.
+type dayList []uint
+
+// String returns comma separated days representation.
+func (d *dayList) String() string {
+	days := make([]string, 0, len(*d))
+	for _, day := range *d {
+		days = append(days, strconv.FormatUint(uint64(day), 10))
+	}
+	return strings.Join(days, cnst.PredictDaysSeparator)
+}
+
+// Set parses comma separated days and appends them to the list.
+// Returns an error if some of the days is not a positive number.
+func (d *dayList) Set(value string) error {
+	for _, item := range strings.Split(value, cnst.PredictDaysSeparator) {
+		day, parseErr := strconv.ParseUint(strings.TrimSpace(item), 10, 0)
+		if parseErr != nil || day == 0 {
+			return err.NewConfigError(cnst.CliDaysParam, item, fmt.Sprintf("%q invalid prediction day", item))
+		}
+		*d = append(*d, uint(day))
+	}
+	return nil
+}
+
+// normalize sorts days in increasing order and removes duplicates.
+func (d dayList) normalize() dayList {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	result := dayList{}
+	for i, day := range d {
+		if i == 0 || day != d[i-1] {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// withSingleDay returns days, or the single day list if days are not provided.
+// Returns an error if the day is set to zero on the command line, so it is not taken as not given.
+func withSingleDay(flags *flag.FlagSet, days dayList, day uint) (dayList, error) {
+	if day == 0 && isFlagSet(flags, cnst.CliDayParam) {
+		return nil, err.NewConfigError(cnst.CliDayParam, "0", fmt.Sprintf("%d invalid prediction day", day))
+	}
+	if len(days) == 0 && day != 0 {
+		days = dayList{day}
+	}
+	return days.normalize(), nil
+}
+
+// aliasMap is a flag.Value that collects comma separated alias=Column pairs.
+type aliasMap map[string]string
+
+// String returns comma separated aliases representation.
+func (a *aliasMap) String() string {
+	aliases := make([]string, 0, len(*a))
+	for alias, column := range *a {
+		aliases = append(aliases, alias+cnst.CsvAliasValueSeparator+column)
+	}
+	sort.Strings(aliases)
+	return strings.Join(aliases, cnst.CsvAliasSeparator)
+}
+
+// Set parses comma separated alias=Column pairs and adds them to the map.
+// Returns an error if some of the pairs is malformed.
+func (a *aliasMap) Set(value string) error {
+	if *a == nil {
+		*a = aliasMap{}
+	}
+	for _, item := range strings.Split(value, cnst.CsvAliasSeparator) {
+		alias, column, found := strings.Cut(item, cnst.CsvAliasValueSeparator)
+		alias, column = strings.TrimSpace(alias), strings.TrimSpace(column)
+		if !found || alias == "" || column == "" {
+			return err.NewConfigError(cnst.CliCsvAliasParam, item, fmt.Sprintf("%q invalid csv alias", item))
+		}
+		(*a)[alias] = column
+	}
+	return nil
+}
+
+// timeoutMap is a flag.Value that collects comma separated stage=duration pairs.
+type timeoutMap map[string]time.Duration
+
+// String returns comma separated stage timeouts representation.
+func (m *timeoutMap) String() string {
+	timeouts := make([]string, 0, len(*m))
+	for stage, timeout := range *m {
+		timeouts = append(timeouts, stage+cnst.StageTimeoutValueSeparator+timeout.String())
+	}
+	sort.Strings(timeouts)
+	return strings.Join(timeouts, cnst.StageTimeoutSeparator)
+}
+
+// Set parses comma separated stage=duration pairs and adds them to the map.
+// Returns an error if some of the pairs is malformed or the duration is not positive.
+func (m *timeoutMap) Set(value string) error {
+	if *m == nil {
+		*m = timeoutMap{}
+	}
+	for _, item := range strings.Split(value, cnst.StageTimeoutSeparator) {
+		stage, duration, _ := strings.Cut(item, cnst.StageTimeoutValueSeparator)
+		timeout, parseErr := time.ParseDuration(strings.TrimSpace(duration))
+		stage = strings.TrimSpace(stage)
+		if parseErr != nil || stage == "" || timeout <= 0 {
+			return err.NewConfigError(cnst.CliStageTimeoutParam, item, fmt.Sprintf("%q invalid stage timeout", item))
+		}
+		(*m)[stage] = timeout
+	}
+	return nil
+}
+
+// sourceList is a flag.Value that collects repeated source paths.
+type sourceList []string
+
+// String returns comma separated sources representation.
+func (s *sourceList) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set appends source path to the list, empty values are ignored.
+func (s *sourceList) Set(value string) error {
+	if value != "" {
+		*s = append(*s, value)
+	}
+	return nil
+}
+
 // cliParams holds the parameters parsed from the command line.
 type cliParams struct {
-	model     string
-	source    string
-	aggregate string
+	model         string
+	sources       sourceList
+	sourceFormat  string
+	aggregate     string
+	days          dayList
+	csvAliases    aliasMap
+	outputFormat  string
+	out           string
+	onError       string
+	maxErrors     uint
+	rejects       string
+	partial       bool
+	timeout       time.Duration
+	stageTimeouts timeoutMap
+	workers       int
+	predictorPool int
+	sortBy        string
+	top           uint
+	holdout       uint
+	holdoutMetric string
+	confidence    float64
+	unweighted    bool
 }
 
 // validateParams checks the fields of the cliParams for any missing or invalid values
@@ -24,7 +174,7 @@ func (c *cliParams) validateParams() error {
 
 	params := []paramCheck{
 		{c.Model(), cnst.CliModelParam},
-		{c.Source(), cnst.CliSourceParam},
+		{c.sources.String(), cnst.CliSourceParam},
 		{c.Aggregate(), cnst.CliAggregateParam},
 	}
 
@@ -33,9 +183,35 @@ func (c *cliParams) validateParams() error {
 	for _, item := range params {
 		if item.value == "" {
 			flag.Usage()
-			return err.NewCustomError(fmt.Sprintf("%q is required", item.name))
+			return err.NewConfigError(item.name, "", fmt.Sprintf("%q is required", item.name))
 		}
 	}
+
+	if len(c.days) == 0 {
+		flag.Usage()
+		return err.NewConfigError(cnst.CliDayParam, "", fmt.Sprintf("%q is required", cnst.CliDayParam))
+	}
+
+	if c.timeout < 0 {
+		return err.NewConfigError(cnst.CliTimeoutParam, c.timeout.String(), fmt.Sprintf("%q invalid timeout", c.timeout))
+	}
+
+	if c.workers < 1 {
+		return err.NewConfigError(cnst.CliWorkersParam, strconv.Itoa(c.workers), fmt.Sprintf("%d invalid workers number", c.workers))
+	}
+
+	if c.holdout == 0 {
+		return err.NewConfigError(cnst.CliHoldoutParam, "0", "0 invalid holdout days number")
+	}
+
+	if c.confidence < 0 || c.confidence >= 1 {
+		value := strconv.FormatFloat(c.confidence, 'g', -1, 64)
+		return err.NewConfigError(cnst.CliConfidenceParam, value, fmt.Sprintf("%s invalid confidence level", value))
+	}
+
+	if c.predictorPool < 0 {
+		return err.NewConfigError(cnst.CliPredictorPoolParam, strconv.Itoa(c.predictorPool), fmt.Sprintf("%d invalid predictor pool size", c.predictorPool))
+	}
 	return nil
 }
 
@@ -44,9 +220,14 @@ func (c *cliParams) Model() string {
 	return c.model
 }
 
-// Source returns the source parameter.
-func (c *cliParams) Source() string {
-	return c.source
+// Sources returns the source parameters, each one is a file path, glob pattern or "-" for stdin.
+func (c *cliParams) Sources() []string {
+	return c.sources
+}
+
+// SourceFormat returns the source format parameter, empty means inferred from the file extension.
+func (c *cliParams) SourceFormat() string {
+	return c.sourceFormat
 }
 
 // Aggregate returns the aggregate parameter.
@@ -54,22 +235,210 @@ func (c *cliParams) Aggregate() string {
 	return c.aggregate
 }
 
+// Days returns prediction days in increasing order.
+func (c *cliParams) Days() []uint {
+	return c.days
+}
+
+// CsvAliases returns alternative CSV column names mapped to canonical ones.
+func (c *cliParams) CsvAliases() map[string]string {
+	return c.csvAliases
+}
+
+// OutputFormat returns the output format parameter.
+func (c *cliParams) OutputFormat() string {
+	return c.outputFormat
+}
+
+// Out returns the results output file path, empty means stdout.
+func (c *cliParams) Out() string {
+	return c.out
+}
+
+// OnError returns the invalid input rows handling mode.
+func (c *cliParams) OnError() string {
+	return c.onError
+}
+
+// MaxErrors returns the number of skipped invalid rows after which the run aborts, zero means no limit.
+func (c *cliParams) MaxErrors() uint {
+	return c.maxErrors
+}
+
+// Rejects returns the rejects file path used in quarantine mode.
+func (c *cliParams) Rejects() string {
+	return c.rejects
+}
+
+// Partial reports whether predictions of an interrupted run are written, marked as partial.
+func (c *cliParams) Partial() bool {
+	return c.partial
+}
+
+// Timeout returns the run duration limit, zero means no limit.
+func (c *cliParams) Timeout() time.Duration {
+	return c.timeout
+}
+
+// StageTimeouts returns the time since the run start by which the stages must finish.
+func (c *cliParams) StageTimeouts() map[string]time.Duration {
+	return c.stageTimeouts
+}
+
+// Workers returns the number of goroutines parsing and aggregating records.
+func (c *cliParams) Workers() int {
+	return c.workers
+}
+
+// PredictorPool returns the number of predictor pool workers, zero means the number of CPUs.
+func (c *cliParams) PredictorPool() int {
+	return c.predictorPool
+}
+
+// Sort returns the results order parameter.
+func (c *cliParams) Sort() string {
+	return c.sortBy
+}
+
+// Top returns the number of the first results written, zero means all of them.
+func (c *cliParams) Top() uint {
+	return c.top
+}
+
+// Holdout returns the number of the last LTV days the auto model candidates are scored on.
+func (c *cliParams) Holdout() uint {
+	return c.holdout
+}
+
+// HoldoutMetric returns the auto model candidates scoring metric.
+func (c *cliParams) HoldoutMetric() string {
+	return c.holdoutMetric
+}
+
+// Confidence returns the confidence level of the predictions intervals, zero means no intervals.
+func (c *cliParams) Confidence() float64 {
+	return c.confidence
+}
+
+// Unweighted reports whether each record weighs 1, instead of the number of its users.
+func (c *cliParams) Unweighted() bool {
+	return c.unweighted
+}
+
+// isFlagSet reports whether the flag with provided name was set on the command line.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 // NewFlags parses command line flags and returns a populated cliParams instance.
 // It returns an error if any required fields are missing.
 func NewFlags() (cliParams, error) {
 	cmd := cliParams{}
 
 	flag.StringVar(&cmd.model, cnst.CliModelParam, "",
-		fmt.Sprintf("The prediction method to use, example: [%s, %s]",
-			cnst.LinearExtrapolationPredictorModel, cnst.AveragePredictorModel))
+		fmt.Sprintf("The prediction method to use, %q selects the best scored one per key, example: [%s, %s, %s, %s, %s]",
+			cnst.AutoPredictorModel, cnst.LinearExtrapolationPredictorModel, cnst.AveragePredictorModel,
+			cnst.PowerLawPredictorModel, cnst.LogarithmicPredictorModel, cnst.AutoPredictorModel))
+
+	flag.Var(&cmd.sources, cnst.CliSourceParam,
+		"Path or glob pattern of the data source files, \"-\" reads from stdin, could be repeated")
 
-	flag.StringVar(&cmd.source, cnst.CliSourceParam, "", "Path to the data source file")
+	flag.StringVar(&cmd.sourceFormat, cnst.CliSourceFormatParam, "",
+		fmt.Sprintf("Data source format of stdin and files without a known extension, required for stdin, example: [%s, %s, %s, %s]",
+			strings.TrimPrefix(cnst.CsvDataSource, cnst.SourceFormatExtPrefix),
+			strings.TrimPrefix(cnst.JsonDataSource, cnst.SourceFormatExtPrefix),
+			strings.TrimPrefix(cnst.JsonlDataSource, cnst.SourceFormatExtPrefix),
+			strings.TrimPrefix(cnst.NdjsonDataSource, cnst.SourceFormatExtPrefix)))
 
 	flag.StringVar(&cmd.aggregate, cnst.CliAggregateParam, "",
 		fmt.Sprintf("Data aggregation sign, example: [%s, %s]", cnst.AggregateCountry, cnst.AggregateCampaign))
 
+	var day uint
+	flag.UintVar(&day, cnst.CliDayParam, cnst.PredictForNDay, "The day to predict value for")
+
+	flag.Var(&cmd.days, cnst.CliDaysParam,
+		fmt.Sprintf("Comma separated days to predict values for, overrides %q, example: 30,60,90", cnst.CliDayParam))
+
+	flag.Var(&cmd.csvAliases, cnst.CliCsvAliasParam,
+		"Comma separated CSV column aliases, example: campaign_uuid=CampaignId,geo=Country")
+
+	flag.StringVar(&cmd.outputFormat, cnst.CliOutputFormat, cnst.TextOutputFormat,
+		fmt.Sprintf("Results output format, example: [%s, %s, %s, %s, %s]", cnst.TextOutputFormat,
+			cnst.CsvOutputFormat, cnst.JsonOutputFormat, cnst.JsonlOutputFormat, cnst.TableOutputFormat))
+
+	flag.StringVar(&cmd.out, cnst.CliOutParam, "",
+		"Path to the results output file or directory, format is inferred from the file extension, example: results.csv")
+
+	flag.StringVar(&cmd.onError, cnst.CliOnErrorParam, cnst.OnErrorFail,
+		fmt.Sprintf("Invalid input rows handling, fail the run, skip rows or quarantine them to the rejects file, example: [%s, %s, %s]",
+			cnst.OnErrorFail, cnst.OnErrorSkip, cnst.OnErrorQuarantine))
+
+	flag.UintVar(&cmd.maxErrors, cnst.CliMaxErrorsParam, 0,
+		"The number of skipped invalid rows after which the run still aborts, 0 means no limit")
+
+	flag.StringVar(&cmd.rejects, cnst.CliRejectsParam, cnst.DefaultRejectsFile,
+		fmt.Sprintf("Path to the rejects file, used in %q mode", cnst.OnErrorQuarantine))
+
+	flag.BoolVar(&cmd.partial, cnst.CliPartialParam, false,
+		"Write predictions of the data read so far when the run is interrupted by SIGINT, SIGTERM or timeout, marked as partial")
+
+	flag.DurationVar(&cmd.timeout, cnst.CliTimeoutParam, 0,
+		"The run duration limit, the error names the stage still running, 0 means no limit, example: 30s, 5m")
+
+	flag.Var(&cmd.stageTimeouts, cnst.CliStageTimeoutParam,
+		fmt.Sprintf("Comma separated time since the run start by which the stages must finish, stages: [%s, %s, %s, %s], example: %s=1m,%s=2m",
+			cnst.DataSourceStage, cnst.AggregatorStage, cnst.PredictorStage, cnst.PostProcessorStage,
+			cnst.DataSourceStage, cnst.PredictorStage))
+
+	flag.IntVar(&cmd.workers, cnst.CliWorkersParam, 1,
+		"The number of goroutines parsing and aggregating records, JSON arrays are parsed serially, records keep the input order, example: 8")
+
+	flag.IntVar(&cmd.predictorPool, cnst.CliPredictorPoolParam, 0,
+		"The number of predictor workers, keys are sharded across them, 0 means the number of CPUs")
+
+	flag.StringVar(&cmd.sortBy, cnst.CliSortParam, cnst.SortValueDesc,
+		fmt.Sprintf("Results order, ties are ordered by key, %q orders by the number of aggregated records, example: [%s, %s, %s, %s]",
+			cnst.SortSamples, cnst.SortValueDesc, cnst.SortValueAsc, cnst.SortKey, cnst.SortSamples))
+
+	flag.UintVar(&cmd.top, cnst.CliTopParam, 0,
+		"The number of the first results written, 0 means all of them, example: 10")
+
+	flag.UintVar(&cmd.holdout, cnst.CliHoldoutParam, cnst.HoldoutDays,
+		fmt.Sprintf("The number of the last LTV days %q model candidates are fitted without and scored on", cnst.AutoPredictorModel))
+
+	flag.StringVar(&cmd.holdoutMetric, cnst.CliHoldoutMetricParam, cnst.HoldoutMetricMae,
+		fmt.Sprintf("The %q model candidates scoring metric, mean absolute error or mean absolute percentage error, example: [%s, %s]",
+			cnst.AutoPredictorModel, cnst.HoldoutMetricMae, cnst.HoldoutMetricMape))
+
+	flag.Float64Var(&cmd.confidence, cnst.CliConfidenceParam, 0,
+		"The confidence level of the key mean LTV intervals written as lower and upper bounds, they narrow as the key gets more records, "+
+			"0 means no intervals, example: 0.95")
+
+	flag.BoolVar(&cmd.unweighted, cnst.CliUnweightedParam, false,
+		"Average records LTV with equal weights, by default JSON records are weighted by their Users and CSV rows weigh 1")
+
 	flag.Parse()
 
+	// Output format is inferred from the output file extension unless provided explicitly
+	if cmd.out != "" && !isFlagSet(flag.CommandLine, cnst.CliOutputFormat) {
+		if format, ok := outfile.FormatFromPath(cmd.out); ok {
+			cmd.outputFormat = format
+		}
+	}
+
+	// Single day is used unless days list is provided
+	days, dayErr := withSingleDay(flag.CommandLine, cmd.days, day)
+	if dayErr != nil {
+		return cliParams{}, dayErr
+	}
+	cmd.days = days
+
 	// Flags validation logic
 	if err := cmd.validateParams(); err != nil {
 		return cliParams{}, err
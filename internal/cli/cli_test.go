@@ -1,12 +1,15 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	cnst "playground/internal/constants"
 	err "playground/internal/utils/cerror"
+	"reflect"
 	"testing"
+	"time"
 )
 
 const (
@@ -93,10 +96,329 @@ func TestNewFlags(t *testing.T) {
 				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
 				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
 			},
-			expectedResult: cliParams{model: DefaultModelParam, source: DefaultSourceParam, aggregate: DefaultAggregateParam},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam, days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
 			expectedError:  false,
 			errorStr:       "",
 		},
+		{
+			name: "zeroDay",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliDayParam), "0",
+			},
+			expectedResult: cliParams{},
+			expectedError:  true,
+			errorStr:       err.NewCustomError("0 invalid prediction day").Error(),
+		},
+		{
+			name: "validDayParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliDayParam), "90",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam, days: dayList{90}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError:  false,
+			errorStr:       "",
+		},
+		{
+			name: "validDaysParamOverridesDay",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliDayParam), "90",
+				fmt.Sprintf("-%s", cnst.CliDaysParam), "180,30,60,30",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam, days: dayList{30, 60, 180}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError:  false,
+			errorStr:       "",
+		},
+		{
+			name: "validCsvAliasParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliCsvAliasParam), "campaign_uuid=CampaignId, geo = Country",
+				fmt.Sprintf("-%s", cnst.CliCsvAliasParam), "revenue_d1=Ltv1",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, csvAliases: aliasMap{"campaign_uuid": "CampaignId", "geo": "Country", "revenue_d1": "Ltv1"},
+				outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validOutputFormatParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliOutputFormat), cnst.JsonOutputFormat,
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.JsonOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validOutParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliOutParam), "results.txt",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, out: "results.txt", onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "outParamInfersOutputFormat",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliOutParam), "results.CSV",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.CsvOutputFormat, out: "results.CSV", onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "outputFormatParamOverridesOutExtension",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliOutParam), "results.json",
+				fmt.Sprintf("-%s", cnst.CliOutputFormat), cnst.JsonlOutputFormat,
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.JsonlOutputFormat, out: "results.json", onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validRepeatedSourceParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), "data/*.csv",
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam, "data/*.csv"},
+				aggregate: DefaultAggregateParam, days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validStdinSourceParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), cnst.StdinSource,
+				fmt.Sprintf("-%s", cnst.CliSourceFormatParam), "jsonl",
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{cnst.StdinSource}, sourceFormat: "jsonl",
+				aggregate: DefaultAggregateParam, days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail, rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validOnErrorParams",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliOnErrorParam), cnst.OnErrorQuarantine,
+				fmt.Sprintf("-%s", cnst.CliMaxErrorsParam), "10",
+				fmt.Sprintf("-%s", cnst.CliRejectsParam), "bad_rows.csv",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat,
+				onError: cnst.OnErrorQuarantine, maxErrors: 10, rejects: "bad_rows.csv", workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validPartialParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliPartialParam),
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, partial: true, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "validTimeoutParams",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliTimeoutParam), "5m",
+				fmt.Sprintf("-%s", cnst.CliStageTimeoutParam), fmt.Sprintf("%s=1m, %s=90s", cnst.DataSourceStage, cnst.PredictorStage),
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 1, timeout: 5 * time.Minute,
+				stageTimeouts: timeoutMap{cnst.DataSourceStage: time.Minute, cnst.PredictorStage: 90 * time.Second}, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+			errorStr:      "",
+		},
+		{
+			name: "negativeTimeout",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliTimeoutParam), "-1s",
+			},
+			expectedResult: cliParams{},
+			expectedError:  true,
+			errorStr:       err.NewCustomError(`"-1s" invalid timeout`).Error(),
+		},
+		{
+			name: "validWorkersParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliWorkersParam), "8",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 8, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+		},
+		{
+			name: "invalidWorkersParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliWorkersParam), "0",
+			},
+			expectedResult: cliParams{},
+			expectedError:  true,
+			errorStr:       err.NewCustomError(`0 invalid workers number`).Error(),
+		},
+		{
+			name: "validPredictorPoolParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliPredictorPoolParam), "4",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 1, predictorPool: 4, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae},
+			expectedError: false,
+		},
+		{
+			name: "invalidPredictorPoolParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliPredictorPoolParam), "-1",
+			},
+			expectedResult: cliParams{},
+			expectedError:  true,
+			errorStr:       err.NewCustomError(`-1 invalid predictor pool size`).Error(),
+		},
+		{
+			name: "validHoldoutParams",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), cnst.AutoPredictorModel,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliHoldoutParam), "3",
+				fmt.Sprintf("-%s", cnst.CliHoldoutMetricParam), cnst.HoldoutMetricMape,
+			},
+			expectedResult: cliParams{model: cnst.AutoPredictorModel, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: 3, holdoutMetric: cnst.HoldoutMetricMape},
+			expectedError: false,
+		},
+		{
+			name: "invalidHoldoutParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), cnst.AutoPredictorModel,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliHoldoutParam), "0",
+			},
+			expectedResult: cliParams{},
+			expectedError:  true,
+			errorStr:       err.NewCustomError(`0 invalid holdout days number`).Error(),
+		},
+		{
+			name: "validConfidenceParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliConfidenceParam), "0.9",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae,
+				confidence: 0.9},
+			expectedError: false,
+		},
+		{
+			name: "invalidConfidenceParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliConfidenceParam), "95",
+			},
+			expectedResult: cliParams{},
+			expectedError:  true,
+			errorStr:       err.NewCustomError(`95 invalid confidence level`).Error(),
+		},
+		{
+			name: "validUnweightedParam",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliUnweightedParam),
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortValueDesc, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae,
+				unweighted: true},
+			expectedError: false,
+		},
+		{
+			name: "validSortAndTopParams",
+			args: []string{
+				fmt.Sprintf("-%s", cnst.CliModelParam), DefaultModelParam,
+				fmt.Sprintf("-%s", cnst.CliSourceParam), DefaultSourceParam,
+				fmt.Sprintf("-%s", cnst.CliAggregateParam), DefaultAggregateParam,
+				fmt.Sprintf("-%s", cnst.CliSortParam), cnst.SortKey,
+				fmt.Sprintf("-%s", cnst.CliTopParam), "10",
+			},
+			expectedResult: cliParams{model: DefaultModelParam, sources: sourceList{DefaultSourceParam}, aggregate: DefaultAggregateParam,
+				days: dayList{cnst.PredictForNDay}, outputFormat: cnst.TextOutputFormat, onError: cnst.OnErrorFail,
+				rejects: cnst.DefaultRejectsFile, workers: 1, sortBy: cnst.SortKey, holdout: cnst.HoldoutDays, holdoutMetric: cnst.HoldoutMetricMae, top: 10},
+			expectedError: false,
+		},
 	}
 
 	for _, testCase := range tests {
@@ -124,9 +446,82 @@ func TestNewFlags(t *testing.T) {
 			}
 
 			// Assert result
-			if flags != testCase.expectedResult {
+			if !reflect.DeepEqual(flags, testCase.expectedResult) {
 				t.Fatalf("NewFlags() with args %v: expected %v, got %v", testCase.args, testCase.expectedResult, flags)
 			}
 		})
 	}
 }
+
+func TestTimeoutMap_Set(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected timeoutMap
+		errorStr string
+	}{
+		{name: "singleStage", value: "predictor=2s", expected: timeoutMap{cnst.PredictorStage: 2 * time.Second}},
+		{name: "multipleStages", value: "datasource=1m,aggregator=1m30s",
+			expected: timeoutMap{cnst.DataSourceStage: time.Minute, cnst.AggregatorStage: 90 * time.Second}},
+		{name: "noDuration", value: "predictor", errorStr: err.NewCustomError(`"predictor" invalid stage timeout`).Error()},
+		{name: "noStage", value: "=1s", errorStr: err.NewCustomError(`"=1s" invalid stage timeout`).Error()},
+		{name: "zeroDuration", value: "predictor=0s", errorStr: err.NewCustomError(`"predictor=0s" invalid stage timeout`).Error()},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			timeouts := timeoutMap{}
+
+			/* ACT */
+			setErr := timeouts.Set(testCase.value)
+
+			/* ASSERT */
+			if testCase.errorStr != "" {
+				if setErr == nil || setErr.Error() != testCase.errorStr {
+					t.Fatalf("Set(%q) : expected error [%s], got [%v]", testCase.value, testCase.errorStr, setErr)
+				}
+				return
+			}
+			if setErr != nil || !reflect.DeepEqual(timeouts, testCase.expected) {
+				t.Fatalf("Set(%q) : expected %v, got %v [%v]", testCase.value, testCase.expected, timeouts, setErr)
+			}
+		})
+	}
+}
+
+func TestDayList_Set(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected dayList
+		errorStr string
+	}{
+		{name: "singleDay", value: "30", expected: dayList{30}},
+		{name: "multipleDays", value: "90, 30", expected: dayList{90, 30}},
+		{name: "zeroDay", value: "30,0", errorStr: err.NewCustomError(`"0" invalid prediction day`).Error()},
+		{name: "notNumber", value: "week", errorStr: err.NewCustomError(`"week" invalid prediction day`).Error()},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			days := dayList{}
+
+			/* ACT */
+			setErr := days.Set(testCase.value)
+
+			/* ASSERT */
+			if testCase.errorStr != "" {
+				var configErr *err.ConfigError
+				if !errors.As(setErr, &configErr) || configErr.Param != cnst.CliDaysParam || setErr.Error() != testCase.errorStr {
+					t.Fatalf("Set(%q) : expected %q error [%s], got [%v]", testCase.value, cnst.CliDaysParam, testCase.errorStr, setErr)
+				}
+				return
+			}
+			if setErr != nil || !reflect.DeepEqual(days, testCase.expected) {
+				t.Fatalf("Set(%q) : expected %v, got %v [%v]", testCase.value, testCase.expected, days, setErr)
+			}
+		})
+	}
+}
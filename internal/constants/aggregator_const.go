@@ -3,4 +3,13 @@ package constants
 const (
 	AggregateCampaign = "campaign"
 	AggregateCountry  = "country"
+
+	AggregateSeparator    = ","
+	AggregateKeySeparator = "|"
+	AggregateKeyEscape    = `\`
+
+	AggregatorStage = "aggregator"
+
+	// AggregatorShardChannelBuffer is the buffer size of a single aggregator key shard channel
+	AggregatorShardChannelBuffer = 64
 )
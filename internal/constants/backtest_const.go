@@ -0,0 +1,16 @@
+package constants
+
+const (
+	BacktestCommand = "backtest"
+	BacktestStage   = "backtest"
+
+	// BacktestAllKeys is the dimension value of the model summary results, evaluated on all keys
+	BacktestAllKeys = "all"
+)
+
+const (
+	MetricMae  = "mae"
+	MetricRmse = "rmse"
+	MetricMape = "mape"
+	MetricBias = "bias"
+)
@@ -2,7 +2,7 @@ package constants
 
 const (
 	RecordChannelBuffer        = 10
-	ErrorChannelBuffer         = 0
+	ErrorChannelBuffer         = 10
 	AggregateChannelBuffer     = 5
 	PredictChannelBuffer       = 5
 	PostProcessorChannelBuffer = 5
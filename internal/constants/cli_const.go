@@ -1,7 +1,30 @@
 package constants
 
 const (
-	CliModelParam     = "model"
-	CliSourceParam    = "source"
-	CliAggregateParam = "aggregate"
+	CliModelParam         = "model"
+	CliSourceParam        = "source"
+	CliAggregateParam     = "aggregate"
+	CliDayParam           = "day"
+	CliDaysParam          = "days"
+	CliCsvAliasParam      = "csv-alias"
+	CliOutputFormat       = "output-format"
+	CliOutParam           = "out"
+	CliSourceFormatParam  = "source-format"
+	CliOnErrorParam       = "on-error"
+	CliMaxErrorsParam     = "max-errors"
+	CliRejectsParam       = "rejects"
+	CliPartialParam       = "partial"
+	CliTimeoutParam       = "timeout"
+	CliStageTimeoutParam  = "stage-timeout"
+	CliWorkersParam       = "workers"
+	CliPredictorPoolParam = "predictor-pool"
+	CliSortParam          = "sort"
+	CliTopParam           = "top"
+	CliHoldoutParam       = "holdout"
+	CliHoldoutMetricParam = "holdout-metric"
+	CliCutoffParam        = "cutoff"
+	CliConfidenceParam    = "confidence"
+	CliUnweightedParam    = "unweighted"
+
+	CliListSeparator = ","
 )
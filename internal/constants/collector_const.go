@@ -0,0 +1,18 @@
+package constants
+
+const (
+	ExitOk          = 0
+	ExitUnknown     = 1
+	ExitConfigError = 2
+	ExitParseError  = 3
+	ExitStageError  = 4
+	ExitInterrupted = 130
+)
+
+const (
+	ConfigStage     = "config"
+	DataSourceStage = "datasource"
+	OutputStage     = "output"
+	PipelineStage   = "pipeline"
+	UnknownStage    = "unknown"
+)
@@ -1,25 +1,12 @@
 package constants
 
 const (
-	CsvUserIdPosition = iota
-	CsvCampaignIdPosition
-	CsvCountryPosition
-	CsvLtv1Position
-	CsvLtv2Position
-	CsvLtv3Position
-	CsvLtv4Position
-	CsvLtv5Position
-	CsvLtv6Position
-	CsvLtv7Position
-	CsvDataLen
+	CsvCampaignIdName = "CampaignId"
+	CsvCountryName    = "Country"
 
-	CsvUserIdName = "userId"
-	CsvLtv1Name   = "ltv1"
-	CsvLtv2Name   = "ltv2"
-	CsvLtv3Name   = "ltv3"
-	CsvLtv4Name   = "ltv4"
-	CsvLtv5Name   = "ltv5"
-	CsvLtv6Name   = "ltv6"
-	CsvLtv7Name   = "ltv7"
-	LtvLen        = 7
+	CsvAliasSeparator      = ","
+	CsvAliasValueSeparator = "="
+
+	LtvFieldPrefix = "ltv"
+	LtvFieldName   = "Ltv"
 )
@@ -1,6 +1,33 @@
 package constants
 
 const (
-	CsvDataSource  = ".csv"
-	JsonDataSource = ".json"
+	CsvDataSource    = ".csv"
+	JsonDataSource   = ".json"
+	JsonlDataSource  = ".jsonl"
+	NdjsonDataSource = ".ndjson"
+)
+
+const (
+	StdinSource           = "-"
+	SourceFormatExtPrefix = "."
+)
+
+const (
+	GzipCompression  = ".gz"
+	GzipCompression2 = ".gzip"
+	ZstdCompression  = ".zst"
+	ZstdCompression2 = ".zstd"
+	Bzip2Compression = ".bz2"
+)
+
+const (
+	CsvDataSourceStage   = "csv datasource"
+	JsonDataSourceStage  = "json datasource"
+	JsonlDataSourceStage = "jsonl datasource"
+	MultiDataSourceStage = "multi datasource"
+)
+
+const (
+	// ParseBatchSize is the number of rows parsed by a single parse worker at once
+	ParseBatchSize = 256
 )
@@ -0,0 +1,10 @@
+package constants
+
+import "time"
+
+const (
+	PipelineShutdownTimeout = 2 * time.Second
+
+	StageTimeoutSeparator      = ","
+	StageTimeoutValueSeparator = "="
+)
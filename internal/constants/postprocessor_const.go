@@ -0,0 +1,12 @@
+package constants
+
+const (
+	PostProcessorStage = "postprocessor"
+)
+
+const (
+	SortValueDesc = "value-desc"
+	SortValueAsc  = "value-asc"
+	SortKey       = "key"
+	SortSamples   = "samples"
+)
@@ -3,5 +3,35 @@ package constants
 const (
 	LinearExtrapolationPredictorModel = "linext"
 	AveragePredictorModel             = "average"
+	PowerLawPredictorModel            = "powerlaw"
+	LogarithmicPredictorModel         = "log"
+	AutoPredictorModel                = "auto"
 	PredictForNDay                    = 60
+	PredictDaysSeparator              = ","
+
+	PredictorStage = "predictor"
+)
+
+const (
+	// HoldoutDays is the default number of the last LTV days the auto model candidates are scored on
+	HoldoutDays = 2
+	// HoldoutMinFitDays is the number of LTV days the auto model candidates need to be fitted on
+	// Keys having fewer days are predicted by the first registered model
+	HoldoutMinFitDays = 2
+
+	HoldoutMetricMae  = "mae"
+	HoldoutMetricMape = "mape"
+)
+
+const (
+	// BootstrapResamples is the number of daily averages resamples the prediction interval is estimated on
+	// if the model has no analytic interval
+	BootstrapResamples = 1000
+	// BootstrapSeed makes the resampling, so the estimated intervals, reproducible
+	BootstrapSeed = 20240601
+)
+
+const (
+	// PredictorShardChannelBuffer is the buffer size of a single predictor pool worker channel
+	PredictorShardChannelBuffer = 64
 )
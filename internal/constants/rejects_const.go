@@ -0,0 +1,9 @@
+package constants
+
+const (
+	OnErrorFail       = "fail"
+	OnErrorSkip       = "skip"
+	OnErrorQuarantine = "quarantine"
+
+	DefaultRejectsFile = "rejects.csv"
+)
@@ -0,0 +1,22 @@
+package constants
+
+const (
+	TextOutputFormat  = "text"
+	CsvOutputFormat   = "csv"
+	JsonOutputFormat  = "json"
+	JsonlOutputFormat = "jsonl"
+	TableOutputFormat = "table"
+
+	OutputDayColumnPrefix = "day"
+	OutputValuePrecision  = 2
+	OutputPartialColumn   = "partial"
+	OutputPartialMark     = "(partial)"
+	OutputModelColumn     = "model"
+	OutputLowerSuffix     = "_lower"
+	OutputUpperSuffix     = "_upper"
+)
+
+const (
+	OutputDefaultFileName = "results"
+	OutputFileMode        = 0644
+)
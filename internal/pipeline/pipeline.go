@@ -0,0 +1,443 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	cnst "playground/internal/constants"
+	"playground/internal/runners/aggregator/aggregator_factory"
+	"playground/internal/runners/backtest/backtest_factory"
+	"playground/internal/runners/common"
+	"playground/internal/runners/datasource/datasource_factory"
+	"playground/internal/runners/postprocessor/postprocessor_factory"
+	"playground/internal/runners/predictor/predictor_factory"
+	"playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/collector"
+	"playground/internal/utils/progress"
+	"playground/internal/utils/rejects"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Option configures optional pipeline parameters
+type Option func(p *Pipeline)
+
+// WithCsvAliases maps alternative CSV column names to canonical ones
+func WithCsvAliases(aliases map[string]string) Option {
+	return func(p *Pipeline) {
+		p.csvAliases = aliases
+	}
+}
+
+// WithRowErrors sets invalid input rows handler, without handler the first invalid row fails the run
+func WithRowErrors(rowErrors *rejects.Handler) Option {
+	return func(p *Pipeline) {
+		p.rowErrors = rowErrors
+	}
+}
+
+// WithErrorCollector sets collector the run errors are added to, e.g. for the end of run report
+func WithErrorCollector(errs *collector.Collector) Option {
+	return func(p *Pipeline) {
+		p.errs = errs
+	}
+}
+
+// WithPartialResults keeps results of an interrupted run, they are predicted on the data read so far
+func WithPartialResults() Option {
+	return func(p *Pipeline) {
+		p.partial = true
+	}
+}
+
+// WithTimeout limits the run duration, the run fails with the stage still running named in the error
+// Zero timeout means no limit
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Pipeline) {
+		p.timeout = timeout
+	}
+}
+
+// WithStageTimeouts limits the time since the run start the stages must finish by, stages are named by stage constants
+func WithStageTimeouts(timeouts map[string]time.Duration) Option {
+	return func(p *Pipeline) {
+		p.stageTimeouts = timeouts
+	}
+}
+
+// WithWorkers sets the number of goroutines parsing and aggregating records, records keep the input order
+// JSON array sources are parsed serially, their records are aggregated by the workers too
+func WithWorkers(workers int) Option {
+	return func(p *Pipeline) {
+		p.workers = workers
+	}
+}
+
+// WithPredictorPool sets the number of predictor workers, keys are sharded across them
+// Zero pool size means the number of CPUs
+func WithPredictorPool(size int) Option {
+	return func(p *Pipeline) {
+		p.predictorPool = size
+	}
+}
+
+// WithSort sets the results order: by value descending or ascending, by key or by samples
+func WithSort(sortBy string) Option {
+	return func(p *Pipeline) {
+		p.sortBy = sortBy
+	}
+}
+
+// WithTop limits the results to the first top ones, zero means no limit
+func WithTop(top uint) Option {
+	return func(p *Pipeline) {
+		p.top = top
+	}
+}
+
+// WithHoldout sets auto model selection parameters: the number of the last LTV days the models are scored on and the metric
+func WithHoldout(days uint, metric string) Option {
+	return func(p *Pipeline) {
+		p.holdout = days
+		p.holdoutMetric = metric
+	}
+}
+
+// WithUnweighted makes each record weigh 1, instead of the number of its users
+func WithUnweighted() Option {
+	return func(p *Pipeline) {
+		p.unweighted = true
+	}
+}
+
+// WithConfidence sets the confidence level of the predictions intervals, zero confidence level disables them
+func WithConfidence(confidence float64) Option {
+	return func(p *Pipeline) {
+		p.confidence = confidence
+	}
+}
+
+// Pipeline is the prediction pipeline: data source, aggregator, predictor and postprocessor runners
+// In backtest mode predictor and postprocessor runners are replaced by the backtest runner
+// Stages are configured by the builder methods, the same parameters as the command line ones are accepted
+type Pipeline struct {
+	sources       []string
+	sourceFormat  string
+	aggregate     string
+	model         string
+	days          []uint
+	postProcessor string
+
+	// Backtest mode evaluates the models instead of prediction, it is on when the cutoff day is set
+	backtestModels []string
+	cutoff         uint
+
+	csvAliases    map[string]string
+	rowErrors     *rejects.Handler
+	errs          *collector.Collector
+	partial       bool
+	workers       int
+	predictorPool int
+	sortBy        string
+	top           uint
+	holdout       uint
+	holdoutMetric string
+	confidence    float64
+	unweighted    bool
+
+	timeout         time.Duration
+	stageTimeouts   map[string]time.Duration
+	shutdownTimeout time.Duration
+}
+
+// NewPipeline initializes and returns an empty Pipeline with the provided options
+func NewPipeline(opts ...Option) *Pipeline {
+	p := &Pipeline{
+		workers:         1,
+		sortBy:          cnst.SortValueDesc,
+		holdout:         cnst.HoldoutDays,
+		holdoutMetric:   cnst.HoldoutMetricMae,
+		shutdownTimeout: cnst.PipelineShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Source sets data sources, each one is a file path, glob pattern or "-" for stdin
+// Empty format means the format is taken from the file extension
+func (p *Pipeline) Source(sources []string, format string) *Pipeline {
+	p.sources, p.sourceFormat = sources, format
+	return p
+}
+
+// Aggregator sets aggregate parameter, comma separated parameter produces composite key
+func (p *Pipeline) Aggregator(aggregate string) *Pipeline {
+	p.aggregate = aggregate
+	return p
+}
+
+// Predictor sets prediction model and the days to predict for
+func (p *Pipeline) Predictor(model string, days []uint) *Pipeline {
+	p.model, p.days = model, days
+	return p
+}
+
+// PostProcessor sets postprocessor aggregate parameter, the aggregator one is used if it isn't set
+func (p *Pipeline) PostProcessor(aggregate string) *Pipeline {
+	p.postProcessor = aggregate
+	return p
+}
+
+// Backtest switches the pipeline to the models evaluation, instead of predictor and postprocessor stages
+// Models are fitted on the LTV days up to the cutoff day and evaluated on the known values of the days
+// Empty models mean all registered models
+func (p *Pipeline) Backtest(models []string, cutoff uint, days []uint) *Pipeline {
+	p.backtestModels, p.cutoff, p.days = models, cutoff, days
+	return p
+}
+
+// Run runs the pipeline until all results are ready, ctx cancellation stops the runners
+// Errors which drop a single record or key don't stop the run, results are returned along with the error then
+// Returns nil results and error if the pipeline is misconfigured or failed
+// Interrupted or timed out run returns nil results too, unless partial results are requested, they are marked partial then
+func (p *Pipeline) Run(ctx context.Context) ([]*types.Result, error) {
+	errs := collector.NewCollector()
+	defer p.report(errs)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// Stages report progress, so the timeout error names the stage still running and what it processed
+	tracker, err := p.tracker()
+	if err != nil {
+		errs.Add(err)
+		return nil, errs.Err()
+	}
+	ctx = progress.NewContext(ctx, tracker)
+
+	// Create channels storage
+	ch := types.NewChannels(
+		cnst.RecordChannelBuffer,
+		cnst.ErrorChannelBuffer,
+		cnst.AggregateChannelBuffer,
+		cnst.PredictChannelBuffer,
+		cnst.PostProcessorChannelBuffer,
+	)
+
+	wg := &sync.WaitGroup{}
+	runners, err := p.runners(ctx, wg, ch)
+	if err != nil {
+		errs.Add(err)
+		return nil, errs.Err()
+	}
+
+	// Timeouts cancel the run with the timeout error as the cause
+	if p.timeout > 0 {
+		timer := time.AfterFunc(p.timeout, func() {
+			if stage := tracker.Running(); stage != nil {
+				cancel(timeoutError("run", p.timeout, stage))
+			}
+		})
+		defer timer.Stop()
+	}
+	for name, timeout := range p.stageTimeouts {
+		stage := tracker.Stage(name)
+		timer := time.AfterFunc(timeout, func() {
+			if !stage.Finished() {
+				cancel(timeoutError("stage", timeout, stage))
+			}
+		})
+		defer timer.Stop()
+	}
+
+	// Set wait group and launch runners
+	wg.Add(len(runners))
+	for _, runner := range runners {
+		go runner.Run()
+	}
+
+	// Error channel is shared by the runners, it is closed once all of them finished
+	go func(errorCh types.ErrorChannel) {
+		wg.Wait()
+		close(errorCh)
+	}(ch.ErrorCh)
+
+	// Read results and errors until both channels are closed
+	// After the first fatal error runners are cancelled and the rest of results is dropped
+	// Cancelled runners are waited for a limited time, a runner blocked in a read can't be stopped
+	results := make([]*types.Result, 0)
+	failed := false
+	done := ctx.Done()
+	var shutdown <-chan time.Time
+	for ch.ErrorCh != nil || ch.PostProcCh != nil {
+		select {
+		case err, ok := <-ch.ErrorCh:
+			if !ok {
+				ch.ErrorCh = nil
+				continue
+			}
+			errs.Add(err)
+			if !failed && collector.Fatal(err) {
+				failed = true
+				cancel(nil)
+			}
+
+		case result, ok := <-ch.PostProcCh:
+			if !ok {
+				ch.PostProcCh = nil
+				continue
+			}
+			results = append(results, result)
+
+		case <-done:
+			done = nil
+			shutdown = time.After(p.shutdownTimeout)
+
+		case <-shutdown:
+			if stage := tracker.Running(); stage != nil {
+				log.Warningf("%s stage didn't stop in %s, abandoned", stage.Name(), p.shutdownTimeout)
+			}
+			// Abandoned runners still could send, so channels are drained until all of them finished
+			go drain(ch.ErrorCh, ch.PostProcCh)
+			ch.ErrorCh, ch.PostProcCh = nil, nil
+		}
+	}
+
+	// Cancelled by the caller or timed out, results are incomplete
+	if !failed && ctx.Err() != nil {
+		var stageErr *cerror.StageError
+		if cause := context.Cause(ctx); errors.As(cause, &stageErr) {
+			errs.Add(cause)
+		} else {
+			errs.Add(&cerror.StageError{Stage: cnst.PipelineStage, Reason: "run interrupted", Err: cause})
+		}
+		if p.partial {
+			return results, errs.Err()
+		}
+		failed = true
+	}
+	if failed {
+		return nil, errs.Err()
+	}
+	return results, errs.Err()
+}
+
+// drain reads and drops errors and results until both channels are closed
+// Error channel is closed once all the runners finished, so no runner stays blocked on a send
+func drain(errorCh types.ErrorChannel, postProcCh types.PostProcessorChannel) {
+	for errorCh != nil || postProcCh != nil {
+		select {
+		case _, ok := <-errorCh:
+			if !ok {
+				errorCh = nil
+			}
+		case _, ok := <-postProcCh:
+			if !ok {
+				postProcCh = nil
+			}
+		}
+	}
+}
+
+// tracker creates the pipeline stages progress tracker
+// Returns error if some of the stage timeouts names unknown stage
+func (p *Pipeline) tracker() (*progress.Tracker, error) {
+	tracker := progress.NewTracker()
+	tracker.Add(cnst.DataSourceStage, "records read")
+	tracker.Add(cnst.AggregatorStage, "records aggregated")
+	if p.cutoff != 0 {
+		tracker.Add(cnst.BacktestStage, "aggregated records received")
+	} else {
+		tracker.Add(cnst.PredictorStage, "aggregated records received")
+		tracker.Add(cnst.PostProcessorStage, "predictions received")
+	}
+
+	for name, timeout := range p.stageTimeouts {
+		if tracker.Stage(name) == nil {
+			value := fmt.Sprintf("%s%s%s", name, cnst.StageTimeoutValueSeparator, timeout)
+			return nil, cerror.NewConfigError(cnst.CliStageTimeoutParam, value, fmt.Sprintf("%q invalid stage name", name))
+		}
+	}
+	return tracker, nil
+}
+
+// timeoutError returns error of the timeout exceeded while the stage was still running
+func timeoutError(kind string, timeout time.Duration, stage *progress.Stage) error {
+	return &cerror.StageError{
+		Stage:  stage.Name(),
+		Reason: fmt.Sprintf("%s timeout %s exceeded, %d %s", kind, timeout, stage.Processed(), stage.Unit()),
+		Err:    context.DeadlineExceeded,
+	}
+}
+
+// runners creates the pipeline runners connected by ch channels
+// Returns error if some of the stages is misconfigured
+func (p *Pipeline) runners(ctx context.Context, wg *sync.WaitGroup, ch *types.Channels) ([]common.IRunner, error) {
+	postProcessor := p.postProcessor
+	if postProcessor == "" {
+		postProcessor = p.aggregate
+	}
+
+	// Create datasource runner (Pipeline entry point)
+	sourceRunner, err := datasource_factory.NewRunner(ctx, wg, p.sources, p.sourceFormat, p.csvAliases, p.rowErrors, p.workers, ch.RecordCh, ch.ErrorCh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create aggregator runner
+	aggregatorRunner, err := aggregator_factory.NewRunner(ctx, wg, p.workers, !p.unweighted, p.aggregate, ch.RecordCh, ch.AggregateCh, ch.ErrorCh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create backtest runner, it replaces predictor and postprocessor runners
+	if p.cutoff != 0 {
+		backtestRunner, err := backtest_factory.NewRunner(ctx, wg, postProcessor, p.backtestModels, p.cutoff, p.days, ch.AggregateCh, ch.PostProcCh, ch.ErrorCh)
+		if err != nil {
+			return nil, err
+		}
+		return []common.IRunner{
+			sourceRunner,
+			aggregatorRunner,
+			backtestRunner,
+		}, nil
+	}
+
+	// Create predictor runner, pool is sized by the number of CPUs unless provided
+	poolSize := p.predictorPool
+	if poolSize == 0 {
+		poolSize = runtime.NumCPU()
+	}
+	predictorRunner, err := predictor_factory.NewRunner(ctx, wg, poolSize, p.model, p.days, p.holdout, p.holdoutMetric, p.confidence, ch.AggregateCh, ch.PredictCh, ch.ErrorCh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create postprocessor runner, it orders and limits the results
+	postProcessorRunner, err := postprocessor_factory.NewRunner(ctx, wg, postProcessor, p.sortBy, p.top, ch.PredictCh, ch.PostProcCh, ch.ErrorCh)
+	if err != nil {
+		return nil, err
+	}
+
+	return []common.IRunner{
+		sourceRunner,
+		aggregatorRunner,
+		predictorRunner,
+		postProcessorRunner,
+	}, nil
+}
+
+// report adds the run errors to the pipeline errors collector if it is provided
+func (p *Pipeline) report(errs *collector.Collector) {
+	if p.errs == nil {
+		return
+	}
+	for _, err := range errs.Errors() {
+		p.errs.Add(err)
+	}
+}
@@ -0,0 +1,463 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/collector"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createTempCSV creates temporary csv file with the provided content
+func createTempCSV(t *testing.T, content string) string {
+	f, err := os.CreateTemp("", "tmp*.csv")
+	if err != nil {
+		t.Fatalf("Failed to create file [%s]", err.Error())
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("Failed to write file [%s]", err.Error())
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	return f.Name()
+}
+
+// withModel returns result with the chosen model set
+func withModel(result *types.Result, model string) *types.Result {
+	result.SetModel(model)
+	return result
+}
+
+// withConfidence returns result with the predictions intervals confidence level set
+func withConfidence(result *types.Result, confidence float64) *types.Result {
+	result.SetConfidence(confidence)
+	return result
+}
+
+func TestPipeline_Run(t *testing.T) {
+	validCsv := "UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3\n" +
+		"1,a,DE,1,2,3\n" +
+		"2,b,US,2,4,6\n"
+	keyErrorCsv := "UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3\n" +
+		"1,a,DE,1,2,3\n" +
+		"2,b,FR,5,0,0\n"
+	sameRecordsCsv := validCsv +
+		"3,a,DE,1,2,3\n" +
+		"4,b,US,2,4,6\n"
+	singleRecordKeyCsv := "UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3\n" +
+		"1,a,DE,1,2,3\n" +
+		"2,a,DE,1,2,3\n" +
+		"3,b,FR,1,2,3\n"
+	invalidRowCsv := "UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3\n" +
+		"1,a,DE,1,2,3\n" +
+		"2,b,US,HELLO,4,6\n"
+
+	tests := []struct {
+		name            string
+		content         string
+		model           string
+		confidence      float64
+		expectedResults []*types.Result
+		expectedCode    int
+	}{
+		{
+			name:    "ValidData",
+			content: validCsv,
+			model:   cnst.LinearExtrapolationPredictorModel,
+			expectedResults: []*types.Result{
+				types.NewResult("US", []types.Dimension{{Name: cnst.AggregateCountry, Value: "US"}}, []types.Prediction{{Day: 10, Value: 20}}),
+				types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}}, []types.Prediction{{Day: 10, Value: 10}}),
+			},
+			expectedCode: cnst.ExitOk,
+		},
+		{
+			// Three LTV days are not enough to hold out two of them, the first registered model is chosen
+			name:    "AutoModel",
+			content: validCsv,
+			model:   cnst.AutoPredictorModel,
+			expectedResults: []*types.Result{
+				withModel(types.NewResult("US", []types.Dimension{{Name: cnst.AggregateCountry, Value: "US"}}, []types.Prediction{{Day: 10, Value: 20}}),
+					cnst.LinearExtrapolationPredictorModel),
+				withModel(types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}}, []types.Prediction{{Day: 10, Value: 10}}),
+					cnst.LinearExtrapolationPredictorModel),
+			},
+			expectedCode: cnst.ExitOk,
+		},
+		{
+			// Key records are the same and their daily averages lie on the line, so the intervals are the predicted values themselves
+			name:       "Confidence",
+			content:    sameRecordsCsv,
+			model:      cnst.LinearExtrapolationPredictorModel,
+			confidence: 0.9,
+			expectedResults: []*types.Result{
+				withConfidence(types.NewResult("US", []types.Dimension{{Name: cnst.AggregateCountry, Value: "US"}},
+					[]types.Prediction{{Day: 10, Value: 20, Lower: 20, Upper: 20}}), 0.9),
+				withConfidence(types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}},
+					[]types.Prediction{{Day: 10, Value: 10, Lower: 10, Upper: 10}}), 0.9),
+			},
+			expectedCode: cnst.ExitOk,
+		},
+		{
+			// Spread of a single record key is unknown, so is its interval
+			name:       "ConfidenceSingleRecordKeySkipped",
+			content:    singleRecordKeyCsv,
+			model:      cnst.LinearExtrapolationPredictorModel,
+			confidence: 0.9,
+			expectedResults: []*types.Result{
+				withConfidence(types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}},
+					[]types.Prediction{{Day: 10, Value: 10, Lower: 10, Upper: 10}}), 0.9),
+			},
+			expectedCode: cnst.ExitStageError,
+		},
+		{
+			name:    "KeyErrorKeepsOtherResults",
+			content: keyErrorCsv,
+			model:   cnst.LinearExtrapolationPredictorModel,
+			expectedResults: []*types.Result{
+				types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}}, []types.Prediction{{Day: 10, Value: 10}}),
+			},
+			expectedCode: cnst.ExitStageError,
+		},
+		{
+			name:            "InvalidRowFailsRun",
+			content:         invalidRowCsv,
+			model:           cnst.LinearExtrapolationPredictorModel,
+			expectedResults: nil,
+			expectedCode:    cnst.ExitParseError,
+		},
+		{
+			name:            "InvalidModel",
+			content:         validCsv,
+			model:           "foo",
+			expectedResults: nil,
+			expectedCode:    cnst.ExitConfigError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			path := createTempCSV(t, testCase.content)
+			errs := collector.NewCollector()
+			p := NewPipeline(WithErrorCollector(errs), WithConfidence(testCase.confidence)).
+				Source([]string{path}, "").
+				Aggregator(cnst.AggregateCountry).
+				Predictor(testCase.model, []uint{10})
+
+			/* ACT */
+			results, err := p.Run(context.Background())
+
+			/* ASSERT */
+			if !reflect.DeepEqual(results, testCase.expectedResults) {
+				t.Fatalf("Run() exp: %+v\ngot: %+v", testCase.expectedResults, results)
+			}
+			if (err != nil) != (testCase.expectedCode != cnst.ExitOk) {
+				t.Fatalf("Run() : unexpected error [%v]", err)
+			}
+			if code := errs.ExitCode(); code != testCase.expectedCode {
+				t.Fatalf("Run() : expected exit code %d, got %d, errors [%v]", testCase.expectedCode, code, err)
+			}
+		})
+	}
+}
+
+func TestPipeline_RunWeightedRecords(t *testing.T) {
+	// Per user LTV of 3 users cohort is 1, 2, 3 and of a single user is 5, 10, 15
+	// Weighted daily averages are 2, 4, 6 and unweighted ones are 3, 6, 9
+	content := `[{"CampaignId":"a","Country":"DE","Ltv":[3,6,9],"Users":3},` +
+		`{"CampaignId":"b","Country":"DE","Ltv":[5,10,15],"Users":1}]`
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []*types.Result
+	}{
+		{
+			name:     "Weighted",
+			expected: []*types.Result{types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}}, []types.Prediction{{Day: 10, Value: 20}})},
+		},
+		{
+			name:     "Unweighted",
+			opts:     []Option{WithUnweighted()},
+			expected: []*types.Result{types.NewResult("DE", []types.Dimension{{Name: cnst.AggregateCountry, Value: "DE"}}, []types.Prediction{{Day: 10, Value: 30}})},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			path := filepath.Join(t.TempDir(), "data.json")
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatalf("Failed to write file [%s]", err.Error())
+			}
+			p := NewPipeline(testCase.opts...).
+				Source([]string{path}, "").
+				Aggregator(cnst.AggregateCountry).
+				Predictor(cnst.LinearExtrapolationPredictorModel, []uint{10})
+
+			/* ACT */
+			results, err := p.Run(context.Background())
+
+			/* ASSERT */
+			if err != nil {
+				t.Fatalf("Run() : unexpected error [%v]", err)
+			}
+			if len(results) != 1 || results[0].Label() != "DE" || math.Abs(results[0].Predictions()[0].Value-testCase.expected[0].Predictions()[0].Value) > 1e-9 {
+				t.Fatalf("Run() exp: %+v\ngot: %+v", testCase.expected, results)
+			}
+		})
+	}
+}
+
+// scaledTestData creates temporary csv file holding docs test data rows repeated the provided number of times
+func scaledTestData(tb testing.TB, times int) string {
+	data, err := os.ReadFile(filepath.Join("..", "..", "docs", "testdata", "test_data.csv"))
+	if err != nil {
+		tb.Fatalf("Failed to read test data [%s]", err.Error())
+	}
+	header, rows, _ := strings.Cut(string(data), "\n")
+	rows = strings.TrimRight(rows, "\n") + "\n"
+
+	path := filepath.Join(tb.TempDir(), "test_data.csv")
+	content := header + "\n" + strings.Repeat(rows, times)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		tb.Fatalf("Failed to write file [%s]", err.Error())
+	}
+	return path
+}
+
+func TestPipeline_RunSeveralWorkers(t *testing.T) {
+	path := scaledTestData(t, 3)
+
+	for _, aggregate := range []string{cnst.AggregateCountry, cnst.AggregateCampaign, cnst.AggregateCampaign + cnst.AggregateSeparator + cnst.AggregateCountry} {
+		t.Run(aggregate, func(t *testing.T) {
+			/* ARRANGE */
+			run := func(workers int) map[string][]types.Prediction {
+				results, err := NewPipeline(WithWorkers(workers)).
+					Source([]string{path}, "").
+					Aggregator(aggregate).
+					Predictor(cnst.LinearExtrapolationPredictorModel, []uint{30, 60}).
+					Run(context.Background())
+				if err != nil {
+					t.Fatalf("Run() : unexpected error [%v]", err)
+				}
+				predictions := map[string][]types.Prediction{}
+				for _, result := range results {
+					predictions[result.Label()] = result.Predictions()
+				}
+				return predictions
+			}
+
+			/* ACT */
+			expected := run(1)
+			results := run(4)
+
+			/* ASSERT */
+			// Per key records order is kept, so the float sums and predictions are exactly the same
+			if len(expected) == 0 || !reflect.DeepEqual(results, expected) {
+				t.Fatalf("Run() exp: %+v\ngot: %+v", expected, results)
+			}
+		})
+	}
+}
+
+func BenchmarkPipeline_Run(b *testing.B) {
+	path := scaledTestData(b, 50)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := NewPipeline(WithWorkers(workers)).
+					Source([]string{path}, "").
+					Aggregator(cnst.AggregateCampaign+cnst.AggregateSeparator+cnst.AggregateCountry).
+					Predictor(cnst.LinearExtrapolationPredictorModel, []uint{60}).
+					Run(context.Background())
+				if err != nil {
+					b.Fatalf("Run() : unexpected error [%v]", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_RunMissingSource(t *testing.T) {
+	/* ARRANGE */
+	p := NewPipeline().
+		Source([]string{"no_no_no_ExistFile.csv"}, "").
+		Aggregator(cnst.AggregateCountry).
+		Predictor(cnst.LinearExtrapolationPredictorModel, []uint{10})
+
+	/* ACT */
+	results, err := p.Run(context.Background())
+
+	/* ASSERT */
+	var configErr *cerror.ConfigError
+	if results != nil || !errors.As(err, &configErr) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Run() : expected no results and missing file error, got %v [%v]", results, err)
+	}
+}
+
+func TestPipeline_RunCancelledContext(t *testing.T) {
+	/* ARRANGE */
+	path := createTempCSV(t, "UserId,CampaignId,Country,Ltv1,Ltv2\n1,a,DE,1,2\n")
+	p := NewPipeline().
+		Source([]string{path}, "").
+		Aggregator(cnst.AggregateCountry).
+		Predictor(cnst.LinearExtrapolationPredictorModel, []uint{10})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	/* ACT */
+	results, err := p.Run(ctx)
+
+	/* ASSERT */
+	if results != nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() : expected no results and cancel error, got %v [%v]", results, err)
+	}
+}
+
+func TestPipeline_RunCancelledContextWithPartialResults(t *testing.T) {
+	/* ARRANGE */
+	path := createTempCSV(t, "UserId,CampaignId,Country,Ltv1,Ltv2\n1,a,DE,1,2\n")
+	p := NewPipeline(WithPartialResults()).
+		Source([]string{path}, "").
+		Aggregator(cnst.AggregateCountry).
+		Predictor(cnst.LinearExtrapolationPredictorModel, []uint{10})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	/* ACT */
+	results, err := p.Run(ctx)
+
+	/* ASSERT */
+	// Nothing is read before cancellation, partial results are empty but not nil
+	if results == nil || len(results) != 0 {
+		t.Fatalf("Run() : expected empty partial results, got %v", results)
+	}
+	var stageErr *cerror.StageError
+	if !errors.Is(err, context.Canceled) || !errors.As(err, &stageErr) || stageErr.Stage != cnst.PipelineStage {
+		t.Fatalf("Run() : expected pipeline interruption error, got %v", err)
+	}
+}
+
+func TestPipeline_RunInvalidStageTimeout(t *testing.T) {
+	/* ARRANGE */
+	path := createTempCSV(t, "UserId,CampaignId,Country,Ltv1,Ltv2\n1,a,DE,1,2\n")
+	p := NewPipeline(WithStageTimeouts(map[string]time.Duration{"foo": time.Second})).
+		Source([]string{path}, "").
+		Aggregator(cnst.AggregateCountry).
+		Predictor(cnst.LinearExtrapolationPredictorModel, []uint{10})
+
+	/* ACT */
+	results, err := p.Run(context.Background())
+
+	/* ASSERT */
+	var configErr *cerror.ConfigError
+	if results != nil || !errors.As(err, &configErr) || configErr.Param != cnst.CliStageTimeoutParam {
+		t.Fatalf("Run() : expected no results and stage timeout config error, got %v [%v]", results, err)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	/* ARRANGE */
+	// Unbuffered channels block abandoned runners sends unless they are drained
+	errorCh := types.NewErrorChannel(0)
+	postProcCh := types.NewPostProcessorChannel(0)
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			errorCh <- cerror.NewCustomError("late error")
+			postProcCh <- types.NewResult("JP", nil, nil)
+		}
+		close(postProcCh)
+		close(errorCh)
+		close(sent)
+	}()
+
+	/* ACT */
+	drained := make(chan struct{})
+	go func() {
+		drain(errorCh, postProcCh)
+		close(drained)
+	}()
+
+	/* ASSERT */
+	for _, done := range []chan struct{}{sent, drained} {
+		select {
+		case <-done:
+		case <-time.After(1 * time.Second):
+			t.Fatalf("drain() : timeout")
+		}
+	}
+}
+
+func TestPipeline_RunBacktest(t *testing.T) {
+	/* ARRANGE */
+	// DE history is linear, US history flattens out after the cutoff day
+	path := createTempCSV(t, "UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3,Ltv4,Ltv5\n"+
+		"1,a,DE,1,2,3,4,5\n"+
+		"2,b,US,2,4,6,7,7\n")
+	p := NewPipeline().
+		Source([]string{path}, "").
+		Aggregator(cnst.AggregateCountry).
+		Backtest([]string{cnst.LinearExtrapolationPredictorModel}, 3, []uint{5})
+	expectedMetrics := map[string][]types.Metric{
+		"DE": {{Name: cnst.MetricMae, Value: 0}, {Name: cnst.MetricRmse, Value: 0}, {Name: cnst.MetricMape, Value: 0}, {Name: cnst.MetricBias, Value: 0}},
+		"US": {{Name: cnst.MetricMae, Value: 3}, {Name: cnst.MetricRmse, Value: 3}, {Name: cnst.MetricMape, Value: 300.0 / 7}, {Name: cnst.MetricBias, Value: 3}},
+		cnst.BacktestAllKeys: {{Name: cnst.MetricMae, Value: 1.5}, {Name: cnst.MetricRmse, Value: math.Sqrt(4.5)},
+			{Name: cnst.MetricMape, Value: 150.0 / 7}, {Name: cnst.MetricBias, Value: 1.5}},
+	}
+
+	/* ACT */
+	results, err := p.Run(context.Background())
+
+	/* ASSERT */
+	if err != nil || len(results) != len(expectedMetrics) {
+		t.Fatalf("Run() : expected %d results, got %v [%v]", len(expectedMetrics), results, err)
+	}
+	for _, result := range results {
+		expected := expectedMetrics[result.Label()]
+		if result.Model() != cnst.LinearExtrapolationPredictorModel || len(result.Metrics()) != len(expected) {
+			t.Fatalf("Run() unexpected result: %+v", result)
+		}
+		for i, metric := range result.Metrics() {
+			if metric.Name != expected[i].Name || math.Abs(metric.Value-expected[i].Value) > 1e-9 {
+				t.Fatalf("Run() %s exp: %+v\ngot: %+v", result.Label(), expected, result.Metrics())
+			}
+		}
+	}
+}
+
+func TestPipeline_RunBacktestSkippedKeys(t *testing.T) {
+	/* ARRANGE */
+	// JP has no known values of the backtest day, it is reported and the other keys are still evaluated
+	path := createTempCSV(t, "UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3,Ltv4,Ltv5\n"+
+		"1,a,DE,1,2,3,4,5\n"+
+		"2,b,JP,1,2,3,0,0\n")
+	errs := collector.NewCollector()
+	p := NewPipeline(WithErrorCollector(errs)).
+		Source([]string{path}, "").
+		Aggregator(cnst.AggregateCountry).
+		Backtest([]string{cnst.LinearExtrapolationPredictorModel}, 3, []uint{5})
+
+	/* ACT */
+	results, err := p.Run(context.Background())
+
+	/* ASSERT */
+	if len(results) != 2 || results[0].Label() != "DE" || results[1].Label() != cnst.BacktestAllKeys {
+		t.Fatalf("Run() : expected DE and all keys results, got %v [%v]", results, err)
+	}
+	var stageErr *cerror.StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != cnst.BacktestStage || stageErr.Source != "JP" || errs.ExitCode() != cnst.ExitStageError {
+		t.Fatalf("Run() : expected JP backtest stage error, got %v", err)
+	}
+}
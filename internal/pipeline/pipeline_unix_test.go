@@ -0,0 +1,68 @@
+//go:build unix
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// createFifo creates named pipe without writer, opening it for reading blocks like a hung network mount
+// The reader is released on the test cleanup
+func createFifo(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "hung.csv")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Fatalf("Failed to create fifo [%s]", err.Error())
+	}
+	t.Cleanup(func() {
+		if writer, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			writer.Close()
+		}
+	})
+	return path
+}
+
+func TestPipeline_RunTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "RunTimeout",
+			opts:     []Option{WithTimeout(20 * time.Millisecond)},
+			expected: "error: datasource: run timeout 20ms exceeded, 0 records read: context deadline exceeded",
+		},
+		{
+			name:     "StageTimeout",
+			opts:     []Option{WithStageTimeouts(map[string]time.Duration{cnst.DataSourceStage: 20 * time.Millisecond})},
+			expected: "error: datasource: stage timeout 20ms exceeded, 0 records read: context deadline exceeded",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			p := NewPipeline(testCase.opts...).
+				Source([]string{createFifo(t)}, "").
+				Aggregator(cnst.AggregateCountry).
+				Predictor(cnst.LinearExtrapolationPredictorModel, []uint{10})
+			p.shutdownTimeout = 10 * time.Millisecond
+
+			/* ACT */
+			results, err := p.Run(context.Background())
+
+			/* ASSERT */
+			// Blocked data source is abandoned, the error names it
+			if results != nil || err == nil || err.Error() != testCase.expected || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Run() : expected no results and error [%s], got %v [%v]", testCase.expected, results, err)
+			}
+		})
+	}
+}
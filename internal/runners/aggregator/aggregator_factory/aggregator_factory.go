@@ -1,32 +1,49 @@
 package aggregator_factory
 
 import (
+	"context"
 	"fmt"
 	cnst "playground/internal/constants"
 	"playground/internal/runners/aggregator/runner"
 	campaign "playground/internal/runners/aggregator/strategy/campaign"
+	"playground/internal/runners/aggregator/strategy/composite"
 	country "playground/internal/runners/aggregator/strategy/country"
 	"playground/internal/runners/common"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"strings"
 	"sync"
 )
 
 // NewRunner creates a new data aggregator runner to aggregate records
-// According to aggregator parameter
+// According to aggregator parameter, comma separated parameter produces composite key
+// Workers number sets the number of key sharded aggregation goroutines
+// Weighted runner accumulates records LTV weighted by the number of users, otherwise each record weighs 1
 func NewRunner(
+	ctx context.Context,
 	wg *sync.WaitGroup,
+	workers int,
+	weighted bool,
 	aggregate string,
 	recordCh t.RecordChannel,
-	aggregateCh t.AggregatorChannel) (common.IRunner, error) {
+	aggregateCh t.AggregatorChannel,
+	errorCh t.ErrorChannel) (common.IRunner, error) {
 
 	// General Factory logic, create data aggregator according to aggregate parameter
 	switch aggregate {
 	case cnst.AggregateCampaign:
-		return runner.NewAggregatorRunner(wg, recordCh, aggregateCh, campaign.NewCampaignAggregatorStrategy())
+		return runner.NewAggregatorRunner(ctx, wg, workers, weighted, recordCh, aggregateCh, errorCh, campaign.NewCampaignAggregatorStrategy())
 	case cnst.AggregateCountry:
-		return runner.NewAggregatorRunner(wg, recordCh, aggregateCh, country.NewCountryAggregatorStrategy())
+		return runner.NewAggregatorRunner(ctx, wg, workers, weighted, recordCh, aggregateCh, errorCh, country.NewCountryAggregatorStrategy())
 	default:
-		return nil, cerror.NewCustomError(fmt.Sprintf("%q invalid aggregate parameter", aggregate))
+		dimensions := strings.Split(aggregate, cnst.AggregateSeparator)
+		if len(dimensions) > 1 {
+			strategy, err := composite.NewCompositeAggregatorStrategy(dimensions)
+			if err != nil {
+				return nil, err
+			}
+			return runner.NewAggregatorRunner(ctx, wg, workers, weighted, recordCh, aggregateCh, errorCh, strategy)
+		}
+		return nil, cerror.NewConfigError(cnst.CliAggregateParam, aggregate, fmt.Sprintf("%q invalid aggregate parameter", aggregate))
 	}
 }
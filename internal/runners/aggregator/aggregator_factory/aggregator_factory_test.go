@@ -1,6 +1,7 @@
 package aggregator_factory
 
 import (
+	"context"
 	"fmt"
 	cnst "playground/internal/constants"
 	"playground/internal/types"
@@ -34,6 +35,16 @@ func TestNewAggregator(t *testing.T) {
 			name:      "AggregateCountryParameter",
 			aggregate: cnst.AggregateCountry,
 		},
+		{
+			name:      "AggregateCountryCampaignParameter",
+			aggregate: cnst.AggregateCountry + cnst.AggregateSeparator + cnst.AggregateCampaign,
+		},
+		{
+			name:          "InvalidCompositeAggregatorParameter",
+			aggregate:     cnst.AggregateCountry + cnst.AggregateSeparator + InvalidAggregateParameter,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid aggregate dimension", InvalidAggregateParameter)).Error(),
+		},
 	}
 
 	for _, testCase := range tests {
@@ -43,9 +54,10 @@ func TestNewAggregator(t *testing.T) {
 			wg := &sync.WaitGroup{}
 			recordCh := types.NewRecordChannel(0)
 			aggregateCh := types.NewAggregatorChannel(0)
+			errorCh := types.NewErrorChannel(0)
 
 			/* ACT */
-			_, err := NewRunner(wg, testCase.aggregate, recordCh, aggregateCh)
+			_, err := NewRunner(context.Background(), wg, 1, true, testCase.aggregate, recordCh, aggregateCh, errorCh)
 
 			/* ASSERT */
 			// Assert expected error string
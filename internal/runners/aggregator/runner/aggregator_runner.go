@@ -1,45 +1,75 @@
 package runner
 
 import (
+	"context"
 	log "github.com/sirupsen/logrus"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/progress"
+	"playground/internal/utils/shard"
+	"strconv"
 	"sync"
 )
 
 // countryAggregator represents a data aggregator backed by a "country" aggregate parameter
 type countryAggregator struct {
+	ctx                 context.Context
 	wg                  *sync.WaitGroup
+	workers             int
+	weighted            bool
 	recordCh            t.RecordChannel
 	aggregatedCh        t.AggregatorChannel
+	errorCh             t.ErrorChannel
 	aggregationStrategy t.AggregatorStrategy
 }
 
 // NewAggregatorRunner initializes and returns countryAggregator
-// Returns error if some of wg, recordCh, aggregatedCh, aggregationStrategy is nil
+// Invalid records are reported to errorCh and skipped, errorCh isn't closed by the runner
+// Cancelled ctx stops the runner, the aggregate channel is closed then
+// Several workers aggregate records sharded by key, aggregated data is sent in the received records order
+// Weighted runner passes the record weight to the aggregated data, records with non-positive weight are reported and skipped
+// Returns error if some of ctx, wg, recordCh, aggregatedCh, errorCh, aggregationStrategy is nil or workers number is less than 1
 func NewAggregatorRunner(
+	ctx context.Context,
 	wg *sync.WaitGroup,
+	workers int,
+	weighted bool,
 	recordCh t.RecordChannel,
 	aggregateCh t.AggregatorChannel,
+	errorCh t.ErrorChannel,
 	aggregationStrategy t.AggregatorStrategy) (*countryAggregator, error) {
 
+	if ctx == nil {
+		return nil, cerror.NewConfigError("context", "", "invalid context")
+	}
 	if wg == nil {
-		return nil, cerror.NewCustomError("invalid wait group")
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
+	}
+	if workers < 1 {
+		return nil, cerror.NewConfigError("workers", strconv.Itoa(workers), "invalid workers number")
 	}
 	if recordCh == nil {
-		return nil, cerror.NewCustomError("invalid record channel")
+		return nil, cerror.NewConfigError("record channel", "", "invalid record channel")
 	}
 	if aggregateCh == nil {
-		return nil, cerror.NewCustomError("invalid aggregate channel")
+		return nil, cerror.NewConfigError("aggregate channel", "", "invalid aggregate channel")
+	}
+	if errorCh == nil {
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
 	}
 	if aggregationStrategy == nil {
-		return nil, cerror.NewCustomError("invalid aggregation strategy")
+		return nil, cerror.NewConfigError("aggregation strategy", "", "invalid aggregation strategy")
 	}
 
 	return &countryAggregator{
+		ctx:                 ctx,
 		wg:                  wg,
+		workers:             workers,
+		weighted:            weighted,
 		recordCh:            recordCh,
 		aggregatedCh:        aggregateCh,
+		errorCh:             errorCh,
 		aggregationStrategy: aggregationStrategy,
 	}, nil
 }
@@ -49,19 +79,158 @@ func (r *countryAggregator) Run() {
 	defer close(r.aggregatedCh)
 	defer r.wg.Done()
 
-	// Read records until record channel is open
-	for record := range r.recordCh {
-		// Received cancel event
-		if record == nil {
-			log.Warning("aggregator runner shutdown")
+	// Received records are reported to the pipeline progress tracker, if any
+	stage := progress.FromContext(r.ctx).Stage(cnst.AggregatorStage)
+	defer stage.Finish()
+
+	if r.workers > 1 {
+		r.runSharded(stage)
+		return
+	}
 
-			// Notify next runner about cancel event
-			r.aggregatedCh <- nil
+	// Read records until record channel is open or cancel event received
+	for {
+		select {
+		case <-r.ctx.Done():
+			log.Warning("aggregator runner shutdown")
 			return
+
+		case record, ok := <-r.recordCh:
+			if !ok {
+				log.Debug("aggregator runner finished work")
+				return
+			}
+			stage.Inc()
+
+			if !r.send(r.aggregate(record)) {
+				return
+			}
 		}
+	}
+}
 
-		// Send aggregated data to next runner
-		r.aggregatedCh <- r.aggregationStrategy(record)
+// runSharded aggregates records in several shard workers and sends aggregated data in the received records order
+// Shard is chosen by the record campaign and country, so records of any key made of them are handled by the same worker
+// Shard of each record is queued in the received order, the shard results are merged back in the same order,
+// so the aggregated data is the same as of a single worker
+func (r *countryAggregator) runSharded(stage *progress.Stage) {
+	// Dispatcher and shard workers are stopped once the runner returns
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+
+	shards := make([]t.RecordChannel, r.workers)
+	results := make([]t.AggregatorChannel, r.workers)
+	for i := range shards {
+		shards[i] = t.NewRecordChannel(cnst.AggregatorShardChannelBuffer)
+		results[i] = t.NewAggregatorChannel(cnst.AggregatorShardChannelBuffer)
+		go r.runShard(ctx, shards[i], results[i])
+	}
+	order := make(chan int, r.workers*cnst.AggregatorShardChannelBuffer)
+	go r.dispatch(ctx, shards, order)
+
+	// Merge shard results in the received records order until dispatching is finished or cancel event received
+	for i := range order {
+		select {
+		case aggData := <-results[i]:
+			stage.Inc()
+			if !r.send(aggData) {
+				return
+			}
+		case <-ctx.Done():
+			log.Warning("aggregator runner shutdown")
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		log.Warning("aggregator runner shutdown")
+		return
 	}
 	log.Debug("aggregator runner finished work")
 }
+
+// dispatch sends records to their shards until record channel is open or cancel event received
+// Shard index of each sent record is queued to order, order and shard channels are closed once dispatching is finished
+func (r *countryAggregator) dispatch(ctx context.Context, shards []t.RecordChannel, order chan<- int) {
+	defer close(order)
+	defer func() {
+		for _, shardCh := range shards {
+			close(shardCh)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case record, ok := <-r.recordCh:
+			if !ok {
+				return
+			}
+			i := shard.Index(record.CampaignId()+cnst.AggregateSeparator+record.Country(), len(shards))
+			select {
+			case order <- i:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case shards[i] <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// runShard aggregates the shard records until shard channel is open or cancel event received
+func (r *countryAggregator) runShard(ctx context.Context, shardCh t.RecordChannel, resultCh t.AggregatorChannel) {
+	for record := range shardCh {
+		select {
+		case resultCh <- r.aggregate(record):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// aggregate applies the aggregation strategy to the record, weighted runner passes the record weight to the aggregated data
+func (r *countryAggregator) aggregate(record *t.Record) *t.AggregatedData {
+	aggData := r.aggregationStrategy(record)
+	if r.weighted {
+		aggData.SetWeight(record.Weight())
+	}
+	return aggData
+}
+
+// send validates and sends aggregated data to next runner
+// Returns false if cancel event received
+func (r *countryAggregator) send(aggData *t.AggregatedData) bool {
+	// Non-finite LTV would spoil the whole key aggregate, report and skip the record
+	if !aggData.Ltv().Finite() {
+		r.errorCh <- &cerror.StageError{
+			Stage:  cnst.AggregatorStage,
+			Source: aggData.Key(),
+			Reason: "non-finite ltv data skipped",
+		}
+		return true
+	}
+
+	// Weight is the number of users, e.g. JSON record without users has no LTV to accumulate
+	if !(aggData.Weight() > 0) {
+		r.errorCh <- &cerror.StageError{
+			Stage:  cnst.AggregatorStage,
+			Source: aggData.Key(),
+			Reason: "non-positive record weight skipped",
+		}
+		return true
+	}
+
+	// Send aggregated data to next runner, cancel event interrupts waiting for it
+	select {
+	case r.aggregatedCh <- aggData:
+		return true
+	case <-r.ctx.Done():
+		log.Warning("aggregator runner shutdown")
+		return false
+	}
+}
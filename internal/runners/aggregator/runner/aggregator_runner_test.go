@@ -1,11 +1,15 @@
 package runner
 
 import (
+	c "context"
+	"math"
+	cnst "playground/internal/constants"
 	"playground/internal/runners/aggregator/strategy/campaign"
 	"playground/internal/runners/aggregator/strategy/country"
 	tp "playground/internal/types"
 	"playground/internal/utils/cerror"
 	"reflect"
+	"strconv"
 	s "sync"
 	"testing"
 	"time"
@@ -16,9 +20,11 @@ const (
 )
 
 type inputParameters struct {
+	ctx      c.Context
 	wg       *s.WaitGroup
 	rCh      tp.RecordChannel
 	aCh      tp.AggregatorChannel
+	eCh      tp.ErrorChannel
 	strategy tp.AggregatorStrategy
 }
 
@@ -34,27 +40,39 @@ func TestNewAggregatorRunner_InvalidInputParams(t *testing.T) {
 		expectedResult newAggregatorResult
 		expectedError  bool
 	}{
+		{
+			name:           "noContext",
+			input:          inputParameters{nil, &s.WaitGroup{}, nil, nil, nil, nil},
+			expectedResult: newAggregatorResult{aggregator: nil, err: cerror.NewCustomError("invalid context")},
+			expectedError:  true,
+		},
 		{
 			name:           "noWaitGroup",
-			input:          inputParameters{nil, nil, nil, nil},
+			input:          inputParameters{c.Background(), nil, nil, nil, nil, nil},
 			expectedResult: newAggregatorResult{aggregator: nil, err: cerror.NewCustomError("invalid wait group")},
 			expectedError:  true,
 		},
 		{
 			name:           "noRecordChannel",
-			input:          inputParameters{&s.WaitGroup{}, nil, nil, nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, nil, nil, nil, nil},
 			expectedResult: newAggregatorResult{aggregator: nil, err: cerror.NewCustomError("invalid record channel")},
 			expectedError:  true,
 		},
 		{
 			name:           "noAggregateChannel",
-			input:          inputParameters{&s.WaitGroup{}, tp.NewRecordChannel(0), nil, nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewRecordChannel(0), nil, nil, nil},
 			expectedResult: newAggregatorResult{aggregator: nil, err: cerror.NewCustomError("invalid aggregate channel")},
 			expectedError:  true,
 		},
+		{
+			name:           "noErrorChannel",
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewRecordChannel(0), tp.NewAggregatorChannel(0), nil, nil},
+			expectedResult: newAggregatorResult{aggregator: nil, err: cerror.NewCustomError("invalid error channel")},
+			expectedError:  true,
+		},
 		{
 			name:           "noAggregateStrategy",
-			input:          inputParameters{&s.WaitGroup{}, tp.NewRecordChannel(0), tp.NewAggregatorChannel(0), nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewRecordChannel(0), tp.NewAggregatorChannel(0), tp.NewErrorChannel(0), nil},
 			expectedResult: newAggregatorResult{aggregator: nil, err: cerror.NewCustomError("invalid aggregation strategy")},
 			expectedError:  true,
 		},
@@ -64,7 +82,7 @@ func TestNewAggregatorRunner_InvalidInputParams(t *testing.T) {
 			/* ARRANGE */
 
 			/* ACT */
-			result, err := NewAggregatorRunner(testCase.input.wg, testCase.input.rCh, testCase.input.aCh, testCase.input.strategy)
+			result, err := NewAggregatorRunner(testCase.input.ctx, testCase.input.wg, 1, true, testCase.input.rCh, testCase.input.aCh, testCase.input.eCh, testCase.input.strategy)
 
 			/* ASSERT */
 			// Assert expected error
@@ -92,14 +110,16 @@ func TestNewAggregatorRunner_InvalidInputParams(t *testing.T) {
 func TestNewAggregatorRunner_ValidInputParamsCountryStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
 		tp.NewRecordChannel(0),
 		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
 		country.NewCountryAggregatorStrategy(),
 	}
 
 	/* ACT */
-	result, err := NewAggregatorRunner(in.wg, in.rCh, in.aCh, in.strategy)
+	result, err := NewAggregatorRunner(in.ctx, in.wg, 1, true, in.rCh, in.aCh, in.eCh, in.strategy)
 	// Assert unexpected error
 	if err != nil {
 		t.Fatalf("NewAggregatorRunner() : expected error string [%v], got [%v]", nil, err)
@@ -114,9 +134,11 @@ func TestNewAggregatorRunner_ValidInputParamsCountryStrategy(t *testing.T) {
 func TestNewAggregatorRunner_RunWithCountryStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
 		tp.NewRecordChannel(0),
 		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
 		country.NewCountryAggregatorStrategy(),
 	}
 	// Prepare records and expected aggregated data
@@ -133,7 +155,7 @@ func TestNewAggregatorRunner_RunWithCountryStrategy(t *testing.T) {
 	}
 
 	in.wg.Add(1)
-	aggregator, _ := NewAggregatorRunner(in.wg, in.rCh, in.aCh, in.strategy)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 1, true, in.rCh, in.aCh, in.eCh, in.strategy)
 
 	/* ACT */
 	// Mock record streamer
@@ -175,32 +197,34 @@ func TestNewAggregatorRunner_RunWithCountryStrategy(t *testing.T) {
 
 func TestNewAggregatorRunner_RunWithCountryStrategyAndCancelEvent(t *testing.T) {
 	/* ARRANGE */
+	ctx, cancel := c.WithCancel(c.Background())
+	defer cancel()
 	in := inputParameters{
+		ctx,
 		&s.WaitGroup{},
 		tp.NewRecordChannel(0),
 		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
 		country.NewCountryAggregatorStrategy(),
 	}
-	// Prepare records and expected aggregated data
+	// Prepare records and expected aggregated data, cancel event is sent once they are aggregated
 	records := []*tp.Record{
 		tp.NewRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "JP", tp.LtvCollection{1.73305638789404, 1.7684248856061633, 2.781764692566589, 0, 0, 0, 0}),
 		tp.NewRecord("6325253f-ec73-4dd7-a9e2-8bf921119c16", "US", tp.LtvCollection{1.9466884664338124, 3.166483202629052, 4.892883942338033, 0, 0, 0, 0}),
-		nil,
 	}
 
 	expectedAggregatedData := []*tp.AggregatedData{}
-	for _, record := range records[0 : len(records)-1] {
+	for _, record := range records {
 		agg := tp.NewAggregatedData(record.Country(), record.Ltv())
 		expectedAggregatedData = append(expectedAggregatedData, agg)
 	}
 
 	in.wg.Add(1)
-	aggregator, _ := NewAggregatorRunner(in.wg, in.rCh, in.aCh, in.strategy)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 1, true, in.rCh, in.aCh, in.eCh, in.strategy)
 
 	/* ACT */
-	// Mock record streamer
+	// Mock record streamer, record channel stays open
 	go func() {
-		defer close(in.rCh)
 		for _, record := range records {
 			in.rCh <- record
 		}
@@ -213,14 +237,6 @@ func TestNewAggregatorRunner_RunWithCountryStrategyAndCancelEvent(t *testing.T)
 		// Assert expected aggregated data
 		case result, ok := <-in.aCh:
 			if ok {
-				// Assert cancel event
-				if result == nil {
-					if len(expectedAggregatedData) != 0 {
-						t.Fatalf("Run() unexpected aggregated data slice len exp: %+v\ngot: %+v", 0, len(expectedAggregatedData))
-					}
-					return
-				}
-
 				// Assert result
 				expected := expectedAggregatedData[0]
 				if !reflect.DeepEqual(expected, result) {
@@ -229,6 +245,11 @@ func TestNewAggregatorRunner_RunWithCountryStrategyAndCancelEvent(t *testing.T)
 				// Remove 1 element, slice as a queue )
 				expectedAggregatedData = expectedAggregatedData[1:]
 
+				// Invoke cancel, aggregate channel is expected to be closed
+				if len(expectedAggregatedData) == 0 {
+					cancel()
+				}
+
 			} else {
 				// Assert empty expected aggregated data list
 				if len(expectedAggregatedData) != 0 {
@@ -246,9 +267,11 @@ func TestNewAggregatorRunner_RunWithCountryStrategyAndCancelEvent(t *testing.T)
 func TestNewAggregatorRunner_RunWithCampaignStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
 		tp.NewRecordChannel(0),
 		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
 		campaign.NewCampaignAggregatorStrategy(),
 	}
 	// Prepare records and expected aggregated data
@@ -265,7 +288,7 @@ func TestNewAggregatorRunner_RunWithCampaignStrategy(t *testing.T) {
 	}
 
 	in.wg.Add(1)
-	aggregator, _ := NewAggregatorRunner(in.wg, in.rCh, in.aCh, in.strategy)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 1, true, in.rCh, in.aCh, in.eCh, in.strategy)
 
 	/* ACT */
 	// Mock record streamer
@@ -307,32 +330,34 @@ func TestNewAggregatorRunner_RunWithCampaignStrategy(t *testing.T) {
 
 func TestNewAggregatorRunner_RunWithCampaignStrategyAndCancelEvent(t *testing.T) {
 	/* ARRANGE */
+	ctx, cancel := c.WithCancel(c.Background())
+	defer cancel()
 	in := inputParameters{
+		ctx,
 		&s.WaitGroup{},
 		tp.NewRecordChannel(0),
 		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
 		campaign.NewCampaignAggregatorStrategy(),
 	}
-	// Prepare records and expected aggregated data
+	// Prepare records and expected aggregated data, cancel event is sent once they are aggregated
 	records := []*tp.Record{
 		tp.NewRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "JP", tp.LtvCollection{1.73305638789404, 1.7684248856061633, 2.781764692566589, 0, 0, 0, 0}),
 		tp.NewRecord("6325253f-ec73-4dd7-a9e2-8bf921119c16", "US", tp.LtvCollection{1.9466884664338124, 3.166483202629052, 4.892883942338033, 0, 0, 0, 0}),
-		nil,
 	}
 
 	expectedAggregatedData := []*tp.AggregatedData{}
-	for _, record := range records[0 : len(records)-1] {
+	for _, record := range records {
 		agg := tp.NewAggregatedData(record.CampaignId(), record.Ltv())
 		expectedAggregatedData = append(expectedAggregatedData, agg)
 	}
 
 	in.wg.Add(1)
-	aggregator, _ := NewAggregatorRunner(in.wg, in.rCh, in.aCh, in.strategy)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 1, true, in.rCh, in.aCh, in.eCh, in.strategy)
 
 	/* ACT */
-	// Mock record streamer
+	// Mock record streamer, record channel stays open
 	go func() {
-		defer close(in.rCh)
 		for _, record := range records {
 			in.rCh <- record
 		}
@@ -345,14 +370,6 @@ func TestNewAggregatorRunner_RunWithCampaignStrategyAndCancelEvent(t *testing.T)
 		// Assert expected aggregated data
 		case result, ok := <-in.aCh:
 			if ok {
-				// Assert cancel event
-				if result == nil {
-					if len(expectedAggregatedData) != 0 {
-						t.Fatalf("Run() unexpected aggregated data slice len exp: %+v\ngot: %+v", 0, len(expectedAggregatedData))
-					}
-					return
-				}
-
 				// Assert result
 				expected := expectedAggregatedData[0]
 				if !reflect.DeepEqual(expected, result) {
@@ -361,6 +378,11 @@ func TestNewAggregatorRunner_RunWithCampaignStrategyAndCancelEvent(t *testing.T)
 				// Remove 1 element, slice as a queue )
 				expectedAggregatedData = expectedAggregatedData[1:]
 
+				// Invoke cancel, aggregate channel is expected to be closed
+				if len(expectedAggregatedData) == 0 {
+					cancel()
+				}
+
 			} else {
 				// Assert empty expected aggregated data list
 				if len(expectedAggregatedData) != 0 {
@@ -374,3 +396,247 @@ func TestNewAggregatorRunner_RunWithCampaignStrategyAndCancelEvent(t *testing.T)
 		}
 	}
 }
+
+func TestNewAggregatorRunner_RunWithNonFiniteLtvRecord(t *testing.T) {
+	/* ARRANGE */
+	in := inputParameters{
+		c.Background(),
+		&s.WaitGroup{},
+		tp.NewRecordChannel(0),
+		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
+		country.NewCountryAggregatorStrategy(),
+	}
+	// Prepare records, JP record LTV is divided by zero users
+	records := []*tp.Record{
+		tp.NewRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "JP", tp.LtvCollection{math.Inf(1), math.NaN()}),
+		tp.NewRecord("6325253f-ec73-4dd7-a9e2-8bf921119c16", "US", tp.LtvCollection{1.9466884664338124, 3.166483202629052}),
+	}
+	expectedAggregatedData := []*tp.AggregatedData{tp.NewAggregatedData("US", records[1].Ltv())}
+	errorStr := (&cerror.StageError{Stage: cnst.AggregatorStage, Source: "JP", Reason: "non-finite ltv data skipped"}).Error()
+
+	in.wg.Add(1)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 1, true, in.rCh, in.aCh, in.eCh, in.strategy)
+
+	/* ACT */
+	// Mock record streamer
+	go func() {
+		defer close(in.rCh)
+		for _, record := range records {
+			in.rCh <- record
+		}
+	}()
+	go aggregator.Run()
+
+	/* ASSERT */
+	reported := false
+	for {
+		select {
+		// Assert expected aggregated data
+		case result, ok := <-in.aCh:
+			if ok {
+				if len(expectedAggregatedData) == 0 || !reflect.DeepEqual(expectedAggregatedData[0], result) {
+					t.Fatalf("Run() unexpected aggregated data: %+v", result)
+				}
+				expectedAggregatedData = expectedAggregatedData[1:]
+			} else {
+				if !reported || len(expectedAggregatedData) != 0 {
+					t.Fatalf("Run() expected error reported and all data sent, reported: %v, left: %+v", reported, expectedAggregatedData)
+				}
+				return
+			}
+			// Assert expected error data
+		case err := <-in.eCh:
+			if err.Error() != errorStr {
+				t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+			}
+			reported = true
+			// Assert potential hang situation
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
+
+func TestNewAggregatorRunner_InvalidWorkers(t *testing.T) {
+	/* ARRANGE */
+	errorStr := cerror.NewCustomError("invalid workers number").Error()
+
+	/* ACT */
+	result, err := NewAggregatorRunner(c.Background(), &s.WaitGroup{}, 0, true, tp.NewRecordChannel(0), tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0), country.NewCountryAggregatorStrategy())
+
+	/* ASSERT */
+	if result != nil || err == nil || err.Error() != errorStr {
+		t.Fatalf("NewAggregatorRunner() : expected error string [%s], got %v [%v]", errorStr, result, err)
+	}
+}
+
+func TestNewAggregatorRunner_RunSeveralWorkers(t *testing.T) {
+	/* ARRANGE */
+	in := inputParameters{
+		c.Background(),
+		&s.WaitGroup{},
+		tp.NewRecordChannel(0),
+		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(1),
+		country.NewCountryAggregatorStrategy(),
+	}
+	// Prepare records of several keys, LTV value holds the record number
+	// Records of the same country have several campaigns, so they are spread across the shards
+	countries := []string{"JP", "US", "DE", "TR", "FR", "IT"}
+	records := []*tp.Record{}
+	for i := 0; i < 100*len(countries); i++ {
+		records = append(records, tp.NewRecord(strconv.Itoa(i%7), countries[i%len(countries)], tp.LtvCollection{float64(i)}))
+	}
+	// Non-finite record is reported and skipped
+	records = append(records, tp.NewRecord("", "JP", tp.LtvCollection{math.NaN()}))
+	errorStr := (&cerror.StageError{Stage: cnst.AggregatorStage, Source: "JP", Reason: "non-finite ltv data skipped"}).Error()
+
+	in.wg.Add(1)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 4, true, in.rCh, in.aCh, in.eCh, in.strategy)
+
+	/* ACT */
+	go func() {
+		defer close(in.rCh)
+		for _, record := range records {
+			in.rCh <- record
+		}
+	}()
+	go aggregator.Run()
+
+	received := []*tp.AggregatedData{}
+	for aggData := range in.aCh {
+		received = append(received, aggData)
+	}
+
+	/* ASSERT */
+	// Shard results are merged back, so all the records are expected in the received order
+	if len(received) != 100*len(countries) {
+		t.Fatalf("Run() expected %d records, got %d", 100*len(countries), len(received))
+	}
+	for i, aggData := range received {
+		if aggData.Key() != countries[i%len(countries)] || aggData.Ltv()[0] != float64(i) {
+			t.Fatalf("Run() unexpected record %d: %+v", i, aggData)
+		}
+	}
+	select {
+	case err := <-in.eCh:
+		if err.Error() != errorStr {
+			t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+		}
+	default:
+		t.Fatalf("Run() : expected error [%s]", errorStr)
+	}
+}
+
+func TestNewAggregatorRunner_RunSeveralWorkersWithCancelEvent(t *testing.T) {
+	/* ARRANGE */
+	ctx, cancel := c.WithCancel(c.Background())
+	in := inputParameters{
+		ctx,
+		&s.WaitGroup{},
+		tp.NewRecordChannel(0),
+		tp.NewAggregatorChannel(0),
+		tp.NewErrorChannel(0),
+		country.NewCountryAggregatorStrategy(),
+	}
+
+	in.wg.Add(1)
+	aggregator, _ := NewAggregatorRunner(in.ctx, in.wg, 4, true, in.rCh, in.aCh, in.eCh, in.strategy)
+
+	/* ACT */
+	// Record channel stays open, the first record is received before the cancel event
+	go aggregator.Run()
+	in.rCh <- tp.NewRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "JP", tp.LtvCollection{1, 2})
+	first := <-in.aCh
+	cancel()
+
+	/* ASSERT */
+	if first.Key() != "JP" {
+		t.Fatalf("Run() unexpected aggregated data: %+v", first)
+	}
+	select {
+	case _, ok := <-in.aCh:
+		if ok {
+			t.Fatalf("Run() expected aggregate channel closed")
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Run() : timeout")
+	}
+}
+
+// withWeight returns aggregated data with the weight set
+func withWeight(aggData *tp.AggregatedData, weight float64) *tp.AggregatedData {
+	aggData.SetWeight(weight)
+	return aggData
+}
+
+func TestNewAggregatorRunner_RunWithRecordWeights(t *testing.T) {
+	// US record has no users, so it has nothing to accumulate in weighted mode
+	records := []*tp.Record{
+		tp.NewWeightedRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "JP", tp.LtvCollection{1, 2}, 3),
+		tp.NewWeightedRecord("6325253f-ec73-4dd7-a9e2-8bf921119c16", "US", tp.LtvCollection{2, 4}, 0),
+	}
+
+	tests := []struct {
+		name                   string
+		weighted               bool
+		expectedAggregatedData []*tp.AggregatedData
+		expectedErrors         []string
+	}{
+		{
+			name:                   "Weighted",
+			weighted:               true,
+			expectedAggregatedData: []*tp.AggregatedData{withWeight(tp.NewAggregatedData("JP", records[0].Ltv()), 3)},
+			expectedErrors: []string{
+				(&cerror.StageError{Stage: cnst.AggregatorStage, Source: "US", Reason: "non-positive record weight skipped"}).Error(),
+			},
+		},
+		{
+			name:     "Unweighted",
+			weighted: false,
+			expectedAggregatedData: []*tp.AggregatedData{
+				tp.NewAggregatedData("JP", records[0].Ltv()),
+				tp.NewAggregatedData("US", records[1].Ltv()),
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			rCh := tp.NewRecordChannel(uint(len(records)))
+			aCh := tp.NewAggregatorChannel(uint(len(records)))
+			eCh := tp.NewErrorChannel(uint(len(records)))
+			wg := &s.WaitGroup{}
+			for _, record := range records {
+				rCh <- record
+			}
+			close(rCh)
+
+			wg.Add(1)
+			aggregator, _ := NewAggregatorRunner(c.Background(), wg, 1, testCase.weighted, rCh, aCh, eCh, country.NewCountryAggregatorStrategy())
+
+			/* ACT */
+			aggregator.Run()
+			close(eCh)
+
+			/* ASSERT */
+			var aggregated []*tp.AggregatedData
+			for aggData := range aCh {
+				aggregated = append(aggregated, aggData)
+			}
+			var errs []string
+			for err := range eCh {
+				errs = append(errs, err.Error())
+			}
+			if !reflect.DeepEqual(aggregated, testCase.expectedAggregatedData) {
+				t.Fatalf("Run() exp: %+v\ngot: %+v", testCase.expectedAggregatedData, aggregated)
+			}
+			if !reflect.DeepEqual(errs, testCase.expectedErrors) {
+				t.Fatalf("Run() exp errors: %v\ngot: %v", testCase.expectedErrors, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package composite
+
+import (
+	"fmt"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"strings"
+)
+
+// dimensionGetters maps aggregation dimension name to Record dimension value getter
+var dimensionGetters = map[string]func(record *t.Record) string{
+	cnst.AggregateCampaign: (*t.Record).CampaignId,
+	cnst.AggregateCountry:  (*t.Record).Country,
+}
+
+// NewCompositeAggregatorStrategy returns Record aggregation strategy, accorded to several dimensions key
+// Dimension values are kept in the same order as provided dimensions
+// Returns error in cases of unknown or duplicated dimension
+func NewCompositeAggregatorStrategy(dimensions []string) (t.AggregatorStrategy, error) {
+	getters := make([]func(record *t.Record) string, 0, len(dimensions))
+	known := map[string]bool{}
+	for _, dimension := range dimensions {
+		dimension = strings.TrimSpace(dimension)
+		getter, found := dimensionGetters[dimension]
+		if !found || known[dimension] {
+			return nil, cerror.NewConfigError(cnst.CliAggregateParam, dimension, fmt.Sprintf("%q invalid aggregate dimension", dimension))
+		}
+		known[dimension] = true
+		getters = append(getters, getter)
+	}
+
+	return func(record *t.Record) *t.AggregatedData {
+		values := make([]string, 0, len(getters))
+		for _, getter := range getters {
+			values = append(values, getter(record))
+		}
+		return t.NewCompositeAggregatedData(values, record.Ltv())
+	}, nil
+}
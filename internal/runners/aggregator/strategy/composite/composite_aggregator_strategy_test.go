@@ -0,0 +1,104 @@
+package composite
+
+import (
+	"fmt"
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"reflect"
+	"testing"
+)
+
+func TestNewCompositeAggregatorStrategy_InvalidDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		dimensions []string
+		errorStr   string
+	}{
+		{
+			name:       "unknownDimension",
+			dimensions: []string{cnst.AggregateCountry, "city"},
+			errorStr:   cerror.NewCustomError(fmt.Sprintf("%q invalid aggregate dimension", "city")).Error(),
+		},
+		{
+			name:       "duplicatedDimension",
+			dimensions: []string{cnst.AggregateCountry, cnst.AggregateCampaign, cnst.AggregateCountry},
+			errorStr:   cerror.NewCustomError(fmt.Sprintf("%q invalid aggregate dimension", cnst.AggregateCountry)).Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+
+			/* ACT */
+			strategy, err := NewCompositeAggregatorStrategy(testCase.dimensions)
+
+			/* ASSERT */
+			if strategy != nil {
+				t.Fatalf("NewCompositeAggregatorStrategy() with args %v: unexpected strategy", testCase.dimensions)
+			}
+			if err == nil || err.Error() != testCase.errorStr {
+				t.Fatalf("NewCompositeAggregatorStrategy() with args %v: expected error string [%s], got [%v]", testCase.dimensions, testCase.errorStr, err)
+			}
+		})
+	}
+}
+
+func TestNewCompositeAggregatorStrategy(t *testing.T) {
+	/* ARRANGE */
+	// Prepare records and expected aggregated data
+	records := []*tp.Record{
+		tp.NewRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "JP", tp.LtvCollection{1.73305638789404, 1.7684248856061633, 2.781764692566589}),
+		tp.NewRecord("6325253f-ec73-4dd7-a9e2-8bf921119c16", "US", tp.LtvCollection{1.9466884664338124, 3.166483202629052, 4.892883942338033}),
+	}
+	expectedAggregatedData := []*tp.AggregatedData{}
+	for _, record := range records {
+		agg := tp.NewCompositeAggregatedData([]string{record.Country(), record.CampaignId()}, record.Ltv())
+		expectedAggregatedData = append(expectedAggregatedData, agg)
+	}
+
+	/* ACT */
+	strategy, err := NewCompositeAggregatorStrategy([]string{cnst.AggregateCountry, " " + cnst.AggregateCampaign})
+
+	/* ASSERT */
+	if err != nil {
+		t.Fatalf("NewCompositeAggregatorStrategy() : unexpected error [%s]", err.Error())
+	}
+	for i, data := range records {
+		aggregatedResult := strategy(data)
+		if !reflect.DeepEqual(expectedAggregatedData[i], aggregatedResult) {
+			t.Fatalf("NewCompositeAggregatorStrategy() exp: %+v\ngot: %+v",
+				expectedAggregatedData[i], aggregatedResult)
+		}
+		expectedKey := data.Country() + cnst.AggregateKeySeparator + data.CampaignId()
+		if aggregatedResult.Key() != expectedKey {
+			t.Fatalf("NewCompositeAggregatorStrategy() key exp: %+v\ngot: %+v", expectedKey, aggregatedResult.Key())
+		}
+	}
+}
+
+func TestNewCompositeAggregatorStrategy_KeySeparatorInValues(t *testing.T) {
+	/* ARRANGE */
+	// Dimension values containing key separator would produce the same key if not escaped
+	records := []*tp.Record{
+		tp.NewRecord("b", "a|", tp.LtvCollection{1}),
+		tp.NewRecord("|b", "a", tp.LtvCollection{1}),
+		tp.NewRecord("b", `a\`, tp.LtvCollection{1}),
+	}
+	strategy, err := NewCompositeAggregatorStrategy([]string{cnst.AggregateCountry, cnst.AggregateCampaign})
+	if err != nil {
+		t.Fatalf("NewCompositeAggregatorStrategy() : unexpected error [%s]", err.Error())
+	}
+
+	/* ACT */
+	keys := map[string]bool{}
+	for _, record := range records {
+		keys[strategy(record).Key()] = true
+	}
+
+	/* ASSERT */
+	if len(keys) != len(records) {
+		t.Fatalf("NewCompositeAggregatorStrategy() keys collision: expected %d keys, got %v", len(records), keys)
+	}
+}
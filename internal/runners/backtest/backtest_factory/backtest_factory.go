@@ -0,0 +1,37 @@
+package backtest_factory
+
+import (
+	"context"
+	br "playground/internal/runners/backtest/runner"
+	"playground/internal/runners/common"
+	"playground/internal/runners/postprocessor/postprocessor_factory"
+	"playground/internal/runners/predictor/predictor_factory"
+	t "playground/internal/types"
+	"sync"
+)
+
+// NewRunner creates a new backtest runner to evaluate models on aggregated data
+// Models are fitted on the LTV days up to the cutoff day and evaluated on the known values of the days
+// According to models parameter, empty models mean all registered models
+// According to aggregate parameter, comma separated parameter produces composite key columns
+func NewRunner(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	aggregate string,
+	models []string,
+	cutoff uint,
+	days []uint,
+	aggregateCh t.AggregatorChannel,
+	postCh t.PostProcessorChannel,
+	errorCh t.ErrorChannel) (common.IRunner, error) {
+
+	backtestModels, err := predictor_factory.Models(models)
+	if err != nil {
+		return nil, err
+	}
+	strategy, err := postprocessor_factory.NewStrategy(aggregate)
+	if err != nil {
+		return nil, err
+	}
+	return br.NewBacktestRunner(ctx, wg, cutoff, days, backtestModels, aggregateCh, postCh, errorCh, strategy)
+}
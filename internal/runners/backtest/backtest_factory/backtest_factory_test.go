@@ -0,0 +1,92 @@
+package backtest_factory
+
+import (
+	"context"
+	"fmt"
+	cnst "playground/internal/constants"
+	"playground/internal/types"
+	"playground/internal/utils/cerror"
+	"sync"
+	"testing"
+)
+
+const (
+	InvalidModelParameter     = "PredictSomethingUnpredictable"
+	InvalidAggregateParameter = "AggregateSomethingUnaggregatable"
+)
+
+func TestNewRunner(t *testing.T) {
+	tests := []struct {
+		name          string
+		aggregate     string
+		models        []string
+		cutoff        uint
+		days          []uint
+		expectedError bool
+		errorStr      string
+	}{
+		{
+			name:          "InvalidModelParameter",
+			aggregate:     cnst.AggregateCountry,
+			models:        []string{cnst.LinearExtrapolationPredictorModel, InvalidModelParameter},
+			cutoff:        7,
+			days:          []uint{30},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid model parameter", InvalidModelParameter)).Error(),
+		},
+		{
+			name:          "InvalidAggregateParameter",
+			aggregate:     InvalidAggregateParameter,
+			cutoff:        7,
+			days:          []uint{30},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid postprocessor parameter", InvalidAggregateParameter)).Error(),
+		},
+		{
+			name:          "DayBeforeCutoff",
+			aggregate:     cnst.AggregateCountry,
+			cutoff:        7,
+			days:          []uint{30, 7},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError("7 backtest day isn't after cutoff day 7").Error(),
+		},
+		{
+			name:      "AllModels",
+			aggregate: cnst.AggregateCountry,
+			cutoff:    7,
+			days:      []uint{30},
+		},
+		{
+			name:      "SelectedModelsCompositeKey",
+			aggregate: cnst.AggregateCampaign + cnst.AggregateSeparator + cnst.AggregateCountry,
+			models:    []string{cnst.PowerLawPredictorModel, cnst.LogarithmicPredictorModel},
+			cutoff:    3,
+			days:      []uint{7, 14},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Prepare input parameters
+			wg := &sync.WaitGroup{}
+			aggregateCh := types.NewAggregatorChannel(0)
+			postCh := types.NewPostProcessorChannel(0)
+			errorCh := types.NewErrorChannel(0)
+
+			/* ACT */
+			_, err := NewRunner(context.Background(), wg, testCase.aggregate, testCase.models, testCase.cutoff, testCase.days, aggregateCh, postCh, errorCh)
+
+			/* ASSERT */
+			// Assert expected error string
+			if (err != nil) && (err.Error() != testCase.errorStr) {
+				t.Fatalf("NewRunner() : expected error string [%s], got [%s]", testCase.errorStr, err.Error())
+			}
+
+			// Assert expected error
+			if (err != nil) != testCase.expectedError {
+				t.Fatalf("NewRunner() : expected error %v, got %v", testCase.expectedError, err != nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"math"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/predictor"
+	"playground/internal/utils/progress"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// keyHistory represents key LTV history and the key dimensions
+type keyHistory struct {
+	dimensions []string
+	ltv        predictor.LtvAverages
+}
+
+// modelErrors collects predicted and actual values of all evaluated keys of a model
+type modelErrors struct {
+	actual    []float64
+	predicted []float64
+}
+
+// backtestRunner represents models evaluation against the known LTV history
+type backtestRunner struct {
+	ctx              context.Context
+	wg               *sync.WaitGroup
+	cutoff           uint
+	days             []uint
+	models           []predictor.Model
+	aggregatorCh     t.AggregatorChannel
+	postProcessorCh  t.PostProcessorChannel
+	errorCh          t.ErrorChannel
+	postProcStrategy t.PostProcessorStrategy
+}
+
+// NewBacktestRunner initializes and returns backtestRunner
+// Models are fitted on the LTV days up to the cutoff day and evaluated on the known values of the later days
+// Results of each key and model are sent in the key order, followed by each model results on all keys
+// Keys without values up to the cutoff day, without known values of the days or without finite prediction
+// are reported to errorCh and skipped
+// Cancelled ctx doesn't stop reading, aggregatorCh is drained and the results are sent as partial results
+// Returns error if some of ctx, wg, aggregatorCh, postProcessorCh, errorCh, postProcStrategy is nil,
+// models or days are empty, cutoff is 0 or some of the days isn't after the cutoff day
+func NewBacktestRunner(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	cutoff uint,
+	days []uint,
+	models []predictor.Model,
+	aggregatorCh t.AggregatorChannel,
+	postProcessorCh t.PostProcessorChannel,
+	errorCh t.ErrorChannel,
+	postProcStrategy t.PostProcessorStrategy) (*backtestRunner, error) {
+
+	if ctx == nil {
+		return nil, cerror.NewConfigError("context", "", "invalid context")
+	}
+	if wg == nil {
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
+	}
+	if cutoff == 0 {
+		return nil, cerror.NewConfigError(cnst.CliCutoffParam, "0", "invalid cutoff day")
+	}
+	if len(days) == 0 {
+		return nil, cerror.NewConfigError("backtest days", "", "invalid backtest days")
+	}
+	for _, day := range days {
+		if day <= cutoff {
+			value := strconv.FormatUint(uint64(day), 10)
+			return nil, cerror.NewConfigError(cnst.CliDaysParam, value, fmt.Sprintf("%s backtest day isn't after cutoff day %d", value, cutoff))
+		}
+	}
+	if len(models) == 0 {
+		return nil, cerror.NewConfigError("backtest models", "", "invalid backtest models")
+	}
+	if aggregatorCh == nil {
+		return nil, cerror.NewConfigError("aggregator channel", "", "invalid aggregator channel")
+	}
+	if postProcessorCh == nil {
+		return nil, cerror.NewConfigError("postprocessor channel", "", "invalid postprocessor channel")
+	}
+	if errorCh == nil {
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
+	}
+	if postProcStrategy == nil {
+		return nil, cerror.NewConfigError("postprocessor strategy", "", "invalid postprocessor strategy")
+	}
+
+	return &backtestRunner{
+		ctx:              ctx,
+		wg:               wg,
+		cutoff:           cutoff,
+		days:             days,
+		models:           models,
+		aggregatorCh:     aggregatorCh,
+		postProcessorCh:  postProcessorCh,
+		errorCh:          errorCh,
+		postProcStrategy: postProcStrategy,
+	}, nil
+}
+
+// Run interface implementation, evaluates models on each key history
+func (r *backtestRunner) Run() {
+	defer close(r.postProcessorCh)
+	defer r.wg.Done()
+
+	// Received aggregated records are reported to the pipeline progress tracker, if any
+	stage := progress.FromContext(r.ctx).Stage(cnst.BacktestStage)
+	defer stage.Finish()
+
+	// Read and store key histories until aggregator channel is open
+	// Cancel event doesn't stop reading, the keys received so far are evaluated
+	histories := map[string]*keyHistory{}
+	for aggData := range r.aggregatorCh {
+		stage.Inc()
+		history, found := histories[aggData.Key()]
+		if !found {
+			history = &keyHistory{dimensions: aggData.Dimensions()}
+			histories[aggData.Key()] = history
+		}
+		history.ltv.AddWeighted(aggData.Ltv(), aggData.Weight())
+	}
+
+	// Results of cancelled run are partial results
+	partial := r.ctx.Err() != nil
+	if partial {
+		log.Warning("backtest runner shutdown")
+	}
+
+	keys := make([]string, 0, len(histories))
+	for key := range histories {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	totals := make([]modelErrors, len(r.models))
+	var dimensions []string
+	for _, key := range keys {
+		if r.evaluate(key, histories[key], totals, partial) {
+			dimensions = histories[key].dimensions
+		}
+	}
+
+	// Model results on all evaluated keys
+	if dimensions == nil {
+		return
+	}
+	all := make([]string, 0, len(dimensions))
+	for range dimensions {
+		all = append(all, cnst.BacktestAllKeys)
+	}
+	for i, model := range r.models {
+		if len(totals[i].actual) != 0 {
+			r.send(all, model.Name, totals[i].actual, totals[i].predicted, partial)
+		}
+	}
+	log.Debug("backtest runner finished work")
+}
+
+// evaluate predicts the key days by each model on the history up to the cutoff day and sends the key results
+// Predicted and actual values are added to the model totals, returns false if the key isn't evaluated
+func (r *backtestRunner) evaluate(key string, history *keyHistory, totals []modelErrors, partial bool) bool {
+	train := history.ltv.AveragesUntil(int(r.cutoff))
+	if len(train) == 0 {
+		r.report(key, "no ltv data up to the cutoff day", partial)
+		return false
+	}
+	days := make([]uint, 0, len(r.days))
+	actual := make([]float64, 0, len(r.days))
+	for _, day := range r.days {
+		if value, found := history.ltv.Average(int(day)); found {
+			days = append(days, day)
+			actual = append(actual, value)
+		}
+	}
+	if len(days) == 0 {
+		r.report(key, "no known ltv values of the backtest days", partial)
+		return false
+	}
+
+	evaluated := false
+	for i, model := range r.models {
+		predicted := make([]float64, 0, len(days))
+		for _, day := range days {
+			predicted = append(predicted, model.Curve(train, float64(day)))
+		}
+		if !finite(predicted) {
+			r.report(key, fmt.Sprintf("not enough ltv data to predict by %s model", model.Name), partial)
+			continue
+		}
+
+		totals[i].actual = append(totals[i].actual, actual...)
+		totals[i].predicted = append(totals[i].predicted, predicted...)
+		r.send(history.dimensions, model.Name, actual, predicted, partial)
+		evaluated = true
+	}
+	return evaluated
+}
+
+// report sends the key evaluation error, keys of cancelled run are expected to lack data, they are skipped silently
+func (r *backtestRunner) report(key, reason string, partial bool) {
+	if partial {
+		return
+	}
+	r.errorCh <- &cerror.StageError{
+		Stage:  cnst.BacktestStage,
+		Source: key,
+		Reason: reason,
+	}
+}
+
+// send converts the model errors to result according to postprocessor strategy and sends it, marked partial if requested
+func (r *backtestRunner) send(dimensions []string, model string, actual, predicted []float64, partial bool) {
+	result := r.postProcStrategy(t.NewCompositePredictedData(dimensions, nil))
+	result.SetModel(model)
+	result.SetMetrics([]t.Metric{
+		{Name: cnst.MetricMae, Value: predictor.MeanAbsoluteError(actual, predicted)},
+		{Name: cnst.MetricRmse, Value: predictor.RootMeanSquaredError(actual, predicted)},
+		{Name: cnst.MetricMape, Value: predictor.MeanAbsolutePercentageError(actual, predicted)},
+		{Name: cnst.MetricBias, Value: predictor.MeanError(actual, predicted)},
+	})
+	if partial {
+		result.MarkPartial()
+	}
+	r.postProcessorCh <- result
+}
+
+// finite returns false if some of the values is NaN or infinity
+func finite(values []float64) bool {
+	for _, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+	}
+	return true
+}
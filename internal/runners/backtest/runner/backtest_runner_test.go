@@ -0,0 +1,206 @@
+package runner
+
+import (
+	c "context"
+	cnst "playground/internal/constants"
+	"playground/internal/runners/postprocessor/strategy/country"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/predictor"
+	"reflect"
+	s "sync"
+	"testing"
+	"time"
+)
+
+// lastValue model predicts the last known value for any day
+var lastValue = predictor.Model{Name: "last", Curve: func(data []float64, day float64) float64 { return data[len(data)-1] }}
+
+var linext = predictor.Model{Name: cnst.LinearExtrapolationPredictorModel, Curve: predictor.LinearExtrapolation}
+
+// result returns expected backtest result of the country
+func result(country, model string, mae, rmse, mape, bias float64) *tp.Result {
+	result := tp.NewResult(country, []tp.Dimension{{Name: cnst.AggregateCountry, Value: country}}, nil)
+	result.SetModel(model)
+	result.SetMetrics([]tp.Metric{
+		{Name: cnst.MetricMae, Value: mae},
+		{Name: cnst.MetricRmse, Value: rmse},
+		{Name: cnst.MetricMape, Value: mape},
+		{Name: cnst.MetricBias, Value: bias},
+	})
+	return result
+}
+
+func TestNewBacktestRunner_InvalidInputParams(t *testing.T) {
+	models := []predictor.Model{linext}
+	tests := []struct {
+		name     string
+		cutoff   uint
+		days     []uint
+		models   []predictor.Model
+		errorStr string
+	}{
+		{name: "zeroCutoff", cutoff: 0, days: []uint{10}, models: models, errorStr: "invalid cutoff day"},
+		{name: "noDays", cutoff: 3, days: nil, models: models, errorStr: "invalid backtest days"},
+		{name: "dayNotAfterCutoff", cutoff: 3, days: []uint{10, 2}, models: models, errorStr: "2 backtest day isn't after cutoff day 3"},
+		{name: "noModels", cutoff: 3, days: []uint{10}, models: nil, errorStr: "invalid backtest models"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			result, err := NewBacktestRunner(c.Background(), &s.WaitGroup{}, testCase.cutoff, testCase.days, testCase.models,
+				tp.NewAggregatorChannel(0), tp.NewPostProcessorChannel(0), tp.NewErrorChannel(0), country.NewPostProcessorStrategy())
+
+			/* ASSERT */
+			if err == nil || err.Error() != cerror.NewCustomError(testCase.errorStr).Error() {
+				t.Fatalf("NewBacktestRunner() : expected error string [%s], got [%v]", testCase.errorStr, err)
+			}
+			if result != nil {
+				t.Fatalf("NewBacktestRunner() : expected nil runner, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestNewBacktestRunner_Run(t *testing.T) {
+	/* ARRANGE */
+	aggregatorCh := tp.NewAggregatorChannel(0)
+	postCh := tp.NewPostProcessorChannel(0)
+	errorCh := tp.NewErrorChannel(0)
+	wg := &s.WaitGroup{}
+	// Days after the cutoff day 3 are known for US and JP, FR history ends at the cutoff day
+	aggregated := []*tp.AggregatedData{
+		tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3, 4, 5}),
+		tp.NewAggregatedData("JP", tp.LtvCollection{2, 4, 6, 8, 0}),
+		tp.NewAggregatedData("FR", tp.LtvCollection{1, 2, 3}),
+		tp.NewAggregatedData("JP", tp.LtvCollection{2, 4, 6, 8, 10}),
+	}
+	// Keys are sent in order, each key model results are followed by the model results on all keys
+	// Last value model errors: US day 4 and 5 -1, -2, JP day 4 and 5 -2, -4
+	expectedResults := []*tp.Result{
+		result("JP", linext.Name, 0, 0, 0, 0),
+		result("JP", lastValue.Name, 3, 3.1622776601683795, 32.5, -3),
+		result("US", linext.Name, 0, 0, 0, 0),
+		result("US", lastValue.Name, 1.5, 1.5811388300841898, 32.5, -1.5),
+		result(cnst.BacktestAllKeys, linext.Name, 0, 0, 0, 0),
+		result(cnst.BacktestAllKeys, lastValue.Name, 2.25, 2.5, 32.5, -2.25),
+	}
+	errorStr := (&cerror.StageError{Stage: cnst.BacktestStage, Source: "FR", Reason: "no known ltv values of the backtest days"}).Error()
+
+	wg.Add(1)
+	backtest, err := NewBacktestRunner(c.Background(), wg, 3, []uint{4, 5}, []predictor.Model{linext, lastValue},
+		aggregatorCh, postCh, errorCh, country.NewPostProcessorStrategy())
+	if err != nil {
+		t.Fatalf("NewBacktestRunner() unexpected error: %v", err)
+	}
+
+	/* ACT */
+	// Mock aggregated data streamer
+	go func() {
+		defer close(aggregatorCh)
+		for _, aggData := range aggregated {
+			aggregatorCh <- aggData
+		}
+	}()
+	go backtest.Run()
+
+	/* ASSERT */
+	results := []*tp.Result{}
+	reported := false
+	for {
+		select {
+		case result, ok := <-postCh:
+			if ok {
+				results = append(results, result)
+				continue
+			}
+			// Assert results and reported error
+			if !reflect.DeepEqual(expectedResults, results) {
+				t.Fatalf("Run() exp: %+v\ngot: %+v", expectedResults, results)
+			}
+			if !reported {
+				t.Fatalf("Run() expected FR error reported")
+			}
+			return
+		case err := <-errorCh:
+			if err.Error() != errorStr {
+				t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+			}
+			reported = true
+			// Assert potential hang situation
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
+
+func TestNewBacktestRunner_RunWithCancelEvent(t *testing.T) {
+	/* ARRANGE */
+	// Cancel event is received before the aggregator channel is closed
+	ctx, cancel := c.WithCancel(c.Background())
+	cancel()
+	aggregatorCh := tp.NewAggregatorChannel(1)
+	postCh := tp.NewPostProcessorChannel(0)
+	wg := &s.WaitGroup{}
+	aggregatorCh <- tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3, 4, 5})
+	close(aggregatorCh)
+
+	// Results of the keys received so far are marked partial
+	expectedResults := []*tp.Result{
+		result("US", linext.Name, 0, 0, 0, 0),
+		result(cnst.BacktestAllKeys, linext.Name, 0, 0, 0, 0),
+	}
+	for _, expected := range expectedResults {
+		expected.MarkPartial()
+	}
+
+	wg.Add(1)
+	backtest, _ := NewBacktestRunner(ctx, wg, 3, []uint{5}, []predictor.Model{linext},
+		aggregatorCh, postCh, tp.NewErrorChannel(0), country.NewPostProcessorStrategy())
+
+	/* ACT */
+	go backtest.Run()
+
+	/* ASSERT */
+	results := []*tp.Result{}
+	for result := range postCh {
+		results = append(results, result)
+	}
+	if !reflect.DeepEqual(expectedResults, results) {
+		t.Fatalf("Run() exp: %+v\ngot: %+v", expectedResults, results)
+	}
+}
+
+func TestNewBacktestRunner_RunNoDataUntilCutoff(t *testing.T) {
+	/* ARRANGE */
+	// DE has no values up to the cutoff day, it is reported and skipped instead of fitting the model on nothing
+	average := predictor.Model{Name: cnst.AveragePredictorModel, Curve: predictor.Average}
+	aggregatorCh := tp.NewAggregatorChannel(2)
+	postCh := tp.NewPostProcessorChannel(0)
+	errorCh := tp.NewErrorChannel(1)
+	wg := &s.WaitGroup{}
+	aggregatorCh <- tp.NewAggregatedData("DE", tp.LtvCollection{0, 0, 0, 4, 5})
+	aggregatorCh <- tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3, 4, 5})
+	close(aggregatorCh)
+	errorStr := (&cerror.StageError{Stage: cnst.BacktestStage, Source: "DE", Reason: "no ltv data up to the cutoff day"}).Error()
+
+	wg.Add(1)
+	backtest, _ := NewBacktestRunner(c.Background(), wg, 3, []uint{5}, []predictor.Model{average},
+		aggregatorCh, postCh, errorCh, country.NewPostProcessorStrategy())
+
+	/* ACT */
+	go backtest.Run()
+
+	/* ASSERT */
+	labels := []string{}
+	for result := range postCh {
+		labels = append(labels, result.Label())
+	}
+	if !reflect.DeepEqual(labels, []string{"US", cnst.BacktestAllKeys}) {
+		t.Fatalf("Run() exp US and all keys results\ngot: %v", labels)
+	}
+	if err := <-errorCh; err.Error() != errorStr {
+		t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+	}
+}
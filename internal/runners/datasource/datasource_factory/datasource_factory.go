@@ -9,36 +9,170 @@ import (
 	"playground/internal/runners/common"
 	"playground/internal/runners/datasource/runner/csv"
 	"playground/internal/runners/datasource/runner/json"
+	"playground/internal/runners/datasource/runner/jsonl"
+	"playground/internal/runners/datasource/runner/multi"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/rejects"
+	"playground/internal/utils/source"
 	"sync"
 )
 
 // NewRunner creates a new data source runner to stream data
-// In data processing pipeline
+// In data processing pipeline, csvAliases map alternative CSV column names to canonical ones
+// Invalid CSV rows, JSON array elements and JSON lines are passed to rowErrors handler, nil handler fails on the first one
+// Sources could be file paths, glob patterns or "-" for stdin
+// Source format is taken from the file extension, format parameter applies to stdin and files without a known one
+// CSV and JSON Lines sources are parsed by workers goroutines, JSON array is decoded by a single one
 func NewRunner(
 	ctx context.Context,
 	wg *sync.WaitGroup,
-	filePath string,
+	sources []string,
+	format string,
+	csvAliases map[string]string,
+	rowErrors *rejects.Handler,
+	workers int,
 	recordCh t.RecordChannel,
 	errorCh t.ErrorChannel) (common.IRunner, error) {
 
-	// Check for file exists
-	_, err := os.Stat(filePath)
+	if format != "" {
+		if _, err := dataSourceType(cnst.SourceFormatExtPrefix + format); err != nil {
+			return nil, err
+		}
+	}
+	paths, err := expandSources(sources)
 	if err != nil {
-		return nil, cerror.NewCustomError(fmt.Sprintf("%q no such file", filePath))
+		return nil, err
+	}
+
+	newRunner := func(
+		ctx context.Context,
+		wg *sync.WaitGroup,
+		path string,
+		recordCh t.RecordChannel,
+		errorCh t.ErrorChannel) (common.IRunner, error) {
+		return newSourceRunner(ctx, wg, path, format, csvAliases, rowErrors, workers, recordCh, errorCh)
+	}
+
+	// Single source is read directly, several ones are fanned into the record channel
+	if len(paths) == 1 {
+		return newRunner(ctx, wg, paths[0], recordCh, errorCh)
+	}
+
+	// Validate all sources before the pipeline is started
+	for _, path := range paths {
+		if _, err := sourceExtension(path, format); err != nil {
+			return nil, err
+		}
 	}
+	return multi.NewDataSourceRunner(ctx, wg, paths, newRunner, recordCh, errorCh)
+}
 
-	// Get file extension
-	ext := filepath.Ext(filePath)
+// newSourceRunner creates a data source runner for a single source path
+func newSourceRunner(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	path string,
+	format string,
+	csvAliases map[string]string,
+	rowErrors *rejects.Handler,
+	workers int,
+	recordCh t.RecordChannel,
+	errorCh t.ErrorChannel) (common.IRunner, error) {
+
+	ext, err := sourceExtension(path, format)
+	if err != nil {
+		return nil, err
+	}
 
 	// General Factory logic, create data source depends on file extension
 	switch ext {
 	case cnst.CsvDataSource:
-		return csv.NewDataSourceRunner(ctx, wg, filePath, recordCh, errorCh)
+		return csv.NewDataSourceRunner(ctx, wg, path, csvAliases, rowErrors, workers, recordCh, errorCh)
 	case cnst.JsonDataSource:
-		return json.NewDataSourceRunner(ctx, wg, filePath, recordCh, errorCh)
+		return json.NewDataSourceRunner(ctx, wg, path, rowErrors, recordCh, errorCh)
+	case cnst.JsonlDataSource, cnst.NdjsonDataSource:
+		return jsonl.NewDataSourceRunner(ctx, wg, path, rowErrors, workers, recordCh, errorCh)
 	default:
-		return nil, cerror.NewCustomError(fmt.Sprintf("%q invalid data source type extension", ext))
+		return nil, cerror.NewConfigError(cnst.CliSourceFormatParam, ext, fmt.Sprintf("%q invalid data source type extension", ext))
+	}
+}
+
+// sourceExtension returns data source type extension, known file extension takes precedence over format parameter
+// Compression suffixes are skipped, e.g. "data.csv.gz" is a CSV source
+// Returns error if the format is unknown, stdin source requires format parameter
+func sourceExtension(path, format string) (string, error) {
+	ext := source.Ext(path)
+	if path != cnst.StdinSource {
+		if known, err := dataSourceType(ext); err == nil || format == "" {
+			return known, err
+		}
+	} else if format == "" {
+		return "", cerror.NewConfigError(cnst.CliSourceFormatParam, "", fmt.Sprintf("%q source format is required for stdin", cnst.CliSourceFormatParam))
+	}
+	return dataSourceType(cnst.SourceFormatExtPrefix + format)
+}
+
+// dataSourceType returns ext if it is a known data source type extension
+func dataSourceType(ext string) (string, error) {
+	switch ext {
+	case cnst.CsvDataSource, cnst.JsonDataSource, cnst.JsonlDataSource, cnst.NdjsonDataSource:
+		return ext, nil
+	default:
+		return "", cerror.NewConfigError(cnst.CliSourceFormatParam, ext, fmt.Sprintf("%q invalid data source type extension", ext))
+	}
+}
+
+// expandSources resolves glob patterns to file paths, stdin source is kept as is
+// Paths matched several times are read once, returns error if some of the sources doesn't match any file
+// Stdin could be read only once, so returns error if it is given several times
+func expandSources(sources []string) ([]string, error) {
+	if len(sources) == 0 {
+		return nil, cerror.NewConfigError(cnst.CliSourceParam, "", "invalid data sources list")
+	}
+
+	paths := make([]string, 0, len(sources))
+	seen := map[string]bool{}
+	for _, src := range sources {
+		if src == cnst.StdinSource {
+			if seen[src] {
+				return nil, cerror.NewConfigError(cnst.CliSourceParam, src, fmt.Sprintf("%q stdin source is given more than once", src))
+			}
+			seen[src] = true
+			paths = append(paths, src)
+			continue
+		}
+
+		matches, err := filepath.Glob(src)
+		if err != nil {
+			return nil, &cerror.ConfigError{
+				Param:   cnst.CliSourceParam,
+				Value:   src,
+				Message: fmt.Sprintf("%q invalid source pattern", src),
+				Err:     err,
+			}
+		}
+		// Not a pattern or no matches, source is checked as a plain path
+		if len(matches) == 0 {
+			matches = []string{src}
+		}
+
+		for _, path := range matches {
+			// Check for file exists
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				return nil, &cerror.ConfigError{
+					Param:   cnst.CliSourceParam,
+					Value:   path,
+					Message: fmt.Sprintf("%q no such file", path),
+					Err:     err,
+				}
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
 	}
+	return paths, nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	cnst "playground/internal/constants"
 	"playground/internal/types"
 	"playground/internal/utils/cerror"
 	"sync"
@@ -16,54 +17,145 @@ const (
 	UnsupportedFileExt = "*.acb"
 	ValidCsvFile       = "tmp.*.csv"
 	ValidJsonFile      = "tmp.*.json"
+	ValidJsonlFile     = "tmp.*.jsonl"
+	ValidNdjsonFile    = "tmp.*.ndjson"
+	ValidCsvGzipFile   = "tmp.*.csv.gz"
+	ValidJsonZstdFile  = "tmp.*.json.zst"
+	CompressedOnlyFile = "tmp*.bz2"
 )
 
 func TestNewDataSource(t *testing.T) {
 	// Prepare test data
-	validCsvFile, err := os.CreateTemp("", ValidCsvFile)
-	if err != nil {
-		t.Fatalf("Failed to create tmp csv file data [%s]", err.Error())
+	dir := t.TempDir()
+	createFile := func(pattern string) string {
+		f, err := os.CreateTemp(dir, pattern)
+		if err != nil {
+			t.Fatalf("Failed to create tmp file data [%s]", err.Error())
+		}
+		f.Close()
+		return f.Name()
 	}
-	defer os.Remove(validCsvFile.Name())
-
-	validJsonFile, err := os.CreateTemp("", ValidJsonFile)
-	if err != nil {
-		t.Fatalf("Failed to create tmp csv file data [%s]", err.Error())
-	}
-	defer os.Remove(validJsonFile.Name())
-
-	unsupportedFile, _ := os.CreateTemp("", UnsupportedFileExt)
-	if err != nil {
-		t.Fatalf("Failed to create unsupported tmp file data [%s]", err.Error())
-	}
-	defer os.Remove(unsupportedFile.Name())
+	validCsvFile := createFile(ValidCsvFile)
+	createFile(ValidCsvFile)
+	validJsonFile := createFile(ValidJsonFile)
+	validJsonlFile := createFile(ValidJsonlFile)
+	validNdjsonFile := createFile(ValidNdjsonFile)
+	validCsvGzipFile := createFile(ValidCsvGzipFile)
+	validJsonZstdFile := createFile(ValidJsonZstdFile)
+	compressedOnlyFile := createFile(CompressedOnlyFile)
+	unsupportedFile := createFile(UnsupportedFileExt)
 	ext := filepath.Ext(UnsupportedFileExt)
 
 	tests := []struct {
 		name          string
-		filePath      string
+		sources       []string
+		format        string
 		expectedError bool
 		errorStr      string
 	}{
+		{
+			name:          "NoSources",
+			sources:       []string{},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError("invalid data sources list").Error(),
+		},
 		{
 			name:          "FileDoesNotExist",
-			filePath:      NoExFile,
+			sources:       []string{NoExFile},
 			expectedError: true,
 			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q no such file", NoExFile)).Error(),
 		},
 		{
 			name:          "UnsupportedFileExtension",
-			filePath:      unsupportedFile.Name(),
+			sources:       []string{unsupportedFile},
 			expectedError: true,
 			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid data source type extension", ext)).Error(),
 		},
 		{
-			name:     "ValidCsvFile",
-			filePath: validCsvFile.Name(),
+			name:          "UnsupportedFileExtensionAmongSeveralFiles",
+			sources:       []string{validCsvFile, unsupportedFile},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid data source type extension", ext)).Error(),
+		},
+		{
+			name:    "ValidCsvFile",
+			sources: []string{validCsvFile},
+		},
+		{
+			name:    "ValidJsonFile",
+			sources: []string{validJsonFile},
+		},
+		{
+			name:    "ValidJsonlFile",
+			sources: []string{validJsonlFile},
+		},
+		{
+			name:    "ValidNdjsonFile",
+			sources: []string{validNdjsonFile},
+		},
+		{
+			name:    "ValidCompressedCsvFile",
+			sources: []string{validCsvGzipFile},
+		},
+		{
+			name:    "ValidCompressedJsonFile",
+			sources: []string{validJsonZstdFile},
+		},
+		{
+			name:          "CompressedFileWithoutDataExtension",
+			sources:       []string{compressedOnlyFile},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid data source type extension", "")).Error(),
+		},
+		{
+			name:    "ValidSeveralFiles",
+			sources: []string{validCsvFile, validJsonFile, validJsonlFile},
+		},
+		{
+			name:    "ValidGlobPattern",
+			sources: []string{filepath.Join(dir, ValidCsvFile)},
+		},
+		{
+			name:          "GlobPatternWithoutMatches",
+			sources:       []string{filepath.Join(dir, "*.parquet")},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q no such file", filepath.Join(dir, "*.parquet"))).Error(),
+		},
+		{
+			name:    "SourceFormatAppliedToUnknownExtension",
+			sources: []string{unsupportedFile},
+			format:  "jsonl",
 		},
 		{
-			name:     "ValidJsonFile",
-			filePath: validJsonFile.Name(),
+			name:    "SourceFormatAppliedToStdinOnly",
+			sources: []string{cnst.StdinSource, validCsvFile, validJsonFile},
+			format:  "jsonl",
+		},
+		{
+			name:          "InvalidSourceFormat",
+			sources:       []string{validCsvFile},
+			format:        "xml",
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid data source type extension", ".xml")).Error(),
+		},
+		{
+			name:    "StdinSource",
+			sources: []string{cnst.StdinSource},
+			format:  "csv",
+		},
+		{
+			name:          "StdinSourceWithoutFormat",
+			sources:       []string{cnst.StdinSource},
+			expectedError: true,
+			errorStr: cerror.NewCustomError(
+				fmt.Sprintf("%q source format is required for stdin", cnst.CliSourceFormatParam)).Error(),
+		},
+		{
+			name:          "StdinSourceGivenTwice",
+			sources:       []string{cnst.StdinSource, validCsvFile, cnst.StdinSource},
+			format:        "csv",
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q stdin source is given more than once", cnst.StdinSource)).Error(),
 		},
 	}
 
@@ -77,7 +169,7 @@ func TestNewDataSource(t *testing.T) {
 			errorCh := types.NewErrorChannel(0)
 
 			/* ACT */
-			_, err := NewRunner(ctx, wg, testCase.filePath, recordCh, errorCh)
+			_, err := NewRunner(ctx, wg, testCase.sources, testCase.format, nil, nil, 1, recordCh, errorCh)
 
 			/* ASSERT */
 			// Assert expected error string
@@ -92,3 +184,31 @@ func TestNewDataSource(t *testing.T) {
 		})
 	}
 }
+
+func TestSourceExtension(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		format      string
+		expectedExt string
+	}{
+		{name: "FileExtension", path: "data.json", expectedExt: cnst.JsonDataSource},
+		{name: "CompressedFileExtension", path: "data.jsonl.gz", expectedExt: cnst.JsonlDataSource},
+		{name: "FileExtensionTakesPrecedenceOverFormat", path: "data.json", format: "csv", expectedExt: cnst.JsonDataSource},
+		{name: "FormatOfUnknownExtension", path: "data.txt", format: "csv", expectedExt: cnst.CsvDataSource},
+		{name: "FormatOfCompressedFileWithoutDataExtension", path: "data.gz", format: "ndjson", expectedExt: cnst.NdjsonDataSource},
+		{name: "FormatOfStdin", path: cnst.StdinSource, format: "json", expectedExt: cnst.JsonDataSource},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			ext, err := sourceExtension(testCase.path, testCase.format)
+
+			/* ASSERT */
+			if err != nil || ext != testCase.expectedExt {
+				t.Fatalf("sourceExtension() : expected %q, got %q, error: %v", testCase.expectedExt, ext, err)
+			}
+		})
+	}
+}
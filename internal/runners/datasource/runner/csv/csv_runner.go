@@ -3,13 +3,18 @@ package csv
 import (
 	"context"
 	"encoding/csv"
-	"fmt"
+	"errors"
 	log "github.com/sirupsen/logrus"
 	"io"
-	"os"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/parallel"
 	"playground/internal/utils/parser"
+	"playground/internal/utils/progress"
+	"playground/internal/utils/rejects"
+	"playground/internal/utils/source"
+	"strconv"
 	"sync"
 )
 
@@ -18,97 +23,250 @@ type csvDataSourceRunner struct {
 	ctx         context.Context
 	wg          *sync.WaitGroup
 	csvFilePath string
+	aliases     map[string]string
+	rowErrors   *rejects.Handler
+	workers     int
 	recordCh    t.RecordChannel
 	errorCh     t.ErrorChannel
 }
 
 // NewDataSourceRunner initializes and returns csvDataSourceRunner
-// Returns error if some of ctx, wg, recordCh, errorCh is nil
+// Aliases map alternative CSV column names to canonical ones
+// Invalid rows are passed to rowErrors handler, nil handler aborts reading on the first one
+// Several workers parse rows in parallel, records are sent in the file order anyway
+// Error channel is shared by the pipeline runners, so it isn't closed by the runner
+// Returns error if some of ctx, wg, recordCh, errorCh is nil or workers number is less than 1
 func NewDataSourceRunner(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	filePath string,
+	aliases map[string]string,
+	rowErrors *rejects.Handler,
+	workers int,
 	recordCh t.RecordChannel,
 	errorCh t.ErrorChannel) (*csvDataSourceRunner, error) {
 
 	// Validate parameters
 	if ctx == nil {
-		return nil, cerror.NewCustomError("invalid context")
+		return nil, cerror.NewConfigError("context", "", "invalid context")
 	}
 	if wg == nil {
-		return nil, cerror.NewCustomError("invalid wait group")
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
+	}
+	if workers < 1 {
+		return nil, cerror.NewConfigError("workers", strconv.Itoa(workers), "invalid workers number")
 	}
 	if recordCh == nil {
-		return nil, cerror.NewCustomError("invalid record channel")
+		return nil, cerror.NewConfigError("record channel", "", "invalid record channel")
 	}
 	if errorCh == nil {
-		return nil, cerror.NewCustomError("invalid error channel")
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
 	}
 
 	return &csvDataSourceRunner{
 		ctx:         ctx,
 		wg:          wg,
 		csvFilePath: filePath,
+		aliases:     aliases,
+		rowErrors:   rowErrors,
+		workers:     workers,
 		recordCh:    recordCh,
 		errorCh:     errorCh,
 	}, nil
 }
 
+// csvRow represents a read CSV line, err is set if the line couldn't be read
+type csvRow struct {
+	fields []string
+	line   int
+	err    error
+}
+
+// csvRecord represents a parsed CSV line
+// rowErr is set for an invalid line, err is set if reading should stop
+type csvRecord struct {
+	record *t.Record
+	line   int
+	rowErr *cerror.ParseError
+	err    error
+}
+
 // Run interface implementation, related to CSV file specific
 func (r *csvDataSourceRunner) Run() {
 	go func() {
 		defer r.wg.Done()
 		defer close(r.recordCh)
-		defer close(r.errorCh)
+
+		// Read records are reported to the pipeline progress tracker, if any
+		stage := progress.FromContext(r.ctx).Stage(cnst.DataSourceStage)
+		defer stage.Finish()
 
 		// Try to open csv file
-		csvFile, err := os.Open(r.csvFilePath)
+		csvFile, err := source.Open(r.csvFilePath)
 		if err != nil {
-			r.errorCh <- cerror.NewCustomError(fmt.Sprintf("failed to open csv file %q", r.csvFilePath))
+			r.errorCh <- &cerror.StageError{
+				Stage:  cnst.CsvDataSourceStage,
+				Source: r.csvFilePath,
+				Reason: "failed to open csv file",
+				Err:    err,
+			}
 			return
 		}
 		defer csvFile.Close()
 
 		// Create a new CSV reader reading from the opened file
+		// Row length is validated against the header by the parser
 		reader := csv.NewReader(csvFile)
+		reader.FieldsPerRecord = -1
 
-		// Scip CSV header
-		_, err = reader.Read()
-		if err != nil && err != io.EOF {
-			r.errorCh <- cerror.NewCustomError(fmt.Sprintf("failed to read csv %q", "header"))
+		// Read CSV header, map columns by name
+		columns, err := reader.Read()
+		if err != nil {
+			if err != io.EOF {
+				r.errorCh <- &cerror.StageError{
+					Stage:  cnst.CsvDataSourceStage,
+					Source: r.csvFilePath,
+					Reason: "failed to read csv header",
+					Err:    err,
+				}
+			}
+			return
+		}
+		header, err := parser.NewCsvHeader(columns, r.aliases)
+		if err != nil {
+			r.errorCh <- &cerror.StageError{Stage: cnst.CsvDataSourceStage, Source: r.csvFilePath, Err: err}
 			return
 		}
 
-		for {
-			select {
-			// Handle cancel event
-			case <-r.ctx.Done():
-				log.Warning("csv datasource shutdown")
-
-				// Notify next runner about cancel event
-				r.recordCh <- nil
-				return
-
-			default:
-				row, err := reader.Read()
-				if err != nil {
-					if err != io.EOF {
-						r.errorCh <- cerror.NewCustomError(fmt.Sprintf("failed to read csv %q", "line"))
-					}
-					log.Debug("csv datasource finished work")
+		// Lines are read one by one, reading stops after the first failure
+		failed := false
+		read := func() (csvRow, bool) {
+			if failed {
+				return csvRow{}, false
+			}
+			fields, err := reader.Read()
+			if err == io.EOF {
+				return csvRow{}, false
+			}
+			var csvErr *csv.ParseError
+			if errors.As(err, &csvErr) {
+				return csvRow{line: csvErr.Line, err: err}, true
+			}
+			if err != nil {
+				failed = true
+				return csvRow{err: err}, true
+			}
+			line, _ := reader.FieldPos(0)
+			return csvRow{fields: fields, line: line}, true
+		}
+		parse := func(row csvRow) csvRecord {
+			return r.parse(row, header)
+		}
+
+		// Single worker reads and parses lines in place, several ones parse batches of lines in parallel
+		// Parsed lines are handled in the file order either way
+		if r.workers <= 1 {
+			for {
+				select {
+				// Handle cancel event
+				case <-r.ctx.Done():
+					log.Warning("csv datasource shutdown")
 					return
+
+				default:
+					row, ok := read()
+					if !ok {
+						log.Debug("csv datasource finished work")
+						return
+					}
+					if !r.send(parse(row), stage) {
+						return
+					}
 				}
+			}
+		}
 
-				// Convert to record csv line
-				record, err := parser.NewRecordFromCsvStrings(row)
-				if err != nil {
-					r.errorCh <- err
+		ctx, cancel := context.WithCancel(r.ctx)
+		batches := parallel.Map(ctx, r.workers, cnst.ParseBatchSize, read, parse)
+		// Reading goroutine is stopped before the file is closed
+		defer func() {
+			cancel()
+			for range batches {
+			}
+		}()
+		for batch := range batches {
+			for _, record := range batch {
+				if !r.send(record, stage) {
 					return
 				}
-
-				// Send data to next runner
-				r.recordCh <- record
 			}
 		}
+		if r.ctx.Err() != nil {
+			log.Warning("csv datasource shutdown")
+			return
+		}
+		log.Debug("csv datasource finished work")
 	}()
 }
+
+// parse converts read CSV line to record
+func (r *csvDataSourceRunner) parse(row csvRow, header *parser.CsvHeader) csvRecord {
+	// Malformed CSV line, reader continues from the next one
+	var csvErr *csv.ParseError
+	if errors.As(row.err, &csvErr) {
+		return csvRecord{line: row.line, rowErr: &cerror.ParseError{Reason: csvErr.Err.Error(), Err: csvErr}}
+	}
+	if row.err != nil {
+		return csvRecord{err: &cerror.StageError{
+			Stage:  cnst.CsvDataSourceStage,
+			Source: r.csvFilePath,
+			Reason: "failed to read csv line",
+			Err:    row.err,
+		}}
+	}
+
+	// Convert to record csv line
+	record, err := parser.NewRecordFromCsvStrings(row.fields, header)
+	var rowErr *cerror.ParseError
+	if errors.As(err, &rowErr) {
+		return csvRecord{line: row.line, rowErr: rowErr}
+	}
+	if err != nil {
+		return csvRecord{err: &cerror.StageError{Stage: cnst.CsvDataSourceStage, Source: r.csvFilePath, Err: err}}
+	}
+	return csvRecord{record: record, line: row.line}
+}
+
+// send passes parsed line to next runner, invalid lines are passed to the row errors handler
+// Returns false if reading should stop
+func (r *csvDataSourceRunner) send(record csvRecord, stage *progress.Stage) bool {
+	if record.err != nil {
+		r.errorCh <- record.err
+		return false
+	}
+	if record.rowErr != nil {
+		return r.handleRowError(record.rowErr, record.line)
+	}
+
+	// Send data to next runner, cancel event interrupts waiting for it
+	select {
+	case r.recordCh <- record.record:
+		stage.Inc()
+		return true
+	case <-r.ctx.Done():
+		log.Warning("csv datasource shutdown")
+		return false
+	}
+}
+
+// handleRowError completes row error location and passes it to the row errors handler
+// Returns false if reading should stop, the error is sent to the error channel in this case
+func (r *csvDataSourceRunner) handleRowError(rowErr *cerror.ParseError, line int) bool {
+	rowErr.Source = r.csvFilePath
+	rowErr.Line = line
+	if err := r.rowErrors.Handle(rowErr); err != nil {
+		r.errorCh <- err
+		return false
+	}
+	return true
+}
@@ -2,11 +2,14 @@ package csv
 
 import (
 	c "context"
+	"encoding/csv"
 	"fmt"
 	"os"
+	cnst "playground/internal/constants"
 	tp "playground/internal/types"
 	"playground/internal/utils/cerror"
 	"playground/internal/utils/parser"
+	"playground/internal/utils/rejects"
 	"reflect"
 	"strings"
 	s "sync"
@@ -20,6 +23,8 @@ const (
 	ValidCsvFile   = "tmp.*.csv"
 )
 
+var Header = []string{"UserId", "CampaignId", "Country", "Ltv1", "Ltv2", "Ltv3", "Ltv4", "Ltv5", "Ltv6", "Ltv7"}
+
 func createTempCSV(fileName string, data []string) (*os.File, error) {
 	f, err := os.CreateTemp("", fileName)
 	if err != nil {
@@ -59,7 +64,7 @@ func getResult(
 	path string,
 	rCh tp.RecordChannel,
 	eCh tp.ErrorChannel) newDataSourceResult {
-	ds, err := NewDataSourceRunner(ctx, wg, path, rCh, eCh)
+	ds, err := NewDataSourceRunner(ctx, wg, path, nil, nil, 1, rCh, eCh)
 	return newDataSourceResult{dataSource: ds, err: err}
 }
 
@@ -111,7 +116,7 @@ func TestNewDataSource_InvalidInputParams(t *testing.T) {
 			}
 
 			/* ACT */
-			result, err := NewDataSourceRunner(testCase.input.ctx, testCase.input.wg, testCase.input.path, testCase.input.rCh, testCase.input.eCh)
+			result, err := NewDataSourceRunner(testCase.input.ctx, testCase.input.wg, testCase.input.path, nil, nil, 1, testCase.input.rCh, testCase.input.eCh)
 
 			/* ASSERT */
 			// Assert expected error
@@ -138,10 +143,11 @@ func TestNewDataSource_InvalidInputParams(t *testing.T) {
 
 func TestCsvDataSource_RunInvalidCsvFileOpening(t *testing.T) {
 	/* ARRANGE */
-	errorStr := cerror.NewCustomError(fmt.Sprintf("failed to open csv file %q", NoExFile)).Error()
+	_, openErr := os.Open(NoExFile)
+	errorStr := (&cerror.StageError{Stage: cnst.CsvDataSourceStage, Source: NoExFile, Reason: "failed to open csv file", Err: openErr}).Error()
 	in := inputParameters{c.Background(), &s.WaitGroup{}, NoExFile, tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -171,8 +177,6 @@ func TestCsvDataSource_RunInvalidCsvFileOpening(t *testing.T) {
 
 func TestNewDataSource_RunReadCorruptedCsvFileHeader(t *testing.T) {
 	/* ARRANGE */
-	errorStr := cerror.NewCustomError(fmt.Sprintf("failed to read csv %q", "header")).Error()
-
 	// Prepare quoted string, to fail data source reader
 	csvData := []string{`one,two,th"ree,four`}
 	f, err := createTempCSV(InvalidCsvFile, csvData)
@@ -180,10 +184,53 @@ func TestNewDataSource_RunReadCorruptedCsvFileHeader(t *testing.T) {
 		t.Fatalf("Failed to create file [%s]", err.Error())
 	}
 	defer os.Remove(f.Name())
+	headerErr := &csv.ParseError{StartLine: 1, Line: 1, Column: 11, Err: csv.ErrBareQuote}
+	errorStr := (&cerror.StageError{Stage: cnst.CsvDataSourceStage, Source: f.Name(), Reason: "failed to read csv header", Err: headerErr}).Error()
+
+	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+	in.wg.Add(1)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
+
+	/* ACT */
+	go source.Run()
+
+	/* ASSERT */
+	for {
+		select {
+		// Assert unexpected record data
+		case _, ok := <-in.rCh:
+			if ok {
+				t.Fatalf("Run() with params %v: unexpected record channel value", in)
+			}
+			// Assert expected error data
+		case err, ok := <-in.eCh:
+			if ok {
+				if err.Error() != errorStr {
+					t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+				}
+				return
+			}
+			// Assert potential hang situation
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
+
+func TestNewDataSource_RunReadCsvFileWithoutLtvColumns(t *testing.T) {
+	/* ARRANGE */
+	// Prepare csv data without ltv columns
+	csvData := []string{"UserId,CampaignId,Country\n", "6,9566c74d-1003-4c4d-bbbb-0407d1e2c649,JP\n"}
+	f, err := createTempCSV(InvalidCsvFile, csvData)
+	if err != nil {
+		t.Fatalf("Failed to create file [%s]", err.Error())
+	}
+	defer os.Remove(f.Name())
+	errorStr := (&cerror.StageError{Stage: cnst.CsvDataSourceStage, Source: f.Name(), Err: cerror.NewParseError("Ltv1", "required csv column is missing")}).Error()
 
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -227,10 +274,11 @@ func TestNewDataSource_RunReadValidCsvFile(t *testing.T) {
 	defer os.Remove(f.Name())
 
 	// Prepare expected data
+	header, _ := parser.NewCsvHeader(Header, nil)
 	expectedRecords := []*tp.Record{}
 	for _, csv := range csvData[1:] {
 		strs := strings.Split(strings.ReplaceAll(csv, "\n", ""), ",")
-		rec, err := parser.NewRecordFromCsvStrings(strs)
+		rec, err := parser.NewRecordFromCsvStrings(strs, header)
 		if err != nil {
 			t.Fatalf("Failed to parse tmp csv file data [%s]", err.Error())
 		}
@@ -239,7 +287,7 @@ func TestNewDataSource_RunReadValidCsvFile(t *testing.T) {
 
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -295,14 +343,14 @@ func TestNewDataSource_RunCancelReadingCsvFile(t *testing.T) {
 	}
 	defer os.Remove(f.Name())
 
-	// Prepare expected data
-	expectedRecords := []*tp.Record{nil}
+	// No records are expected, record channel is closed on cancel event
+	expectedRecords := []*tp.Record{}
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
 
 	// Set cancel context
 	ctx, cancel := c.WithCancel(in.ctx)
-	source, _ := NewDataSourceRunner(ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
 	// Invoke cancel
 	cancel()
 
@@ -347,7 +395,6 @@ func TestNewDataSource_RunCancelReadingCsvFile(t *testing.T) {
 
 func TestNewDataSource_RunReadCorruptedLtvDataFromCsvFileData(t *testing.T) {
 	/* ARRANGE */
-	errorStr := cerror.NewCustomError("failed to convert ltv data").Error()
 	// Prepare invalid csv data
 	csvData := []string{
 		"UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3,Ltv4,Ltv5,Ltv6,Ltv7\n",
@@ -360,12 +407,14 @@ func TestNewDataSource_RunReadCorruptedLtvDataFromCsvFileData(t *testing.T) {
 		t.Fatalf("Failed to create file [%s]", err.Error())
 	}
 	defer os.Remove(f.Name())
+	errorStr := (&cerror.ParseError{Source: f.Name(), Line: 4, Column: "Ltv2", Value: "HELLO", Reason: "failed to convert ltv data"}).Error()
 
 	// Prepare expected data, skip last record (corrupted data)
+	header, _ := parser.NewCsvHeader(Header, nil)
 	expectedRecords := []*tp.Record{}
 	for _, csv := range csvData[1 : len(csvData)-1] {
 		strs := strings.Split(strings.ReplaceAll(csv, "\n", ""), ",")
-		rec, err := parser.NewRecordFromCsvStrings(strs)
+		rec, err := parser.NewRecordFromCsvStrings(strs, header)
 		if err != nil {
 			t.Fatalf("Failed to parse tmp csv file data [%s]", err.Error())
 		}
@@ -374,7 +423,7 @@ func TestNewDataSource_RunReadCorruptedLtvDataFromCsvFileData(t *testing.T) {
 
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -419,7 +468,6 @@ func TestNewDataSource_RunReadCorruptedLtvDataFromCsvFileData(t *testing.T) {
 
 func TestNewDataSource_RunReadCorruptedCsvFileContent(t *testing.T) {
 	/* ARRANGE */
-	errorStr := cerror.NewCustomError(fmt.Sprintf("failed to read csv %q", "line")).Error()
 	// Prepare invalid csv data
 	csvData := []string{
 		"UserId,CampaignId,Country,Ltv1,Ltv2,Ltv3,Ltv4,Ltv5,Ltv6,Ltv7\n",
@@ -431,12 +479,14 @@ func TestNewDataSource_RunReadCorruptedCsvFileContent(t *testing.T) {
 		t.Fatalf("Failed to create file [%s]", err.Error())
 	}
 	defer os.Remove(f.Name())
+	errorStr := (&cerror.ParseError{Source: f.Name(), Line: 3, Reason: `bare " in non-quoted-field`}).Error()
 
 	// Prepare expected data, skip last record (corrupted data)
+	header, _ := parser.NewCsvHeader(Header, nil)
 	expectedRecords := []*tp.Record{}
 	for _, csv := range csvData[1 : len(csvData)-1] {
 		strs := strings.Split(strings.ReplaceAll(csv, "\n", ""), ",")
-		rec, err := parser.NewRecordFromCsvStrings(strs)
+		rec, err := parser.NewRecordFromCsvStrings(strs, header)
 		if err != nil {
 			t.Fatalf("Failed to parse tmp csv file data [%s]", err.Error())
 		}
@@ -445,7 +495,7 @@ func TestNewDataSource_RunReadCorruptedCsvFileContent(t *testing.T) {
 
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, nil, 1, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -487,3 +537,178 @@ func TestNewDataSource_RunReadCorruptedCsvFileContent(t *testing.T) {
 		}
 	}
 }
+
+func TestNewDataSource_RunReadInvalidRowsLeniently(t *testing.T) {
+	// Prepare csv data with invalid rows in the middle
+	csvData := []string{
+		"CampaignId,Country,Ltv1,Ltv2\n",
+		"9566c74d,JP,1,2\n",
+		"6325253f,US,HELLO,2\n",
+		"680b4e7c,DE,1\n",
+		`680b4e7c,D"E,1,2` + "\n",
+		"680b4e7c,DE,3,4\n",
+	}
+	expectedRecords := []*tp.Record{
+		tp.NewRecord("9566c74d", "JP", tp.LtvCollection{1, 2}),
+		tp.NewRecord("680b4e7c", "DE", tp.LtvCollection{3, 4}),
+	}
+
+	tests := []struct {
+		name            string
+		maxErrors       uint
+		workers         int
+		expectedRecords []*tp.Record
+		errorStr        string
+	}{
+		{
+			name:            "SkipInvalidRows",
+			expectedRecords: expectedRecords,
+		},
+		{
+			name:            "InvalidRowsAboveThreshold",
+			maxErrors:       2,
+			expectedRecords: expectedRecords[:1],
+			errorStr:        cerror.NewCustomError(fmt.Sprintf("%d invalid rows exceed %q threshold", 3, "max-errors")).Error(),
+		},
+		{
+			name:            "SkipInvalidRowsSeveralWorkers",
+			workers:         3,
+			expectedRecords: expectedRecords,
+		},
+		{
+			name:            "InvalidRowsAboveThresholdSeveralWorkers",
+			maxErrors:       2,
+			workers:         3,
+			expectedRecords: expectedRecords[:1],
+			errorStr:        cerror.NewCustomError(fmt.Sprintf("%d invalid rows exceed %q threshold", 3, "max-errors")).Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			f, err := createTempCSV(InvalidCsvFile, csvData)
+			if err != nil {
+				t.Fatalf("Failed to create file [%s]", err.Error())
+			}
+			defer os.Remove(f.Name())
+
+			rowErrors, _ := rejects.NewHandler("skip", testCase.maxErrors, "")
+			expectedRecords := testCase.expectedRecords
+			in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+			in.wg.Add(1)
+			source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, rowErrors, max(testCase.workers, 1), in.rCh, in.eCh)
+
+			/* ACT */
+			go source.Run()
+
+			/* ASSERT */
+			for {
+				select {
+				// Assert expected record data, invalid rows are skipped
+				case result, ok := <-in.rCh:
+					if ok {
+						if len(expectedRecords) == 0 || !reflect.DeepEqual(expectedRecords[0], result) {
+							t.Fatalf("Run() unexpected record: %+v, expected: %+v", result, expectedRecords)
+						}
+						expectedRecords = expectedRecords[1:]
+					} else {
+						if testCase.errorStr != "" {
+							t.Fatalf("Run() : expected error [%s]", testCase.errorStr)
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() unexpected records slice len exp: %+v\ngot: %+v", 0, len(expectedRecords))
+						}
+						if rowErrors.Count() != 3 {
+							t.Fatalf("Run() expected 3 skipped rows, got %d", rowErrors.Count())
+						}
+						return
+					}
+					// Assert expected error data
+				case err, ok := <-in.eCh:
+					if ok {
+						if err.Error() != testCase.errorStr {
+							t.Fatalf("Run() : expected error string [%s], got [%s]", testCase.errorStr, err.Error())
+						}
+						return
+					} else {
+						in.eCh = nil
+					}
+					// Assert potential hang situation
+				case <-time.After(1 * time.Second):
+					t.Fatalf("Run() : timeout")
+				}
+			}
+		})
+	}
+}
+
+func TestNewDataSource_InvalidWorkers(t *testing.T) {
+	/* ARRANGE */
+	errorStr := cerror.NewCustomError("invalid workers number").Error()
+
+	/* ACT */
+	result, err := NewDataSourceRunner(c.Background(), &s.WaitGroup{}, "", nil, nil, 0, tp.NewRecordChannel(0), tp.NewErrorChannel(0))
+
+	/* ASSERT */
+	if result != nil || err == nil || err.Error() != errorStr {
+		t.Fatalf("NewDataSourceRunner() : expected error string [%s], got %v [%v]", errorStr, result, err)
+	}
+}
+
+func TestNewDataSource_RunSeveralWorkers(t *testing.T) {
+	/* ARRANGE */
+	// Prepare csv data of several parse batches, every 100th row is invalid
+	csvData := []string{"CampaignId,Country,Ltv1,Ltv2\n"}
+	expectedRecords := []*tp.Record{}
+	invalidRows := 0
+	for i := 0; i < 3*cnst.ParseBatchSize+10; i++ {
+		country := []string{"JP", "US", "DE"}[i%3]
+		if i%100 == 0 {
+			csvData = append(csvData, fmt.Sprintf("%d,%s,HELLO,2\n", i, country))
+			invalidRows++
+			continue
+		}
+		csvData = append(csvData, fmt.Sprintf("%d,%s,%d,%d\n", i, country, i, i+1))
+		expectedRecords = append(expectedRecords, tp.NewRecord(fmt.Sprint(i), country, tp.LtvCollection{float64(i), float64(i + 1)}))
+	}
+	f, err := createTempCSV(ValidCsvFile, csvData)
+	if err != nil {
+		t.Fatalf("Failed to create file [%s]", err.Error())
+	}
+	defer os.Remove(f.Name())
+
+	rowErrors, _ := rejects.NewHandler("skip", 0, "")
+	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+	in.wg.Add(1)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, rowErrors, 4, in.rCh, in.eCh)
+
+	/* ACT */
+	go source.Run()
+
+	/* ASSERT */
+	// Records are expected in the file order
+	for {
+		select {
+		case result, ok := <-in.rCh:
+			if ok {
+				if len(expectedRecords) == 0 || !reflect.DeepEqual(expectedRecords[0], result) {
+					t.Fatalf("Run() unexpected record: %+v, expected: %+v", result, expectedRecords[:1])
+				}
+				expectedRecords = expectedRecords[1:]
+			} else {
+				if len(expectedRecords) != 0 {
+					t.Fatalf("Run() unexpected records slice len exp: %+v\ngot: %+v", 0, len(expectedRecords))
+				}
+				if rowErrors.Count() != uint(invalidRows) {
+					t.Fatalf("Run() expected %d skipped rows, got %d", invalidRows, rowErrors.Count())
+				}
+				return
+			}
+		case err := <-in.eCh:
+			t.Fatalf("Run() : unexpected error [%s]", err.Error())
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
@@ -1,14 +1,19 @@
 package json
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	log "github.com/sirupsen/logrus"
-	"os"
+	"io"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
 	"playground/internal/utils/parser"
+	"playground/internal/utils/progress"
+	"playground/internal/utils/rejects"
+	"playground/internal/utils/source"
 	"sync"
 )
 
@@ -17,37 +22,42 @@ type jsonDataSourceRunner struct {
 	ctx          context.Context
 	wg           *sync.WaitGroup
 	jsonFilePath string
+	rowErrors    *rejects.Handler
 	recordCh     t.RecordChannel
 	errorCh      t.ErrorChannel
 }
 
 // NewDataSourceRunner initializes and returns jsonDataSourceRunner
+// Error channel is shared by the pipeline runners, so it isn't closed by the runner
+// Invalid array elements are passed to rowErrors handler, nil handler aborts reading on the first one
 // Returns error if some of ctx, wg, recordCh, errorCh is nil
 func NewDataSourceRunner(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	filePath string,
+	rowErrors *rejects.Handler,
 	recordCh t.RecordChannel,
 	errorCh t.ErrorChannel) (*jsonDataSourceRunner, error) {
 
 	// Validate parameters
 	if ctx == nil {
-		return nil, cerror.NewCustomError("invalid context")
+		return nil, cerror.NewConfigError("context", "", "invalid context")
 	}
 	if wg == nil {
-		return nil, cerror.NewCustomError("invalid wait group")
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
 	}
 	if recordCh == nil {
-		return nil, cerror.NewCustomError("invalid record channel")
+		return nil, cerror.NewConfigError("record channel", "", "invalid record channel")
 	}
 	if errorCh == nil {
-		return nil, cerror.NewCustomError("invalid error channel")
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
 	}
 
 	return &jsonDataSourceRunner{
 		ctx:          ctx,
 		wg:           wg,
 		jsonFilePath: filePath,
+		rowErrors:    rowErrors,
 		recordCh:     recordCh,
 		errorCh:      errorCh,
 	}, nil
@@ -58,52 +68,116 @@ func (r *jsonDataSourceRunner) Run() {
 	go func() {
 		defer r.wg.Done()
 		defer close(r.recordCh)
-		defer close(r.errorCh)
+
+		// Read records are reported to the pipeline progress tracker, if any
+		stage := progress.FromContext(r.ctx).Stage(cnst.DataSourceStage)
+		defer stage.Finish()
 
 		// Try to open json file
-		jsonDump, err := os.ReadFile(r.jsonFilePath)
+		jsonFile, err := source.Open(r.jsonFilePath)
 		if err != nil {
-			r.errorCh <- cerror.NewCustomError(fmt.Sprintf("failed to read json file %q", r.jsonFilePath))
+			r.errorCh <- &cerror.StageError{
+				Stage:  cnst.JsonDataSourceStage,
+				Source: r.jsonFilePath,
+				Reason: "failed to open json file",
+				Err:    err,
+			}
 			return
 		}
+		defer jsonFile.Close()
 
-		// Unmarshal the json content into a slice of jsonFileData
-		var jsonData []t.JsonFileData
-		err = json.Unmarshal(jsonDump, &jsonData)
-		if err != nil {
-			r.errorCh <- cerror.NewCustomError(fmt.Sprintf("failed to unmarchall json data %q", r.jsonFilePath))
+		// Decode json content element by element, the whole file is never kept in memory
+		decoder := json.NewDecoder(bufio.NewReader(jsonFile))
+
+		// Top level value must be an array
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			r.errorCh <- &cerror.StageError{
+				Stage:  cnst.JsonDataSourceStage,
+				Source: r.jsonFilePath,
+				Reason: "failed to unmarshal json data",
+				Err:    err,
+			}
 			return
 		}
 
+		// Array elements are numbered from 1, so that invalid ones could be located
+		element := 0
 		for {
 			select {
 			// Handle cancel event
 			case <-r.ctx.Done():
 				log.Warning("json datasource shutdown")
-
-				// Notify next runner about cancel event
-				r.recordCh <- nil
 				return
 
 			default:
-				for _, data := range jsonData {
-					// Well, as far as I understand
-					// The json data contains a set of Ltv associated with the number of users, right?
-					// So I divide the sample by the number of users to get ltv per user
-					data.Ltv1 = data.Ltv1 / float64(data.Users)
-					data.Ltv2 = data.Ltv2 / float64(data.Users)
-					data.Ltv3 = data.Ltv3 / float64(data.Users)
-					data.Ltv4 = data.Ltv4 / float64(data.Users)
-					data.Ltv5 = data.Ltv5 / float64(data.Users)
-					data.Ltv6 = data.Ltv6 / float64(data.Users)
-					data.Ltv7 = data.Ltv7 / float64(data.Users)
-
-					// Send data to next runner
-					r.recordCh <- parser.NewRecordFromJsonStruct(&data)
+				if !decoder.More() {
+					// Array closing token is expected after the last element
+					if token, err := decoder.Token(); err != nil || token != json.Delim(']') {
+						r.errorCh <- &cerror.StageError{
+							Stage:  cnst.JsonDataSourceStage,
+							Source: r.jsonFilePath,
+							Reason: "failed to unmarshal json data",
+							Err:    err,
+						}
+						return
+					}
+					log.Debug("json datasource finished work")
+					return
+				}
+
+				element++
+				var data t.JsonFileData
+				if err := decoder.Decode(&data); err != nil {
+					if !r.handleElementError(err, element) {
+						return
+					}
+					continue
+				}
+
+				// Well, as far as I understand
+				// The json data contains a set of Ltv associated with the number of users, right?
+				// So I divide the sample by the number of users to get ltv per user
+				select {
+				case r.recordCh <- parser.NewRecordPerUserFromJsonStruct(&data):
+					stage.Inc()
+				case <-r.ctx.Done():
+					log.Warning("json datasource shutdown")
+					return
 				}
-				log.Debug("json datasource finished work")
-				return
 			}
 		}
 	}()
 }
+
+// handleElementError passes invalid array element error to the row errors handler, element number is reported as the line
+// Syntax errors break the decoder, other ones leave it after the element, so reading could be continued
+// Returns false if reading should stop
+func (r *jsonDataSourceRunner) handleElementError(err error, element int) bool {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		r.errorCh <- &cerror.StageError{
+			Stage:  cnst.JsonDataSourceStage,
+			Source: r.jsonFilePath,
+			Reason: "failed to unmarshal json data",
+			Err:    err,
+		}
+		return false
+	}
+
+	// Field validation errors are passed as is, type errors know the failed field
+	// Other ones are reported for the whole element
+	var rowErr *cerror.ParseError
+	if !errors.As(err, &rowErr) {
+		rowErr = &cerror.ParseError{Reason: "failed to unmarshal json data", Err: err}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			rowErr.Column = typeErr.Field
+		}
+	}
+	rowErr.Source, rowErr.Line = r.jsonFilePath, element
+	if err := r.rowErrors.Handle(rowErr); err != nil {
+		r.errorCh <- err
+		return false
+	}
+	return true
+}
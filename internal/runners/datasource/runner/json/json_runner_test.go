@@ -3,11 +3,16 @@ package json
 import (
 	c "context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
 	tp "playground/internal/types"
 	"playground/internal/utils/cerror"
 	"playground/internal/utils/parser"
+	"playground/internal/utils/rejects"
 	"reflect"
 	s "sync"
 	"testing"
@@ -40,13 +45,9 @@ func createTempJSON(fileName string, data interface{}) (*os.File, error) {
 }
 
 func fieldPerUser(json *tp.JsonFileData) {
-	json.Ltv1 = json.Ltv1 / float64(json.Users)
-	json.Ltv2 = json.Ltv2 / float64(json.Users)
-	json.Ltv3 = json.Ltv3 / float64(json.Users)
-	json.Ltv4 = json.Ltv4 / float64(json.Users)
-	json.Ltv5 = json.Ltv5 / float64(json.Users)
-	json.Ltv6 = json.Ltv6 / float64(json.Users)
-	json.Ltv7 = json.Ltv7 / float64(json.Users)
+	for i := range json.Ltv {
+		json.Ltv[i] = json.Ltv[i] / float64(json.Users)
+	}
 }
 
 type inputParameters struct {
@@ -68,7 +69,7 @@ func getResult(
 	path string,
 	rCh tp.RecordChannel,
 	eCh tp.ErrorChannel) newDataSourceResult {
-	ds, err := NewDataSourceRunner(ctx, wg, path, rCh, eCh)
+	ds, err := NewDataSourceRunner(ctx, wg, path, nil, rCh, eCh)
 	return newDataSourceResult{dataSource: ds, err: err}
 }
 
@@ -120,7 +121,7 @@ func TestNewDataSource_InvalidInputParams(t *testing.T) {
 			}
 
 			/* ACT */
-			result, err := NewDataSourceRunner(testCase.input.ctx, testCase.input.wg, testCase.input.path, testCase.input.rCh, testCase.input.eCh)
+			result, err := NewDataSourceRunner(testCase.input.ctx, testCase.input.wg, testCase.input.path, nil, testCase.input.rCh, testCase.input.eCh)
 
 			/* ASSERT */
 			// Assert expected error
@@ -147,10 +148,11 @@ func TestNewDataSource_InvalidInputParams(t *testing.T) {
 
 func TestCsvDataSource_RunInvalidJsonFileOpening(t *testing.T) {
 	/* ARRANGE */
-	errorStr := cerror.NewCustomError(fmt.Sprintf("failed to read json file %q", NoExFile)).Error()
+	_, openErr := os.Open(NoExFile)
+	errorStr := (&cerror.StageError{Stage: cnst.JsonDataSourceStage, Source: NoExFile, Reason: "failed to open json file", Err: openErr}).Error()
 	in := inputParameters{c.Background(), &s.WaitGroup{}, NoExFile, tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -184,13 +186,15 @@ func TestNewDataSource_RunReadValidJsonFile(t *testing.T) {
 	jsonData := []tp.JsonFileData{
 		{
 			CampaignId: "9566c74d-1003-4c4d-bbbb-0407d1e2c649", Country: "TR",
-			Ltv1: 1.9542502880389025, Ltv2: 1.994132946978472, Ltv3: 3.0126373791241345, Ltv4: 3.113804897018578,
-			Ltv5: 3.201461265181941, Ltv6: 3.796798675112415, Ltv7: 4.321961161757773, Users: 93,
+			Ltv: []float64{1.9542502880389025, 1.994132946978472, 3.0126373791241345, 3.113804897018578,
+				3.201461265181941, 3.796798675112415, 4.321961161757773},
+			Users: 93,
 		},
 		{
 			CampaignId: "6694d2c4-22ac-4208-a007-2939487f6999", Country: "IT",
-			Ltv1: 0.46401632345650307, Ltv2: 0.7080665558155662, Ltv3: 0.9479043587807372, Ltv4: 1.3855588020049658,
-			Ltv5: 1.812878842576647, Ltv6: 2.423993387880591, Ltv7: 3.3931016433043153, Users: 97,
+			Ltv: []float64{0.46401632345650307, 0.7080665558155662, 0.9479043587807372, 1.3855588020049658,
+				1.812878842576647, 2.423993387880591, 3.3931016433043153},
+			Users: 97,
 		},
 	}
 
@@ -204,13 +208,13 @@ func TestNewDataSource_RunReadValidJsonFile(t *testing.T) {
 	expectedRecords := []*tp.Record{}
 	for _, json := range jsonData {
 		fieldPerUser(&json)
-		rec := parser.NewRecordFromJsonStruct(&json)
+		rec := tp.NewWeightedRecord(json.CampaignId, json.Country, json.Ltv, float64(json.Users))
 		expectedRecords = append(expectedRecords, rec)
 	}
 
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -257,13 +261,15 @@ func TestNewDataSource_RunCancelReadingJsonFile(t *testing.T) {
 	jsonData := []tp.JsonFileData{
 		{
 			CampaignId: "9566c74d-1003-4c4d-bbbb-0407d1e2c649", Country: "TR",
-			Ltv1: 1.9542502880389025, Ltv2: 1.994132946978472, Ltv3: 3.0126373791241345, Ltv4: 3.113804897018578,
-			Ltv5: 3.201461265181941, Ltv6: 3.796798675112415, Ltv7: 4.321961161757773, Users: 93,
+			Ltv: []float64{1.9542502880389025, 1.994132946978472, 3.0126373791241345, 3.113804897018578,
+				3.201461265181941, 3.796798675112415, 4.321961161757773},
+			Users: 93,
 		},
 		{
 			CampaignId: "6694d2c4-22ac-4208-a007-2939487f6999", Country: "IT",
-			Ltv1: 0.46401632345650307, Ltv2: 0.7080665558155662, Ltv3: 0.9479043587807372, Ltv4: 1.3855588020049658,
-			Ltv5: 1.812878842576647, Ltv6: 2.423993387880591, Ltv7: 3.3931016433043153, Users: 97,
+			Ltv: []float64{0.46401632345650307, 0.7080665558155662, 0.9479043587807372, 1.3855588020049658,
+				1.812878842576647, 2.423993387880591, 3.3931016433043153},
+			Users: 97,
 		},
 	}
 	f, err := createTempJSON(ValidJsonFile, jsonData)
@@ -272,14 +278,14 @@ func TestNewDataSource_RunCancelReadingJsonFile(t *testing.T) {
 	}
 	defer os.Remove(f.Name())
 
-	// Prepare expected data
-	expectedRecords := []*tp.Record{nil}
+	// No records are expected, record channel is closed on cancel event
+	expectedRecords := []*tp.Record{}
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
 
 	// Set cancel context
 	ctx, cancel := c.WithCancel(in.ctx)
-	source, _ := NewDataSourceRunner(ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(ctx, in.wg, in.path, nil, in.rCh, in.eCh)
 	// Invoke cancel
 	cancel()
 
@@ -342,20 +348,22 @@ func TestNewDataSource_RunReadCorruptedJsonFileContent(t *testing.T) {
 		t.Fatalf("Failed to create file [%s]", err.Error())
 	}
 	defer os.Remove(f.Name())
-	errorStr := cerror.NewCustomError(fmt.Sprintf("failed to unmarchall json data %q", f.Name())).Error()
+	// Users field of the first element is a string, so the element is invalid
+	errorStr := (&cerror.ParseError{Source: f.Name(), Line: 1, Column: "Users", Reason: "failed to unmarshal json data"}).Error()
 
 	// Prepare expected data
 	json := tp.JsonFileData{
 		CampaignId: "9566c74d-1003-4c4d-bbbb-0407d1e2c649", Country: "TR",
-		Ltv1: 1.9542502880389025, Ltv2: 1.994132946978472, Ltv3: 3.0126373791241345, Ltv4: 3.113804897018578,
-		Ltv5: 3.201461265181941, Ltv6: 3.796798675112415, Ltv7: 4.321961161757773, Users: 93,
+		Ltv: []float64{1.9542502880389025, 1.994132946978472, 3.0126373791241345, 3.113804897018578,
+			3.201461265181941, 3.796798675112415, 4.321961161757773},
+		Users: 93,
 	}
 	fieldPerUser(&json)
 	expectedRecords := []*tp.Record{parser.NewRecordFromJsonStruct(&json)}
 
 	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
 	in.wg.Add(1)
-	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, in.rCh, in.eCh)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, in.rCh, in.eCh)
 
 	/* ACT */
 	go source.Run()
@@ -397,3 +405,285 @@ func TestNewDataSource_RunReadCorruptedJsonFileContent(t *testing.T) {
 		}
 	}
 }
+
+func TestNewDataSource_RunReadVariableLtvLenJsonFile(t *testing.T) {
+	/* ARRANGE */
+	// Prepare json data with LtvN fields and Ltv array of different length
+	jsonData := []map[string]interface{}{
+		{
+			"CampaignId": "9566c74d-1003-4c4d-bbbb-0407d1e2c649", "Country": "TR",
+			"Ltv1": 2, "ltv2": 4, "LTV3": 6, "Users": 2,
+		},
+		{
+			"CampaignId": "6694d2c4-22ac-4208-a007-2939487f6999", "Country": "IT",
+			"Ltv":   []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14},
+			"Users": 1,
+		},
+	}
+	f, err := createTempJSON(ValidJsonFile, jsonData)
+	if err != nil {
+		t.Fatalf("Failed to create file [%s]", err.Error())
+	}
+	defer os.Remove(f.Name())
+
+	// Prepare expected data
+	expectedRecords := []*tp.Record{
+		tp.NewWeightedRecord("9566c74d-1003-4c4d-bbbb-0407d1e2c649", "TR", tp.LtvCollection{1, 2, 3}, 2),
+		tp.NewWeightedRecord("6694d2c4-22ac-4208-a007-2939487f6999", "IT", tp.LtvCollection{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, 1),
+	}
+
+	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+	in.wg.Add(1)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, in.rCh, in.eCh)
+
+	/* ACT */
+	go source.Run()
+
+	/* ASSERT */
+	for {
+		select {
+		// Assert expected record data
+		case result, ok := <-in.rCh:
+			if ok {
+				// Assert result
+				expected := expectedRecords[0]
+				if !reflect.DeepEqual(expected, result) {
+					t.Fatalf("Run() exp: %+v\ngot: %+v", expected, result)
+				}
+				// Remove 1 element, slice as a queue )
+				expectedRecords = expectedRecords[1:]
+
+			} else {
+				// Assert empty expected records list
+				if len(expectedRecords) != 0 {
+					t.Fatalf("Run() unexpected records slice len exp: %+v\ngot: %+v", 0, len(expectedRecords))
+				}
+				return
+			}
+			// Assert unexpected error data
+		case err, ok := <-in.eCh:
+			if ok {
+				t.Fatalf("Run() with params %v: unexpected error channel value [%s]", in, err.Error())
+			} else {
+				// record channel closed, skip select case
+				in.eCh = nil
+			}
+			// Assert potential hang situation
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
+
+func TestJsonFileData_UnmarshalInvalidLtvFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		errorStr string
+	}{
+		{
+			name:     "mixedLtvFields",
+			input:    `{"CampaignId":"id","Country":"TR","Ltv":[1,2],"Ltv3":3,"Users":1}`,
+			errorStr: cerror.NewParseError("Ltv", `both "Ltv" and "LtvN" fields provided`).Error(),
+		},
+		{
+			name:     "missingLtvField",
+			input:    `{"CampaignId":"id","Country":"TR","Ltv1":1,"Ltv3":3,"Users":1}`,
+			errorStr: cerror.NewParseError("Ltv2", "field is missing").Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			data := tp.JsonFileData{}
+
+			/* ACT */
+			err := json.Unmarshal([]byte(testCase.input), &data)
+
+			/* ASSERT */
+			if err == nil || err.Error() != testCase.errorStr {
+				t.Fatalf("UnmarshalJSON() : expected error string [%s], got [%v]", testCase.errorStr, err)
+			}
+		})
+	}
+}
+
+func createTempRawJSON(fileName string, content string) (*os.File, error) {
+	f, err := os.CreateTemp("", fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp json file: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the temp json file: %w", err)
+	}
+	return f, nil
+}
+
+func TestNewDataSource_RunStreamJsonFile(t *testing.T) {
+	validElement := `{"CampaignId":"9566c74d","Country":"TR","Ltv":[2,4],"Users":2}`
+	validRecord := tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2)
+
+	tests := []struct {
+		name            string
+		content         string
+		expectedRecords []*tp.Record
+		expectedError   bool
+		errorCause      error
+		elementErr      *cerror.ParseError
+	}{
+		{
+			name:            "EmptyArray",
+			content:         "[]",
+			expectedRecords: []*tp.Record{},
+		},
+		{
+			name:            "RecordsBeforeCorruptedElement",
+			content:         "[" + validElement + "," + validElement + `,{"CampaignId":1}]`,
+			expectedRecords: []*tp.Record{validRecord, validRecord},
+			expectedError:   true,
+			elementErr:      &cerror.ParseError{Line: 3, Column: "CampaignId", Reason: "failed to unmarshal json data"},
+		},
+		{
+			name:            "RecordsBeforeTruncatedArray",
+			content:         "[" + validElement + "," + validElement,
+			expectedRecords: []*tp.Record{validRecord, validRecord},
+			expectedError:   true,
+			errorCause:      errors.New("unexpected end of JSON input"),
+		},
+		{
+			name:            "NonArrayTopLevelValue",
+			content:         validElement,
+			expectedRecords: []*tp.Record{},
+			expectedError:   true,
+		},
+		{
+			name:            "EmptyFile",
+			content:         "",
+			expectedRecords: []*tp.Record{},
+			expectedError:   true,
+			errorCause:      io.EOF,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			f, err := createTempRawJSON(ValidJsonFile, testCase.content)
+			if err != nil {
+				t.Fatalf("Failed to create file [%s]", err.Error())
+			}
+			defer os.Remove(f.Name())
+			errorStr := (&cerror.StageError{Stage: cnst.JsonDataSourceStage, Source: f.Name(), Reason: "failed to unmarshal json data", Err: testCase.errorCause}).Error()
+			if testCase.elementErr != nil {
+				testCase.elementErr.Source = f.Name()
+				errorStr = testCase.elementErr.Error()
+			}
+
+			expectedRecords := testCase.expectedRecords
+			in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+			in.wg.Add(1)
+			source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, in.rCh, in.eCh)
+
+			/* ACT */
+			go source.Run()
+
+			/* ASSERT */
+			for {
+				select {
+				// Assert expected record data, records are sent as soon as they are decoded
+				case result, ok := <-in.rCh:
+					if ok {
+						if len(expectedRecords) == 0 {
+							t.Fatalf("Run() unexpected record: %+v", result)
+						}
+						if !reflect.DeepEqual(expectedRecords[0], result) {
+							t.Fatalf("Run() exp: %+v\ngot: %+v", expectedRecords[0], result)
+						}
+						expectedRecords = expectedRecords[1:]
+					} else {
+						if testCase.expectedError {
+							t.Fatalf("Run() : expected error [%s]", errorStr)
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() unexpected records slice len exp: %+v\ngot: %+v", 0, len(expectedRecords))
+						}
+						return
+					}
+					// Assert expected error data
+				case err, ok := <-in.eCh:
+					if ok {
+						if !testCase.expectedError || err.Error() != errorStr {
+							t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() records not sent before error: %+v", expectedRecords)
+						}
+						return
+					} else {
+						in.eCh = nil
+					}
+					// Assert potential hang situation
+				case <-time.After(1 * time.Second):
+					t.Fatalf("Run() : timeout")
+				}
+			}
+		})
+	}
+}
+
+func TestNewDataSource_RunQuarantineInvalidJsonElements(t *testing.T) {
+	/* ARRANGE */
+	content := `[{"CampaignId":"9566c74d","Country":"TR","Ltv":[2,4],"Users":2},` +
+		`{"CampaignId":"9566c74d","Country":"TR","Ltv":["a"],"Users":2},` +
+		`{"CampaignId":"9566c74d","Country":"TR","Ltv1":1,"Ltv3":2,"Users":2},` +
+		`{"CampaignId":"6694d2c4","Country":"IT","Ltv":[3],"Users":3}]`
+	expectedRecords := []*tp.Record{
+		tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2),
+		tp.NewWeightedRecord("6694d2c4", "IT", tp.LtvCollection{1}, 3),
+	}
+	f, err := createTempRawJSON(ValidJsonFile, content)
+	if err != nil {
+		t.Fatalf("Failed to create file [%s]", err.Error())
+	}
+	defer os.Remove(f.Name())
+
+	rejectsPath := filepath.Join(t.TempDir(), "rejects.csv")
+	rowErrors, _ := rejects.NewHandler(cnst.OnErrorQuarantine, 0, rejectsPath)
+	expectedRejects := "file,line,column,reason\n" +
+		f.Name() + ",2,Ltv.0,failed to unmarshal json data\n" +
+		f.Name() + ",3,Ltv2,field is missing\n"
+
+	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+	in.wg.Add(1)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, rowErrors, in.rCh, in.eCh)
+
+	/* ACT */
+	go source.Run()
+	records := []*tp.Record{}
+	for record := range in.rCh {
+		records = append(records, record)
+	}
+	in.wg.Wait()
+	closeErr := rowErrors.Close()
+
+	/* ASSERT */
+	// Invalid elements are skipped, records and rejects are expected in the file order
+	if closeErr != nil {
+		t.Fatalf("Close() unexpected error [%v]", closeErr)
+	}
+	select {
+	case err := <-in.eCh:
+		t.Fatalf("Run() unexpected error [%v]", err)
+	default:
+	}
+	if !reflect.DeepEqual(records, expectedRecords) {
+		t.Fatalf("Run() exp: %+v\ngot: %+v", expectedRecords, records)
+	}
+	if data, _ := os.ReadFile(rejectsPath); string(data) != expectedRejects {
+		t.Fatalf("Run() rejects exp: %q\ngot: %q", expectedRejects, string(data))
+	}
+}
@@ -0,0 +1,239 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/parallel"
+	"playground/internal/utils/parser"
+	"playground/internal/utils/progress"
+	"playground/internal/utils/rejects"
+	"playground/internal/utils/source"
+	"strconv"
+	"sync"
+)
+
+// jsonlDataSourceRunner represents a data source runner backed by a JSON Lines (NDJSON) file
+// Each non-empty line holds a single campaign cohort JSON object
+type jsonlDataSourceRunner struct {
+	ctx           context.Context
+	wg            *sync.WaitGroup
+	jsonlFilePath string
+	rowErrors     *rejects.Handler
+	workers       int
+	recordCh      t.RecordChannel
+	errorCh       t.ErrorChannel
+}
+
+// NewDataSourceRunner initializes and returns jsonlDataSourceRunner
+// Invalid lines are passed to rowErrors handler, nil handler aborts reading on the first one
+// Several workers parse lines in parallel, records are sent in the file order anyway
+// Error channel is shared by the pipeline runners, so it isn't closed by the runner
+// Returns error if some of ctx, wg, recordCh, errorCh is nil or workers number is less than 1
+func NewDataSourceRunner(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	filePath string,
+	rowErrors *rejects.Handler,
+	workers int,
+	recordCh t.RecordChannel,
+	errorCh t.ErrorChannel) (*jsonlDataSourceRunner, error) {
+
+	// Validate parameters
+	if ctx == nil {
+		return nil, cerror.NewConfigError("context", "", "invalid context")
+	}
+	if wg == nil {
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
+	}
+	if workers < 1 {
+		return nil, cerror.NewConfigError("workers", strconv.Itoa(workers), "invalid workers number")
+	}
+	if recordCh == nil {
+		return nil, cerror.NewConfigError("record channel", "", "invalid record channel")
+	}
+	if errorCh == nil {
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
+	}
+
+	return &jsonlDataSourceRunner{
+		ctx:           ctx,
+		wg:            wg,
+		jsonlFilePath: filePath,
+		rowErrors:     rowErrors,
+		workers:       workers,
+		recordCh:      recordCh,
+		errorCh:       errorCh,
+	}, nil
+}
+
+// jsonlLine represents a read non-empty line, err is set if the file couldn't be read
+type jsonlLine struct {
+	data   []byte
+	number int
+	err    error
+}
+
+// jsonlRecord represents a parsed line
+// rowErr is set for an invalid line, err is set if reading should stop
+type jsonlRecord struct {
+	record *t.Record
+	rowErr *cerror.ParseError
+	err    error
+}
+
+// Run interface implementation, related to JSON Lines file specific
+func (r *jsonlDataSourceRunner) Run() {
+	go func() {
+		defer r.wg.Done()
+		defer close(r.recordCh)
+
+		// Read records are reported to the pipeline progress tracker, if any
+		stage := progress.FromContext(r.ctx).Stage(cnst.DataSourceStage)
+		defer stage.Finish()
+
+		// Try to open jsonl file
+		jsonlFile, err := source.Open(r.jsonlFilePath)
+		if err != nil {
+			r.errorCh <- &cerror.StageError{
+				Stage:  cnst.JsonlDataSourceStage,
+				Source: r.jsonlFilePath,
+				Reason: "failed to open jsonl file",
+				Err:    err,
+			}
+			return
+		}
+		defer jsonlFile.Close()
+
+		// Lines are read without length limit, a cohort could hold a long LTV curve
+		reader := bufio.NewReader(jsonlFile)
+		lineNumber := 0
+		failed := false
+		read := func() (jsonlLine, bool) {
+			for !failed {
+				line, err := reader.ReadBytes('\n')
+				if err != nil && err != io.EOF {
+					failed = true
+					return jsonlLine{err: err}, true
+				}
+				if len(line) == 0 && err == io.EOF {
+					return jsonlLine{}, false
+				}
+				lineNumber++
+
+				// Blank lines are allowed, e.g. trailing new line at the end of file
+				line = bytes.TrimSpace(line)
+				if len(line) != 0 {
+					return jsonlLine{data: line, number: lineNumber}, true
+				}
+			}
+			return jsonlLine{}, false
+		}
+
+		// Single worker reads and parses lines in place, several ones parse batches of lines in parallel
+		// Parsed lines are handled in the file order either way
+		if r.workers <= 1 {
+			for {
+				select {
+				// Handle cancel event
+				case <-r.ctx.Done():
+					log.Warning("jsonl datasource shutdown")
+					return
+
+				default:
+					line, ok := read()
+					if !ok {
+						log.Debug("jsonl datasource finished work")
+						return
+					}
+					if !r.send(r.parse(line), stage) {
+						return
+					}
+				}
+			}
+		}
+
+		ctx, cancel := context.WithCancel(r.ctx)
+		batches := parallel.Map(ctx, r.workers, cnst.ParseBatchSize, read, r.parse)
+		// Reading goroutine is stopped before the file is closed
+		defer func() {
+			cancel()
+			for range batches {
+			}
+		}()
+		for batch := range batches {
+			for _, record := range batch {
+				if !r.send(record, stage) {
+					return
+				}
+			}
+		}
+		if r.ctx.Err() != nil {
+			log.Warning("jsonl datasource shutdown")
+			return
+		}
+		log.Debug("jsonl datasource finished work")
+	}()
+}
+
+// parse converts read line to per user normalized record
+func (r *jsonlDataSourceRunner) parse(line jsonlLine) jsonlRecord {
+	if line.err != nil {
+		return jsonlRecord{err: &cerror.StageError{
+			Stage:  cnst.JsonlDataSourceStage,
+			Source: r.jsonlFilePath,
+			Reason: "failed to read jsonl file",
+			Err:    line.err,
+		}}
+	}
+
+	var data t.JsonFileData
+	if err := json.Unmarshal(line.data, &data); err != nil {
+		// Field validation errors are passed as is, type errors know the failed field
+		// Other ones are reported for the whole line
+		var rowErr *cerror.ParseError
+		if !errors.As(err, &rowErr) {
+			rowErr = &cerror.ParseError{Reason: "failed to unmarshal jsonl data", Err: err}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				rowErr.Column = typeErr.Field
+			}
+		}
+		rowErr.Source, rowErr.Line = r.jsonlFilePath, line.number
+		return jsonlRecord{rowErr: rowErr}
+	}
+	return jsonlRecord{record: parser.NewRecordPerUserFromJsonStruct(&data)}
+}
+
+// send passes parsed line to next runner, invalid lines are passed to the row errors handler
+// Returns false if reading should stop
+func (r *jsonlDataSourceRunner) send(record jsonlRecord, stage *progress.Stage) bool {
+	if record.err != nil {
+		r.errorCh <- record.err
+		return false
+	}
+	if record.rowErr != nil {
+		if err := r.rowErrors.Handle(record.rowErr); err != nil {
+			r.errorCh <- err
+			return false
+		}
+		return true
+	}
+
+	// Send per user normalized data to next runner, cancel event interrupts waiting for it
+	select {
+	case r.recordCh <- record.record:
+		stage.Inc()
+		return true
+	case <-r.ctx.Done():
+		log.Warning("jsonl datasource shutdown")
+		return false
+	}
+}
@@ -0,0 +1,334 @@
+package jsonl
+
+import (
+	c "context"
+	"fmt"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/rejects"
+	"reflect"
+	s "sync"
+	"testing"
+	"time"
+)
+
+const (
+	NoExFile       = "no_Jsonl_no_ExistFile"
+	ValidJsonlFile = "tmp.*.jsonl"
+)
+
+type inputParameters struct {
+	ctx  c.Context
+	wg   *s.WaitGroup
+	path string
+	rCh  tp.RecordChannel
+	eCh  tp.ErrorChannel
+}
+
+func createTempJSONL(fileName string, content string) (*os.File, error) {
+	f, err := os.CreateTemp("", fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp jsonl file: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the temp jsonl file: %w", err)
+	}
+	return f, nil
+}
+
+func TestNewDataSource_InvalidInputParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    inputParameters
+		errorStr string
+	}{
+		{
+			name:     "noContext",
+			input:    inputParameters{nil, &s.WaitGroup{}, "", tp.NewRecordChannel(0), tp.NewErrorChannel(0)},
+			errorStr: cerror.NewCustomError("invalid context").Error(),
+		},
+		{
+			name:     "noWaitGroup",
+			input:    inputParameters{c.Background(), nil, "", tp.NewRecordChannel(0), tp.NewErrorChannel(0)},
+			errorStr: cerror.NewCustomError("invalid wait group").Error(),
+		},
+		{
+			name:     "noRecordChannel",
+			input:    inputParameters{c.Background(), &s.WaitGroup{}, "", nil, tp.NewErrorChannel(0)},
+			errorStr: cerror.NewCustomError("invalid record channel").Error(),
+		},
+		{
+			name:     "noErrorChannel",
+			input:    inputParameters{c.Background(), &s.WaitGroup{}, "", tp.NewRecordChannel(0), nil},
+			errorStr: cerror.NewCustomError("invalid error channel").Error(),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Input parameters are prepared in the test case
+
+			/* ACT */
+			result, err := NewDataSourceRunner(testCase.input.ctx, testCase.input.wg, testCase.input.path, nil, 1, testCase.input.rCh, testCase.input.eCh)
+
+			/* ASSERT */
+			if err == nil || err.Error() != testCase.errorStr {
+				t.Fatalf("NewDataSourceRunner() : expected error string [%s], got [%v]", testCase.errorStr, err)
+			}
+			if result != nil {
+				t.Fatalf("NewDataSourceRunner() unexpected result: %+v", result)
+			}
+		})
+	}
+}
+
+func TestNewDataSource_RunReadJsonlFile(t *testing.T) {
+	validLine := `{"CampaignId":"9566c74d","Country":"TR","Ltv":[2,4],"Users":2}`
+	validLtvNLine := `{"CampaignId":"6694d2c4","Country":"IT","Ltv1":3,"Ltv2":6,"Ltv3":9,"Users":3}`
+
+	tests := []struct {
+		name            string
+		content         string
+		expectedRecords []*tp.Record
+		errorLine       int
+		errorColumn     string
+		errorReason     string
+	}{
+		{
+			name:    "ValidLines",
+			content: validLine + "\n" + validLtvNLine + "\n",
+			expectedRecords: []*tp.Record{
+				tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2),
+				tp.NewWeightedRecord("6694d2c4", "IT", tp.LtvCollection{1, 2, 3}, 3),
+			},
+		},
+		{
+			name:    "BlankLinesAndNoTrailingNewLine",
+			content: "\n" + validLine + "\r\n  \n" + validLtvNLine,
+			expectedRecords: []*tp.Record{
+				tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2),
+				tp.NewWeightedRecord("6694d2c4", "IT", tp.LtvCollection{1, 2, 3}, 3),
+			},
+		},
+		{
+			name:            "EmptyFile",
+			content:         "",
+			expectedRecords: []*tp.Record{},
+		},
+		{
+			name:            "MalformedLine",
+			content:         validLine + "\n\n" + `{"CampaignId":"9566c74d","Ltv":[1,}` + "\n" + validLine + "\n",
+			expectedRecords: []*tp.Record{tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2)},
+			errorLine:       3,
+			errorReason:     "failed to unmarshal jsonl data",
+		},
+		{
+			name:            "InvalidLtvFieldsLine",
+			content:         validLine + "\n" + `{"CampaignId":"9566c74d","Ltv1":1,"Ltv3":3,"Users":1}` + "\n",
+			expectedRecords: []*tp.Record{tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2)},
+			errorLine:       2,
+			errorColumn:     "Ltv2",
+			errorReason:     "field is missing",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			f, err := createTempJSONL(ValidJsonlFile, testCase.content)
+			if err != nil {
+				t.Fatalf("Failed to create file [%s]", err.Error())
+			}
+			defer os.Remove(f.Name())
+			errorStr := (&cerror.ParseError{
+				Source: f.Name(),
+				Line:   testCase.errorLine,
+				Column: testCase.errorColumn,
+				Reason: testCase.errorReason,
+			}).Error()
+
+			expectedRecords := testCase.expectedRecords
+			in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+			in.wg.Add(1)
+			source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, 1, in.rCh, in.eCh)
+
+			/* ACT */
+			go source.Run()
+
+			/* ASSERT */
+			for {
+				select {
+				// Assert expected record data
+				case result, ok := <-in.rCh:
+					if ok {
+						if len(expectedRecords) == 0 {
+							t.Fatalf("Run() unexpected record: %+v", result)
+						}
+						if !reflect.DeepEqual(expectedRecords[0], result) {
+							t.Fatalf("Run() exp: %+v\ngot: %+v", expectedRecords[0], result)
+						}
+						expectedRecords = expectedRecords[1:]
+					} else {
+						if testCase.errorLine != 0 {
+							t.Fatalf("Run() : expected error [%s]", errorStr)
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() unexpected records slice len exp: %+v\ngot: %+v", 0, len(expectedRecords))
+						}
+						return
+					}
+					// Assert expected error data
+				case err, ok := <-in.eCh:
+					if ok {
+						if testCase.errorLine == 0 || err.Error() != errorStr {
+							t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() records not sent before error: %+v", expectedRecords)
+						}
+						return
+					} else {
+						in.eCh = nil
+					}
+					// Assert potential hang situation
+				case <-time.After(1 * time.Second):
+					t.Fatalf("Run() : timeout")
+				}
+			}
+		})
+	}
+}
+
+func TestNewDataSource_RunInvalidJsonlFileOpening(t *testing.T) {
+	/* ARRANGE */
+	_, openErr := os.Open(NoExFile)
+	errorStr := (&cerror.StageError{Stage: cnst.JsonlDataSourceStage, Source: NoExFile, Reason: "failed to open jsonl file", Err: openErr}).Error()
+	in := inputParameters{c.Background(), &s.WaitGroup{}, NoExFile, tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+	in.wg.Add(1)
+	source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, nil, 1, in.rCh, in.eCh)
+
+	/* ACT */
+	go source.Run()
+
+	/* ASSERT */
+	select {
+	case err := <-in.eCh:
+		if err == nil || err.Error() != errorStr {
+			t.Fatalf("Run() : expected error string [%s], got [%v]", errorStr, err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Run() : timeout")
+	}
+}
+
+func TestNewDataSource_RunCancelReadingJsonlFile(t *testing.T) {
+	/* ARRANGE */
+	f, err := createTempJSONL(ValidJsonlFile, `{"CampaignId":"9566c74d","Country":"TR","Ltv":[2,4],"Users":2}`+"\n")
+	if err != nil {
+		t.Fatalf("Failed to create file [%s]", err.Error())
+	}
+	defer os.Remove(f.Name())
+
+	in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+	in.wg.Add(1)
+	ctx, cancel := c.WithCancel(in.ctx)
+	source, _ := NewDataSourceRunner(ctx, in.wg, in.path, nil, 1, in.rCh, in.eCh)
+	cancel()
+
+	/* ACT */
+	go source.Run()
+
+	/* ASSERT */
+	// Record channel is closed without records on cancel event
+	select {
+	case result, ok := <-in.rCh:
+		if ok {
+			t.Fatalf("Run() expected closed record channel, got: %+v", result)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Run() : timeout")
+	}
+}
+
+func TestNewDataSource_RunQuarantineInvalidJsonlLines(t *testing.T) {
+	content := `{"CampaignId":"9566c74d","Country":"TR","Ltv":[2,4],"Users":2}` + "\n" +
+		`{"CampaignId":"9566c74d","Country":"TR","Ltv":["a"],"Users":2}` + "\n" +
+		`{"CampaignId":` + "\n" +
+		"\n" +
+		`{"CampaignId":"6694d2c4","Country":"IT","Ltv":[3],"Users":3}` + "\n" +
+		`{"CampaignId":"6694d2c4","Country":"IT","Ltv":[4],"Users":` + "\n"
+	expectedRecords := []*tp.Record{
+		tp.NewWeightedRecord("9566c74d", "TR", tp.LtvCollection{1, 2}, 2),
+		tp.NewWeightedRecord("6694d2c4", "IT", tp.LtvCollection{1}, 3),
+	}
+
+	tests := []struct {
+		name    string
+		workers int
+	}{
+		{name: "SingleWorker", workers: 1},
+		{name: "SeveralWorkers", workers: 3},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			f, err := createTempJSONL(ValidJsonlFile, content)
+			if err != nil {
+				t.Fatalf("Failed to create file [%s]", err.Error())
+			}
+			defer os.Remove(f.Name())
+
+			rejectsPath := filepath.Join(t.TempDir(), "rejects.csv")
+			rowErrors, _ := rejects.NewHandler("quarantine", 0, rejectsPath)
+			expectedRejects := "file,line,column,reason\n" +
+				f.Name() + ",2,Ltv.0,failed to unmarshal jsonl data\n" +
+				f.Name() + ",3,,failed to unmarshal jsonl data\n" +
+				f.Name() + ",6,,failed to unmarshal jsonl data\n"
+
+			in := inputParameters{c.Background(), &s.WaitGroup{}, f.Name(), tp.NewRecordChannel(0), tp.NewErrorChannel(0)}
+			in.wg.Add(1)
+			source, _ := NewDataSourceRunner(in.ctx, in.wg, in.path, rowErrors, testCase.workers, in.rCh, in.eCh)
+
+			/* ACT */
+			go source.Run()
+			records := []*tp.Record{}
+			for record := range in.rCh {
+				records = append(records, record)
+			}
+			in.wg.Wait()
+			closeErr := rowErrors.Close()
+
+			/* ASSERT */
+			// Records and rejects are expected in the file order
+			if closeErr != nil {
+				t.Fatalf("Close() unexpected error [%v]", closeErr)
+			}
+			if !reflect.DeepEqual(records, expectedRecords) {
+				t.Fatalf("Run() exp: %+v\ngot: %+v", expectedRecords, records)
+			}
+			if data, _ := os.ReadFile(rejectsPath); string(data) != expectedRejects {
+				t.Fatalf("Run() rejects exp: %q\ngot: %q", expectedRejects, string(data))
+			}
+		})
+	}
+}
+
+func TestNewDataSource_InvalidWorkers(t *testing.T) {
+	/* ARRANGE */
+	errorStr := cerror.NewCustomError("invalid workers number").Error()
+
+	/* ACT */
+	result, err := NewDataSourceRunner(c.Background(), &s.WaitGroup{}, "", nil, 0, tp.NewRecordChannel(0), tp.NewErrorChannel(0))
+
+	/* ASSERT */
+	if result != nil || err == nil || err.Error() != errorStr {
+		t.Fatalf("NewDataSourceRunner() : expected error string [%s], got %v [%v]", errorStr, result, err)
+	}
+}
@@ -0,0 +1,166 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	cnst "playground/internal/constants"
+	"playground/internal/runners/common"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/utils/progress"
+	"sync"
+)
+
+// RunnerFactory creates a data source runner for a single source path
+type RunnerFactory func(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	path string,
+	recordCh t.RecordChannel,
+	errorCh t.ErrorChannel) (common.IRunner, error)
+
+// multiDataSourceRunner represents a data source runner backed by several sources
+// Sources are read one by one, all records are fanned into the single record channel
+type multiDataSourceRunner struct {
+	ctx       context.Context
+	wg        *sync.WaitGroup
+	paths     []string
+	newRunner RunnerFactory
+	recordCh  t.RecordChannel
+	errorCh   t.ErrorChannel
+}
+
+// NewDataSourceRunner initializes and returns multiDataSourceRunner
+// Error channel is shared by the pipeline runners, so it isn't closed by the runner
+// Returns error if some of ctx, wg, newRunner, recordCh, errorCh is nil or paths list is empty
+func NewDataSourceRunner(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	paths []string,
+	newRunner RunnerFactory,
+	recordCh t.RecordChannel,
+	errorCh t.ErrorChannel) (*multiDataSourceRunner, error) {
+
+	// Validate parameters
+	if ctx == nil {
+		return nil, cerror.NewConfigError("context", "", "invalid context")
+	}
+	if wg == nil {
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
+	}
+	if len(paths) == 0 {
+		return nil, cerror.NewConfigError("data sources list", "", "invalid data sources list")
+	}
+	if newRunner == nil {
+		return nil, cerror.NewConfigError("data source runner factory", "", "invalid data source runner factory")
+	}
+	if recordCh == nil {
+		return nil, cerror.NewConfigError("record channel", "", "invalid record channel")
+	}
+	if errorCh == nil {
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
+	}
+
+	return &multiDataSourceRunner{
+		ctx:       ctx,
+		wg:        wg,
+		paths:     paths,
+		newRunner: newRunner,
+		recordCh:  recordCh,
+		errorCh:   errorCh,
+	}, nil
+}
+
+// Run interface implementation, runs source runners one after another
+func (r *multiDataSourceRunner) Run() {
+	go func() {
+		defer r.wg.Done()
+		defer close(r.recordCh)
+
+		// Forwarded records are reported to the pipeline progress tracker, if any
+		stage := progress.FromContext(r.ctx).Stage(cnst.DataSourceStage)
+		defer stage.Finish()
+
+		for _, path := range r.paths {
+			select {
+			// Handle cancel event between sources
+			case <-r.ctx.Done():
+				log.Warning("multi datasource shutdown")
+				return
+
+			default:
+				if !r.forward(path, stage) {
+					return
+				}
+			}
+		}
+		log.Debug("multi datasource finished work")
+	}()
+}
+
+// forward runs source runner for path and forwards its records and errors
+// Errors are completed with the source path, forwarded records are counted by stage
+// Returns false if reading should stop
+func (r *multiDataSourceRunner) forward(path string, stage *progress.Stage) bool {
+	sourceWg := &sync.WaitGroup{}
+	recordCh := t.NewRecordChannel(cnst.RecordChannelBuffer)
+	errorCh := t.NewErrorChannel(cnst.ErrorChannelBuffer)
+
+	// Source runner progress is accounted by the multi runner, so the tracker is hidden from it
+	runner, err := r.newRunner(progress.NewContext(r.ctx, nil), sourceWg, path, recordCh, errorCh)
+	if err != nil {
+		r.errorCh <- withSource(path, err)
+		return false
+	}
+	sourceWg.Add(1)
+	runner.Run()
+	defer sourceWg.Wait()
+
+	for {
+		select {
+		case record, ok := <-recordCh:
+			if !ok {
+				// Source runner finished, its error is sent before the record channel is closed
+				select {
+				case err := <-errorCh:
+					r.errorCh <- withSource(path, err)
+					return false
+				default:
+					return true
+				}
+			}
+			select {
+			case r.recordCh <- record:
+				stage.Inc()
+			case <-r.ctx.Done():
+				return false
+			}
+
+		case err := <-errorCh:
+			r.errorCh <- withSource(path, err)
+			return false
+		}
+	}
+}
+
+// withSource completes err with the source path if it isn't known yet
+// Errors without location are wrapped into the multi datasource stage error
+func withSource(path string, err error) error {
+	var stageErr *cerror.StageError
+	if errors.As(err, &stageErr) {
+		if stageErr.Source == "" {
+			stageErr.Source = path
+		}
+		return err
+	}
+
+	var rowErr *cerror.ParseError
+	if errors.As(err, &rowErr) {
+		if rowErr.Source == "" {
+			rowErr.Source = path
+		}
+		return err
+	}
+	return &cerror.StageError{Stage: cnst.MultiDataSourceStage, Source: path, Err: err}
+}
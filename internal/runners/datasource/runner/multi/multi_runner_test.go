@@ -0,0 +1,209 @@
+package multi
+
+import (
+	c "context"
+	"errors"
+	cnst "playground/internal/constants"
+	"playground/internal/runners/common"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"reflect"
+	s "sync"
+	"testing"
+	"time"
+)
+
+// fakeRunner sends prepared records and optional error, the same way file runners do
+type fakeRunner struct {
+	wg       *s.WaitGroup
+	records  []*tp.Record
+	err      error
+	recordCh tp.RecordChannel
+	errorCh  tp.ErrorChannel
+}
+
+func (r *fakeRunner) Run() {
+	go func() {
+		defer r.wg.Done()
+		defer close(r.recordCh)
+		for _, record := range r.records {
+			r.recordCh <- record
+		}
+		if r.err != nil {
+			r.errorCh <- r.err
+		}
+	}()
+}
+
+// fakeFactory creates fake runners, per path records and errors are taken from the maps
+func fakeFactory(records map[string][]*tp.Record, errs map[string]error, factoryErrs map[string]error) RunnerFactory {
+	return func(ctx c.Context, wg *s.WaitGroup, path string, recordCh tp.RecordChannel, errorCh tp.ErrorChannel) (common.IRunner, error) {
+		if err, ok := factoryErrs[path]; ok {
+			return nil, err
+		}
+		return &fakeRunner{wg: wg, records: records[path], err: errs[path], recordCh: recordCh, errorCh: errorCh}, nil
+	}
+}
+
+func TestNewDataSource_InvalidInputParams(t *testing.T) {
+	factory := fakeFactory(nil, nil, nil)
+	tests := []struct {
+		name      string
+		ctx       c.Context
+		wg        *s.WaitGroup
+		paths     []string
+		newRunner RunnerFactory
+		rCh       tp.RecordChannel
+		eCh       tp.ErrorChannel
+		errorStr  string
+	}{
+		{"noContext", nil, &s.WaitGroup{}, []string{"a"}, factory, tp.NewRecordChannel(0), tp.NewErrorChannel(0),
+			cerror.NewCustomError("invalid context").Error()},
+		{"noWaitGroup", c.Background(), nil, []string{"a"}, factory, tp.NewRecordChannel(0), tp.NewErrorChannel(0),
+			cerror.NewCustomError("invalid wait group").Error()},
+		{"noPaths", c.Background(), &s.WaitGroup{}, nil, factory, tp.NewRecordChannel(0), tp.NewErrorChannel(0),
+			cerror.NewCustomError("invalid data sources list").Error()},
+		{"noRunnerFactory", c.Background(), &s.WaitGroup{}, []string{"a"}, nil, tp.NewRecordChannel(0), tp.NewErrorChannel(0),
+			cerror.NewCustomError("invalid data source runner factory").Error()},
+		{"noRecordChannel", c.Background(), &s.WaitGroup{}, []string{"a"}, factory, nil, tp.NewErrorChannel(0),
+			cerror.NewCustomError("invalid record channel").Error()},
+		{"noErrorChannel", c.Background(), &s.WaitGroup{}, []string{"a"}, factory, tp.NewRecordChannel(0), nil,
+			cerror.NewCustomError("invalid error channel").Error()},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Input parameters are prepared in the test case
+
+			/* ACT */
+			result, err := NewDataSourceRunner(testCase.ctx, testCase.wg, testCase.paths, testCase.newRunner, testCase.rCh, testCase.eCh)
+
+			/* ASSERT */
+			if err == nil || err.Error() != testCase.errorStr {
+				t.Fatalf("NewDataSourceRunner() : expected error string [%s], got [%v]", testCase.errorStr, err)
+			}
+			if result != nil {
+				t.Fatalf("NewDataSourceRunner() unexpected result: %+v", result)
+			}
+		})
+	}
+}
+
+func TestMultiDataSource_Run(t *testing.T) {
+	recordA := tp.NewRecord("a", "TR", tp.LtvCollection{1})
+	recordB := tp.NewRecord("b", "US", tp.LtvCollection{2})
+	recordC := tp.NewRecord("c", "JP", tp.LtvCollection{3})
+
+	tests := []struct {
+		name            string
+		paths           []string
+		records         map[string][]*tp.Record
+		errs            map[string]error
+		factoryErrs     map[string]error
+		expectedRecords []*tp.Record
+		errorStr        string
+	}{
+		{
+			name:            "AllSourcesForwarded",
+			paths:           []string{"a.csv", "b.json", "c.jsonl"},
+			records:         map[string][]*tp.Record{"a.csv": {recordA}, "b.json": {recordB, recordB}, "c.jsonl": {recordC}},
+			expectedRecords: []*tp.Record{recordA, recordB, recordB, recordC},
+		},
+		{
+			name:            "SourceErrorNamesFile",
+			paths:           []string{"a.csv", "b.csv", "c.csv"},
+			records:         map[string][]*tp.Record{"a.csv": {recordA}, "b.csv": {recordB}, "c.csv": {recordC}},
+			errs:            map[string]error{"b.csv": cerror.NewCustomError("failed to convert ltv data")},
+			expectedRecords: []*tp.Record{recordA, recordB},
+			errorStr:        (&cerror.StageError{Stage: cnst.MultiDataSourceStage, Source: "b.csv", Reason: "failed to convert ltv data"}).Error(),
+		},
+		{
+			name:            "RunnerFactoryErrorNamesFile",
+			paths:           []string{"a.csv", "b.csv"},
+			records:         map[string][]*tp.Record{"a.csv": {recordA}},
+			factoryErrs:     map[string]error{"b.csv": errors.New("unsupported")},
+			expectedRecords: []*tp.Record{recordA},
+			errorStr:        (&cerror.StageError{Stage: cnst.MultiDataSourceStage, Source: "b.csv", Reason: "unsupported"}).Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			expectedRecords := testCase.expectedRecords
+			wg := &s.WaitGroup{}
+			rCh := tp.NewRecordChannel(0)
+			eCh := tp.NewErrorChannel(0)
+			source, _ := NewDataSourceRunner(c.Background(), wg, testCase.paths,
+				fakeFactory(testCase.records, testCase.errs, testCase.factoryErrs), rCh, eCh)
+			wg.Add(1)
+
+			/* ACT */
+			go source.Run()
+
+			/* ASSERT */
+			for {
+				select {
+				// Assert expected record data, sources are read in order
+				case result, ok := <-rCh:
+					if ok {
+						if len(expectedRecords) == 0 || !reflect.DeepEqual(expectedRecords[0], result) {
+							t.Fatalf("Run() unexpected record: %+v, expected: %+v", result, expectedRecords)
+						}
+						expectedRecords = expectedRecords[1:]
+					} else {
+						if testCase.errorStr != "" {
+							t.Fatalf("Run() : expected error [%s]", testCase.errorStr)
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() unexpected records slice len exp: %+v\ngot: %+v", 0, len(expectedRecords))
+						}
+						return
+					}
+					// Assert expected error data
+				case err, ok := <-eCh:
+					if ok {
+						if err.Error() != testCase.errorStr {
+							t.Fatalf("Run() : expected error string [%s], got [%s]", testCase.errorStr, err.Error())
+						}
+						if len(expectedRecords) != 0 {
+							t.Fatalf("Run() records not sent before error: %+v", expectedRecords)
+						}
+						return
+					} else {
+						eCh = nil
+					}
+					// Assert potential hang situation
+				case <-time.After(1 * time.Second):
+					t.Fatalf("Run() : timeout")
+				}
+			}
+		})
+	}
+}
+
+func TestMultiDataSource_RunCancel(t *testing.T) {
+	/* ARRANGE */
+	wg := &s.WaitGroup{}
+	rCh := tp.NewRecordChannel(0)
+	eCh := tp.NewErrorChannel(0)
+	ctx, cancel := c.WithCancel(c.Background())
+	source, _ := NewDataSourceRunner(ctx, wg, []string{"a.csv"}, fakeFactory(nil, nil, nil), rCh, eCh)
+	wg.Add(1)
+	cancel()
+
+	/* ACT */
+	go source.Run()
+
+	/* ASSERT */
+	// Record channel is closed without records on cancel event
+	select {
+	case result, ok := <-rCh:
+		if ok {
+			t.Fatalf("Run() expected closed record channel, got: %+v", result)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Run() : timeout")
+	}
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+package order
+
+import (
+	"fmt"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+)
+
+// byKey orders predictions by key in increasing order, keys are unique, so it is the final tie-breaker
+func byKey(a, b *t.PredictedData) bool {
+	return a.Key() < b.Key()
+}
+
+// byValueDesc orders predictions by the first requested day value in decreasing order, ties are ordered by key
+func byValueDesc(a, b *t.PredictedData) bool {
+	if a.Predicted() != b.Predicted() {
+		return a.Predicted() > b.Predicted()
+	}
+	return byKey(a, b)
+}
+
+// byValueAsc orders predictions by the first requested day value in increasing order, ties are ordered by key
+func byValueAsc(a, b *t.PredictedData) bool {
+	if a.Predicted() != b.Predicted() {
+		return a.Predicted() < b.Predicted()
+	}
+	return byKey(a, b)
+}
+
+// bySamples orders predictions by the number of samples in decreasing order, ties are ordered by value and key
+func bySamples(a, b *t.PredictedData) bool {
+	if a.Samples() != b.Samples() {
+		return a.Samples() > b.Samples()
+	}
+	return byValueDesc(a, b)
+}
+
+// NewPredictionOrder returns predictions order according to sort parameter
+// Every order is total, so the results order doesn't depend on the order predictions are received in
+// Returns error if sort parameter is unknown
+func NewPredictionOrder(sortBy string) (t.PredictionOrder, error) {
+	switch sortBy {
+	case cnst.SortValueDesc:
+		return byValueDesc, nil
+	case cnst.SortValueAsc:
+		return byValueAsc, nil
+	case cnst.SortKey:
+		return byKey, nil
+	case cnst.SortSamples:
+		return bySamples, nil
+	default:
+		return nil, cerror.NewConfigError(cnst.CliSortParam, sortBy, fmt.Sprintf("%q invalid sort parameter", sortBy))
+	}
+}
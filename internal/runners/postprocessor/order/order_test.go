@@ -0,0 +1,78 @@
+package order
+
+import (
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// prediction returns predicted data of the key with the value and samples
+func prediction(key string, value float64, samples int) *tp.PredictedData {
+	data := tp.NewPredictedData(key, []tp.Prediction{{Day: 60, Value: value}})
+	data.SetSamples(samples)
+	return data
+}
+
+func TestNewPredictionOrder(t *testing.T) {
+	predictions := []*tp.PredictedData{
+		prediction("US", 10, 5),
+		prediction("DE", 20, 1),
+		prediction("JP", 10, 7),
+		prediction("FR", 5, 7),
+		prediction("AU", 10, 1),
+	}
+
+	tests := []struct {
+		name     string
+		sortBy   string
+		expected []string
+	}{
+		{name: "ValueDesc", sortBy: cnst.SortValueDesc, expected: []string{"DE", "AU", "JP", "US", "FR"}},
+		{name: "ValueAsc", sortBy: cnst.SortValueAsc, expected: []string{"FR", "AU", "JP", "US", "DE"}},
+		{name: "Key", sortBy: cnst.SortKey, expected: []string{"AU", "DE", "FR", "JP", "US"}},
+		{name: "Samples", sortBy: cnst.SortSamples, expected: []string{"JP", "FR", "US", "DE", "AU"}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			less, err := NewPredictionOrder(testCase.sortBy)
+			if err != nil {
+				t.Fatalf("NewPredictionOrder() : unexpected error [%v]", err)
+			}
+
+			// Order mustn't depend on the order predictions are received in
+			for shift := range predictions {
+				received := append(append([]*tp.PredictedData{}, predictions[shift:]...), predictions[:shift]...)
+
+				/* ACT */
+				sort.SliceStable(received, func(i, j int) bool { return less(received[i], received[j]) })
+
+				/* ASSERT */
+				keys := []string{}
+				for _, data := range received {
+					keys = append(keys, data.Key())
+				}
+				if !reflect.DeepEqual(keys, testCase.expected) {
+					t.Fatalf("NewPredictionOrder(%q) : expected %v, got %v", testCase.sortBy, testCase.expected, keys)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPredictionOrder_InvalidSortParam(t *testing.T) {
+	/* ARRANGE */
+	errorStr := cerror.NewCustomError(`"foo" invalid sort parameter`).Error()
+
+	/* ACT */
+	less, err := NewPredictionOrder("foo")
+
+	/* ASSERT */
+	if less != nil || err == nil || err.Error() != errorStr {
+		t.Fatalf("NewPredictionOrder() : expected error string [%s], got [%v]", errorStr, err)
+	}
+}
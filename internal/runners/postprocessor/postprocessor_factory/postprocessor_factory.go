@@ -1,34 +1,60 @@
 package postprocessor_factory
 
 import (
+	"context"
 	"fmt"
 	cnst "playground/internal/constants"
 	"playground/internal/runners/common"
+	"playground/internal/runners/postprocessor/order"
 	"playground/internal/runners/postprocessor/runner"
 	"playground/internal/runners/postprocessor/strategy/campaign"
+	"playground/internal/runners/postprocessor/strategy/composite"
 	"playground/internal/runners/postprocessor/strategy/country"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"strings"
 	"sync"
 )
 
 // NewRunner creates a new data postprocessor runner to prepare predicted data for output
-// According to aggregate parameter
+// According to aggregate parameter, comma separated parameter produces composite key columns
+// Results are ordered according to sort parameter, only the first top ones are kept unless top is 0
 func NewRunner(
+	ctx context.Context,
 	wg *sync.WaitGroup,
 	aggregate string,
+	sortBy string,
+	top uint,
 	predictCh t.PredictorChannel,
-	postCh t.PostProcessorChannel) (common.IRunner, error) {
+	postCh t.PostProcessorChannel,
+	errorCh t.ErrorChannel) (common.IRunner, error) {
 
-	// General Factory logic, create data predictor according to aggregate parameter
+	predictionOrder, err := order.NewPredictionOrder(sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := NewStrategy(aggregate)
+	if err != nil {
+		return nil, err
+	}
+	return runner.NewPostProcessorRunner(ctx, wg, predictCh, postCh, errorCh, strategy, predictionOrder, top)
+}
+
+// NewStrategy creates a postprocessor strategy converting predicted data to results
+// According to aggregate parameter, comma separated parameter produces composite key columns
+func NewStrategy(aggregate string) (t.PostProcessorStrategy, error) {
+	// General Factory logic, create postprocessor strategy according to aggregate parameter
 	switch aggregate {
 	case cnst.AggregateCountry:
-		return runner.NewPostProcessorRunner(wg, predictCh, postCh,
-			country.NewPostProcessorStrategy())
+		return country.NewPostProcessorStrategy(), nil
 	case cnst.AggregateCampaign:
-		return runner.NewPostProcessorRunner(wg, predictCh, postCh,
-			campaign.NewPostProcessorStrategy())
+		return campaign.NewPostProcessorStrategy(), nil
 	default:
-		return nil, cerror.NewCustomError(fmt.Sprintf("%q invalid postprocessor parameter", aggregate))
+		dimensions := strings.Split(aggregate, cnst.AggregateSeparator)
+		if len(dimensions) > 1 {
+			return composite.NewPostProcessorStrategy(dimensions)
+		}
+		return nil, cerror.NewConfigError(cnst.CliAggregateParam, aggregate, fmt.Sprintf("%q invalid postprocessor parameter", aggregate))
 	}
 }
@@ -1,6 +1,7 @@
 package postprocessor_factory
 
 import (
+	"context"
 	"fmt"
 	cnst "playground/internal/constants"
 	"playground/internal/types"
@@ -11,28 +12,52 @@ import (
 
 const (
 	InvalidPostProcessorParameter = "PostProcessSomethingUnPostProcessable"
+	InvalidSortParameter          = "SortSomethingUnsortable"
 )
 
 func TestNewRunner(t *testing.T) {
 	tests := []struct {
 		name          string
 		postProcessor string
+		sortBy        string
 		expectedError bool
 		errorStr      string
 	}{
 		{
 			name:          "InvalidPostProcessorParameter",
 			postProcessor: InvalidPostProcessorParameter,
+			sortBy:        cnst.SortValueDesc,
 			expectedError: true,
 			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid postprocessor parameter", InvalidPostProcessorParameter)).Error(),
 		},
+		{
+			name:          "InvalidCompositePostProcessorParameter",
+			postProcessor: cnst.AggregateCountry + cnst.AggregateSeparator + InvalidPostProcessorParameter,
+			sortBy:        cnst.SortValueDesc,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid postprocessor dimension", InvalidPostProcessorParameter)).Error(),
+		},
+		{
+			name:          "InvalidSortParameter",
+			postProcessor: cnst.AggregateCountry,
+			sortBy:        InvalidSortParameter,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid sort parameter", InvalidSortParameter)).Error(),
+		},
 		{
 			name:          "CountryPostProcessorParameter",
 			postProcessor: cnst.AggregateCountry,
+			sortBy:        cnst.SortValueDesc,
 		},
 		{
 			name:          "CampaignPostProcessorParameter",
 			postProcessor: cnst.AggregateCampaign,
+			sortBy:        cnst.SortKey,
+		},
+		{
+			name:          "CompositePostProcessorParameter",
+			postProcessor: cnst.AggregateCampaign + cnst.AggregateSeparator + cnst.AggregateCountry,
+			sortBy:        cnst.SortSamples,
 		},
 	}
 
@@ -43,9 +68,10 @@ func TestNewRunner(t *testing.T) {
 			wg := &sync.WaitGroup{}
 			predictCh := types.NewPredictorChannel(0)
 			postProcCh := types.NewPostProcessorChannel(0)
+			errorCh := types.NewErrorChannel(0)
 
 			/* ACT */
-			_, err := NewRunner(wg, testCase.postProcessor, predictCh, postProcCh)
+			_, err := NewRunner(context.Background(), wg, testCase.postProcessor, testCase.sortBy, 0, predictCh, postProcCh, errorCh)
 
 			/* ASSERT */
 			// Assert expected error string
@@ -60,3 +86,38 @@ func TestNewRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestNewStrategy(t *testing.T) {
+	tests := []struct {
+		name           string
+		aggregate      string
+		expectedLabel  string
+		expectedError  bool
+		predictedInput []string
+	}{
+		{name: "Country", aggregate: cnst.AggregateCountry, predictedInput: []string{"JP"}, expectedLabel: "JP"},
+		{name: "Campaign", aggregate: cnst.AggregateCampaign, predictedInput: []string{"9566c74d"}, expectedLabel: "<9566c74d>"},
+		{name: "Composite", aggregate: cnst.AggregateCountry + cnst.AggregateSeparator + cnst.AggregateCampaign,
+			predictedInput: []string{"JP", "9566c74d"}, expectedLabel: "JP <9566c74d>"},
+		{name: "Invalid", aggregate: InvalidPostProcessorParameter, expectedError: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			strategy, err := NewStrategy(testCase.aggregate)
+
+			/* ASSERT */
+			if (err != nil) != testCase.expectedError {
+				t.Fatalf("NewStrategy() : expected error %v, got %v", testCase.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			result := strategy(types.NewCompositePredictedData(testCase.predictedInput, nil))
+			if result.Label() != testCase.expectedLabel {
+				t.Fatalf("NewStrategy() exp label: %s\ngot: %s", testCase.expectedLabel, result.Label())
+			}
+		})
+	}
+}
@@ -1,47 +1,74 @@
 package runner
 
 import (
+	"context"
 	log "github.com/sirupsen/logrus"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/progress"
 	"sort"
 	"sync"
 )
 
 // postProcessorRunner represents a postprocessor backed by a postprocessing strategy
 type postProcessorRunner struct {
+	ctx              context.Context
 	wg               *sync.WaitGroup
 	predictorCh      t.PredictorChannel
 	postProcessorCh  t.PostProcessorChannel
+	errorCh          t.ErrorChannel
 	postProcStrategy t.PostProcessorStrategy
+	order            t.PredictionOrder
+	top              uint
 }
 
 // NewPostProcessorRunner initializes and returns postProcessorRunner
-// Returns error if some of wg, predictorCh, postProcessorCh, postProcStrategy is nil
+// Duplicated predictions are reported to errorCh and skipped, errorCh isn't closed by the runner
+// Cancelled ctx doesn't stop reading, predictorCh is drained and the predictions are sent as partial results
+// Results are sent in the provided order, only the first top ones unless top is 0
+// Returns error if some of ctx, wg, predictorCh, postProcessorCh, errorCh, postProcStrategy, order is nil
 func NewPostProcessorRunner(
+	ctx context.Context,
 	wg *sync.WaitGroup,
 	predictorCh t.PredictorChannel,
 	postProcessorCh t.PostProcessorChannel,
-	postProcStrategy t.PostProcessorStrategy) (*postProcessorRunner, error) {
+	errorCh t.ErrorChannel,
+	postProcStrategy t.PostProcessorStrategy,
+	order t.PredictionOrder,
+	top uint) (*postProcessorRunner, error) {
 
+	if ctx == nil {
+		return nil, cerror.NewConfigError("context", "", "invalid context")
+	}
 	if wg == nil {
-		return nil, cerror.NewCustomError("invalid wait group")
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
 	}
 	if predictorCh == nil {
-		return nil, cerror.NewCustomError("invalid predictor channel")
+		return nil, cerror.NewConfigError("predictor channel", "", "invalid predictor channel")
 	}
 	if postProcessorCh == nil {
-		return nil, cerror.NewCustomError("invalid postprocessor channel")
+		return nil, cerror.NewConfigError("postprocessor channel", "", "invalid postprocessor channel")
+	}
+	if errorCh == nil {
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
 	}
 	if postProcStrategy == nil {
-		return nil, cerror.NewCustomError("invalid postprocessor strategy")
+		return nil, cerror.NewConfigError("postprocessor strategy", "", "invalid postprocessor strategy")
+	}
+	if order == nil {
+		return nil, cerror.NewConfigError("prediction order", "", "invalid prediction order")
 	}
 
 	return &postProcessorRunner{
+		ctx:              ctx,
 		wg:               wg,
 		predictorCh:      predictorCh,
 		postProcessorCh:  postProcessorCh,
+		errorCh:          errorCh,
 		postProcStrategy: postProcStrategy,
+		order:            order,
+		top:              top,
 	}, nil
 }
 
@@ -50,26 +77,58 @@ func (r *postProcessorRunner) Run() {
 	defer close(r.postProcessorCh)
 	defer r.wg.Done()
 
+	// Received predictions are reported to the pipeline progress tracker, if any
+	stage := progress.FromContext(r.ctx).Stage(cnst.PostProcessorStage)
+	defer stage.Finish()
+
 	predictions := make([]*t.PredictedData, 0)
+	keys := map[string]bool{}
 
-	// Read and store predicted data
+	// Read and store predicted data until predictor channel is open
+	// Cancel event doesn't stop reading, predictor sends the predictions of the data received so far and stops
 	for predictData := range r.predictorCh {
-		// Received cancel event
-		if predictData == nil {
-			log.Warning("postprocessor runner shutdown")
-			return
+		stage.Inc()
+
+		// Each key is predicted once, the second prediction means broken key aggregation
+		if keys[predictData.Key()] {
+			r.errorCh <- &cerror.StageError{
+				Stage:  cnst.PostProcessorStage,
+				Source: predictData.Key(),
+				Reason: "duplicated prediction skipped",
+			}
+			continue
 		}
+		keys[predictData.Key()] = true
 		predictions = append(predictions, predictData)
 	}
 
-	// Sort predicted data in decreasing order
-	sort.Slice(predictions, func(i, j int) bool {
-		return predictions[i].Predicted() > predictions[j].Predicted()
+	// Predictions of cancelled run are partial results
+	if r.ctx.Err() != nil {
+		log.Warning("postprocessor runner shutdown")
+		r.send(predictions, true)
+		return
+	}
+	r.send(predictions, false)
+	log.Debug("postprocessor runner finished work")
+}
+
+// send orders predictions and sends the top ones as results, marked partial if requested
+func (r *postProcessorRunner) send(predictions []*t.PredictedData, partial bool) {
+	sort.SliceStable(predictions, func(i, j int) bool {
+		return r.order(predictions[i], predictions[j])
 	})
+	if r.top != 0 && uint(len(predictions)) > r.top {
+		predictions = predictions[:r.top]
+	}
 
 	// Convert predicted data to output string, according to postprocessor strategy implementation
 	for _, prediction := range predictions {
-		r.postProcessorCh <- r.postProcStrategy(prediction)
+		result := r.postProcStrategy(prediction)
+		result.SetModel(prediction.Model())
+		result.SetConfidence(prediction.Confidence())
+		if partial {
+			result.MarkPartial()
+		}
+		r.postProcessorCh <- result
 	}
-	log.Debug("postprocessor runner finished work")
 }
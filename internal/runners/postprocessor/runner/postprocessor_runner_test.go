@@ -1,7 +1,10 @@
 package runner
 
 import (
+	c "context"
 	"fmt"
+	cnst "playground/internal/constants"
+	"playground/internal/runners/postprocessor/order"
 	"playground/internal/runners/postprocessor/strategy/campaign"
 	"playground/internal/runners/postprocessor/strategy/country"
 	tp "playground/internal/types"
@@ -14,10 +17,20 @@ import (
 )
 
 type inputParameters struct {
+	ctx    c.Context
 	wg     *s.WaitGroup
 	pCh    tp.PredictorChannel
 	postCh tp.PostProcessorChannel
+	eCh    tp.ErrorChannel
 	pSt    tp.PostProcessorStrategy
+	ord    tp.PredictionOrder
+	top    uint
+}
+
+// valueDesc returns the default prediction order
+func valueDesc() tp.PredictionOrder {
+	predictionOrder, _ := order.NewPredictionOrder(cnst.SortValueDesc)
+	return predictionOrder
 }
 
 type newPostProcessorResult struct {
@@ -32,37 +45,55 @@ func TestNewPostProcessorRunner_InvalidInputParams(t *testing.T) {
 		expectedResult newPostProcessorResult
 		expectedError  bool
 	}{
+		{
+			name:           "noContext",
+			input:          inputParameters{nil, &s.WaitGroup{}, nil, nil, nil, nil, nil, 0},
+			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid context")},
+			expectedError:  true,
+		},
 		{
 			name:           "noWaitGroup",
-			input:          inputParameters{nil, nil, nil, nil},
+			input:          inputParameters{c.Background(), nil, nil, nil, nil, nil, nil, 0},
 			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid wait group")},
 			expectedError:  true,
 		},
 		{
 			name:           "noPredictChannel",
-			input:          inputParameters{&s.WaitGroup{}, nil, nil, nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, nil, nil, nil, nil, nil, 0},
 			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid predictor channel")},
 			expectedError:  true,
 		},
 		{
 			name:           "noPostProcessorChannel",
-			input:          inputParameters{&s.WaitGroup{}, tp.NewPredictorChannel(0), nil, nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewPredictorChannel(0), nil, nil, nil, nil, 0},
 			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid postprocessor channel")},
 			expectedError:  true,
 		},
+		{
+			name:           "noErrorChannel",
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewPredictorChannel(0), tp.NewPostProcessorChannel(0), nil, nil, nil, 0},
+			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid error channel")},
+			expectedError:  true,
+		},
 		{
 			name:           "noPredictStrategy",
-			input:          inputParameters{&s.WaitGroup{}, tp.NewPredictorChannel(0), tp.NewPostProcessorChannel(0), nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewPredictorChannel(0), tp.NewPostProcessorChannel(0), tp.NewErrorChannel(0), nil, nil, 0},
 			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid postprocessor strategy")},
 			expectedError:  true,
 		},
+		{
+			name:           "noPredictionOrder",
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, tp.NewPredictorChannel(0), tp.NewPostProcessorChannel(0), tp.NewErrorChannel(0), country.NewPostProcessorStrategy(), nil, 0},
+			expectedResult: newPostProcessorResult{postProcessor: nil, err: cerror.NewCustomError("invalid prediction order")},
+			expectedError:  true,
+		},
 	}
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
 			/* ARRANGE */
 
 			/* ACT */
-			result, err := NewPostProcessorRunner(testCase.input.wg, testCase.input.pCh, testCase.input.postCh, testCase.input.pSt)
+			result, err := NewPostProcessorRunner(testCase.input.ctx, testCase.input.wg, testCase.input.pCh, testCase.input.postCh, testCase.input.eCh, testCase.input.pSt, testCase.input.ord, testCase.input.top)
 
 			/* ASSERT */
 			// Assert expected error
@@ -90,14 +121,18 @@ func TestNewPostProcessorRunner_InvalidInputParams(t *testing.T) {
 func TestNewPostProcessorRunner_ValidInputParamsCountryStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
 		tp.NewPredictorChannel(0),
 		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
 		country.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
 	}
 
 	/* ACT */
-	result, err := NewPostProcessorRunner(in.wg, in.pCh, in.postCh, in.pSt)
+	result, err := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
 	// Assert unexpected error
 	if err != nil {
 		t.Fatalf("NewPostProcessorRunner() : expected error string [%v], got [%v]", nil, err)
@@ -112,25 +147,34 @@ func TestNewPostProcessorRunner_ValidInputParamsCountryStrategy(t *testing.T) {
 func TestNewPostProcessorRunner_RunWithCountryStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
 		tp.NewPredictorChannel(0),
 		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
 		country.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
 	}
 	//Prepare predicted data
 	predicted := []*tp.PredictedData{
-		tp.NewPredictedData("JP", 123.123),
-		tp.NewPredictedData("US", 9999.99999),
+		tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 123.123}}),
+		tp.NewPredictedData("US", []tp.Prediction{{Day: 60, Value: 9999.99999}}),
 	}
+	// Model chosen for the key and the predictions intervals are passed to the result
+	predicted[0].SetModel(cnst.PowerLawPredictorModel)
+	predicted[1].SetIntervals(0.9, []float64{9000}, []float64{11000})
 	// Iterate in reverse order cuz postprocessor sort data
-	expectedPostProcData := []string{}
+	expectedPostProcData := []*tp.Result{}
 	for i := len(predicted) - 1; i >= 0; i-- {
-		expectedPostProcData = append(expectedPostProcData,
-			fmt.Sprintf("%s: %.2f", predicted[i].Key(), predicted[i].Predicted()))
+		result := tp.NewResult(predicted[i].Key(), []tp.Dimension{{Name: cnst.AggregateCountry, Value: predicted[i].Key()}}, predicted[i].Predictions())
+		result.SetModel(predicted[i].Model())
+		result.SetConfidence(predicted[i].Confidence())
+		expectedPostProcData = append(expectedPostProcData, result)
 	}
 
 	in.wg.Add(1)
-	postProcessor, _ := NewPostProcessorRunner(in.wg, in.pCh, in.postCh, in.pSt)
+	postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
 
 	/* ACT */
 	// Mock aggregated streamer
@@ -172,18 +216,25 @@ func TestNewPostProcessorRunner_RunWithCountryStrategy(t *testing.T) {
 
 func TestNewPostProcessorRunner_RunWithCountryStrategyAndCancelEvent(t *testing.T) {
 	/* ARRANGE */
+	// Cancel event is received before the predictor channel is closed
+	ctx, cancel := c.WithCancel(c.Background())
+	cancel()
 	in := inputParameters{
+		ctx,
 		&s.WaitGroup{},
 		tp.NewPredictorChannel(0),
 		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
 		country.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
 	}
-	//Prepare cancel event
-	predicted := []*tp.PredictedData{nil}
+	// No predictions are received
+	predicted := []*tp.PredictedData{}
 
 	expectedGoroutines := runtime.NumGoroutine()
 	in.wg.Add(1)
-	postProcessor, _ := NewPostProcessorRunner(in.wg, in.pCh, in.postCh, in.pSt)
+	postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
 
 	/* ACT */
 	// Mock aggregated streamer
@@ -221,28 +272,101 @@ func TestNewPostProcessorRunner_RunWithCountryStrategyAndCancelEvent(t *testing.
 	}
 }
 
+func TestNewPostProcessorRunner_RunWithPredictionsAndCancelEvent(t *testing.T) {
+	/* ARRANGE */
+	// Cancel event is received before the predictor channel is closed
+	ctx, cancel := c.WithCancel(c.Background())
+	cancel()
+	in := inputParameters{
+		ctx,
+		&s.WaitGroup{},
+		tp.NewPredictorChannel(0),
+		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
+		country.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
+	}
+	//Prepare predicted data
+	predicted := []*tp.PredictedData{
+		tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 123.123}}),
+		tp.NewPredictedData("US", []tp.Prediction{{Day: 60, Value: 9999.99999}}),
+	}
+	// Predictions of cancelled run are sorted and marked partial
+	expectedPostProcData := []*tp.Result{}
+	for i := len(predicted) - 1; i >= 0; i-- {
+		result := tp.NewResult(predicted[i].Key(), []tp.Dimension{{Name: cnst.AggregateCountry, Value: predicted[i].Key()}}, predicted[i].Predictions())
+		result.MarkPartial()
+		expectedPostProcData = append(expectedPostProcData, result)
+	}
+
+	in.wg.Add(1)
+	postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
+
+	/* ACT */
+	// Mock aggregated streamer
+	go func() {
+		defer close(in.pCh)
+		for _, predictedData := range predicted {
+			in.pCh <- predictedData
+		}
+	}()
+	go postProcessor.Run()
+
+	/* ASSERT */
+	for {
+		select {
+		// Assert expected postprocessed data
+		case result, ok := <-in.postCh:
+			if ok {
+				// Assert result
+				expected := expectedPostProcData[0]
+				if !reflect.DeepEqual(expected, result) {
+					t.Fatalf("Run() exp: %+v\ngot: %+v", expected, result)
+				}
+				// Remove 1 element, slice as a queue )
+				expectedPostProcData = expectedPostProcData[1:]
+
+			} else {
+				// Assert empty expected records list
+				if len(expectedPostProcData) != 0 {
+					t.Fatalf("Run() unexpected postprocessor slice len exp: %+v\ngot: %+v", 0, len(expectedPostProcData))
+				}
+				return
+			}
+			// Assert potential hang situation
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
+
 func TestNewPostProcessorRunner_RunWithCampaignStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
 		tp.NewPredictorChannel(0),
 		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
 		campaign.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
 	}
 	//Prepare predicted data
 	predicted := []*tp.PredictedData{
-		tp.NewPredictedData("JP", 123.123),
-		tp.NewPredictedData("US", 9999.99999),
+		tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 123.123}}),
+		tp.NewPredictedData("US", []tp.Prediction{{Day: 60, Value: 9999.99999}}),
 	}
 	// Iterate in reverse order cuz postprocessor sort data
-	expectedPostProcData := []string{}
+	expectedPostProcData := []*tp.Result{}
 	for i := len(predicted) - 1; i >= 0; i-- {
 		expectedPostProcData = append(expectedPostProcData,
-			fmt.Sprintf("<%s>: %.2f", predicted[i].Key(), predicted[i].Predicted()))
+			tp.NewResult(fmt.Sprintf("<%s>", predicted[i].Key()), []tp.Dimension{{Name: cnst.AggregateCampaign, Value: predicted[i].Key()}}, predicted[i].Predictions()))
 	}
 
 	in.wg.Add(1)
-	postProcessor, _ := NewPostProcessorRunner(in.wg, in.pCh, in.postCh, in.pSt)
+	postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
 
 	/* ACT */
 	// Mock aggregated streamer
@@ -284,18 +408,25 @@ func TestNewPostProcessorRunner_RunWithCampaignStrategy(t *testing.T) {
 
 func TestNewPostProcessorRunner_RunWithCampaignStrategyAndCancelEvent(t *testing.T) {
 	/* ARRANGE */
+	// Cancel event is received before the predictor channel is closed
+	ctx, cancel := c.WithCancel(c.Background())
+	cancel()
 	in := inputParameters{
+		ctx,
 		&s.WaitGroup{},
 		tp.NewPredictorChannel(0),
 		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
 		campaign.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
 	}
-	// Prepare cancel event
-	predicted := []*tp.PredictedData{nil}
+	// No predictions are received
+	predicted := []*tp.PredictedData{}
 
 	expectedGoroutines := runtime.NumGoroutine()
 	in.wg.Add(1)
-	postProcessor, _ := NewPostProcessorRunner(in.wg, in.pCh, in.postCh, in.pSt)
+	postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
 
 	/* ACT */
 	// Mock aggregated streamer
@@ -332,3 +463,154 @@ func TestNewPostProcessorRunner_RunWithCampaignStrategyAndCancelEvent(t *testing
 		}
 	}
 }
+
+func TestNewPostProcessorRunner_RunWithDuplicatedPrediction(t *testing.T) {
+	/* ARRANGE */
+	in := inputParameters{
+		c.Background(),
+		&s.WaitGroup{},
+		tp.NewPredictorChannel(0),
+		tp.NewPostProcessorChannel(0),
+		tp.NewErrorChannel(0),
+		country.NewPostProcessorStrategy(),
+		valueDesc(),
+		0,
+	}
+	// Prepare predicted data, the second JP prediction is a duplicate
+	predicted := []*tp.PredictedData{
+		tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 123.123}}),
+		tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 9999.99999}}),
+	}
+	expectedPostProcData := []*tp.Result{
+		tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, predicted[0].Predictions()),
+	}
+	errorStr := (&cerror.StageError{Stage: cnst.PostProcessorStage, Source: "JP", Reason: "duplicated prediction skipped"}).Error()
+
+	in.wg.Add(1)
+	postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
+
+	/* ACT */
+	// Mock predicted data streamer
+	go func() {
+		defer close(in.pCh)
+		for _, predictedData := range predicted {
+			in.pCh <- predictedData
+		}
+	}()
+	go postProcessor.Run()
+
+	/* ASSERT */
+	reported := false
+	for {
+		select {
+		// Assert expected postprocessed data
+		case result, ok := <-in.postCh:
+			if ok {
+				if len(expectedPostProcData) == 0 || !reflect.DeepEqual(expectedPostProcData[0], result) {
+					t.Fatalf("Run() unexpected postprocessed data: %+v", result)
+				}
+				expectedPostProcData = expectedPostProcData[1:]
+			} else {
+				if !reported || len(expectedPostProcData) != 0 {
+					t.Fatalf("Run() expected error reported and all data sent, reported: %v, left: %+v", reported, expectedPostProcData)
+				}
+				return
+			}
+			// Assert expected error data
+		case err := <-in.eCh:
+			if err.Error() != errorStr {
+				t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+			}
+			reported = true
+			// Assert potential hang situation
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Run() : timeout")
+		}
+	}
+}
+
+func TestNewPostProcessorRunner_RunWithOrderAndTop(t *testing.T) {
+	tests := []struct {
+		name         string
+		sortBy       string
+		top          uint
+		expectedKeys []string
+	}{
+		{
+			name:         "ValueDescTiesByKey",
+			sortBy:       cnst.SortValueDesc,
+			expectedKeys: []string{"US", "DE", "JP", "AU"},
+		},
+		{
+			name:         "KeyTopTwo",
+			sortBy:       cnst.SortKey,
+			top:          2,
+			expectedKeys: []string{"AU", "DE"},
+		},
+		{
+			name:         "TopAboveLength",
+			sortBy:       cnst.SortValueAsc,
+			top:          10,
+			expectedKeys: []string{"AU", "DE", "JP", "US"},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			predictionOrder, err := order.NewPredictionOrder(testCase.sortBy)
+			if err != nil {
+				t.Fatalf("NewPredictionOrder() unexpected error: %v", err)
+			}
+			in := inputParameters{
+				c.Background(),
+				&s.WaitGroup{},
+				tp.NewPredictorChannel(0),
+				tp.NewPostProcessorChannel(0),
+				tp.NewErrorChannel(0),
+				country.NewPostProcessorStrategy(),
+				predictionOrder,
+				testCase.top,
+			}
+			// DE and JP have the same value, the tie is broken by key
+			predicted := []*tp.PredictedData{
+				tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 2}}),
+				tp.NewPredictedData("US", []tp.Prediction{{Day: 60, Value: 3}}),
+				tp.NewPredictedData("AU", []tp.Prediction{{Day: 60, Value: 1}}),
+				tp.NewPredictedData("DE", []tp.Prediction{{Day: 60, Value: 2}}),
+			}
+
+			in.wg.Add(1)
+			postProcessor, _ := NewPostProcessorRunner(in.ctx, in.wg, in.pCh, in.postCh, in.eCh, in.pSt, in.ord, in.top)
+
+			/* ACT */
+			// Mock predicted data streamer
+			go func() {
+				defer close(in.pCh)
+				for _, predictedData := range predicted {
+					in.pCh <- predictedData
+				}
+			}()
+			go postProcessor.Run()
+
+			/* ASSERT */
+			keys := []string{}
+			for {
+				select {
+				case result, ok := <-in.postCh:
+					if ok {
+						keys = append(keys, result.Label())
+						continue
+					}
+					// Assert results order and limit
+					if !reflect.DeepEqual(testCase.expectedKeys, keys) {
+						t.Fatalf("Run() exp: %+v\ngot: %+v", testCase.expectedKeys, keys)
+					}
+					return
+					// Assert potential hang situation
+				case <-time.After(1 * time.Second):
+					t.Fatalf("Run() : timeout")
+				}
+			}
+		})
+	}
+}
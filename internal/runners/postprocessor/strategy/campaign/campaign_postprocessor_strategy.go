@@ -2,12 +2,15 @@ package campaign
 
 import (
 	"fmt"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 )
 
 // campaignPostProcessor campaign postprocessor strategy predicted data conversion strategy function
-func campaignPostProcessor(data *t.PredictedData) string {
-	return fmt.Sprintf("<%s>: %.2f", data.Key(), data.Predicted())
+func campaignPostProcessor(data *t.PredictedData) *t.Result {
+	return t.NewResult(fmt.Sprintf("<%s>", data.Key()),
+		[]t.Dimension{{Name: cnst.AggregateCampaign, Value: data.Key()}},
+		data.Predictions())
 }
 
 // NewPostProcessorStrategy returns campaign postprocessor strategy predicted data convertor strategy
@@ -0,0 +1,47 @@
+package composite
+
+import (
+	"fmt"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"strings"
+)
+
+// dimensionFormats maps aggregation dimension name to dimension value output format
+var dimensionFormats = map[string]string{
+	cnst.AggregateCampaign: "<%s>",
+	cnst.AggregateCountry:  "%s",
+}
+
+// NewPostProcessorStrategy returns composite key predicted data convertor strategy
+// Dimension values are kept as separate named result dimensions
+// Returns error in cases of unknown or duplicated dimension
+func NewPostProcessorStrategy(dimensions []string) (t.PostProcessorStrategy, error) {
+	names := make([]string, 0, len(dimensions))
+	formats := make([]string, 0, len(dimensions))
+	known := map[string]bool{}
+	for _, dimension := range dimensions {
+		dimension = strings.TrimSpace(dimension)
+		format, found := dimensionFormats[dimension]
+		if !found || known[dimension] {
+			return nil, cerror.NewConfigError(cnst.CliAggregateParam, dimension, fmt.Sprintf("%q invalid postprocessor dimension", dimension))
+		}
+		known[dimension] = true
+		names = append(names, dimension)
+		formats = append(formats, format)
+	}
+
+	return func(data *t.PredictedData) *t.Result {
+		labels := make([]string, 0, len(names))
+		resultDimensions := make([]t.Dimension, 0, len(names))
+		for i, value := range data.Dimensions() {
+			if i >= len(names) {
+				break
+			}
+			labels = append(labels, fmt.Sprintf(formats[i], value))
+			resultDimensions = append(resultDimensions, t.Dimension{Name: names[i], Value: value})
+		}
+		return t.NewResult(strings.Join(labels, " "), resultDimensions, data.Predictions())
+	}, nil
+}
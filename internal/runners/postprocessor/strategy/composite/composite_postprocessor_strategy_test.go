@@ -0,0 +1,59 @@
+package composite
+
+import (
+	"fmt"
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"reflect"
+	"testing"
+)
+
+func TestNewPostProcessorStrategy_InvalidDimensions(t *testing.T) {
+	/* ARRANGE */
+	dimensions := []string{cnst.AggregateCountry, "city"}
+	errorStr := cerror.NewCustomError(fmt.Sprintf("%q invalid postprocessor dimension", "city")).Error()
+
+	/* ACT */
+	strategy, err := NewPostProcessorStrategy(dimensions)
+
+	/* ASSERT */
+	if strategy != nil {
+		t.Fatalf("NewPostProcessorStrategy() with args %v: unexpected strategy", dimensions)
+	}
+	if err == nil || err.Error() != errorStr {
+		t.Fatalf("NewPostProcessorStrategy() with args %v: expected error string [%s], got [%v]", dimensions, errorStr, err)
+	}
+}
+
+func TestNewPostProcessorStrategy(t *testing.T) {
+	/* ARRANGE */
+	//Prepare predicted data
+	predictedData := []*tp.PredictedData{
+		tp.NewCompositePredictedData([]string{"JP", "9566c74d"}, []tp.Prediction{{Day: 60, Value: 123.123}}),
+		tp.NewCompositePredictedData([]string{"US", "6325253f"}, []tp.Prediction{{Day: 30, Value: 1}, {Day: 60, Value: 9999.99999}}),
+	}
+	expectedPostProcData := []*tp.Result{
+		tp.NewResult("JP <9566c74d>",
+			[]tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}, {Name: cnst.AggregateCampaign, Value: "9566c74d"}},
+			predictedData[0].Predictions()),
+		tp.NewResult("US <6325253f>",
+			[]tp.Dimension{{Name: cnst.AggregateCountry, Value: "US"}, {Name: cnst.AggregateCampaign, Value: "6325253f"}},
+			predictedData[1].Predictions()),
+	}
+
+	/* ACT */
+	strategy, err := NewPostProcessorStrategy([]string{cnst.AggregateCountry, cnst.AggregateCampaign})
+
+	/* ASSERT */
+	if err != nil {
+		t.Fatalf("NewPostProcessorStrategy() : unexpected error [%s]", err.Error())
+	}
+	for i, data := range predictedData {
+		resultPostProc := strategy(data)
+		if !reflect.DeepEqual(expectedPostProcData[i], resultPostProc) {
+			t.Fatalf("NewPostProcessorStrategy() exp: %+v\ngot: %+v",
+				expectedPostProcData[i], resultPostProc)
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package country
 
 import (
-	"fmt"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 )
 
 // countryPostProcessor country postprocessor predicted data conversion strategy function
-func countryPostProcessor(data *t.PredictedData) string {
-	return fmt.Sprintf("%s: %.2f", data.Key(), data.Predicted())
+func countryPostProcessor(data *t.PredictedData) *t.Result {
+	return t.NewResult(data.Key(),
+		[]t.Dimension{{Name: cnst.AggregateCountry, Value: data.Key()}},
+		data.Predictions())
 }
 
 // NewPostProcessorStrategy returns country postprocessor strategy predicted data convertor strategy
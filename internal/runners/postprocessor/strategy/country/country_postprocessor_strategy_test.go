@@ -1,7 +1,7 @@
 package country
 
 import (
-	"fmt"
+	cnst "playground/internal/constants"
 	tp "playground/internal/types"
 	"reflect"
 	"testing"
@@ -11,13 +11,13 @@ func TestNewPostProcessorStrategy(t *testing.T) {
 	/* ARRANGE */
 	//Prepare predicted data
 	predictedData := []*tp.PredictedData{
-		tp.NewPredictedData("JP", 123.123),
-		tp.NewPredictedData("US", 9999.99999),
+		tp.NewPredictedData("JP", []tp.Prediction{{Day: 60, Value: 123.123}}),
+		tp.NewPredictedData("US", []tp.Prediction{{Day: 60, Value: 9999.99999}}),
 	}
-	expectedPostProcData := []string{}
+	expectedPostProcData := []*tp.Result{}
 	for _, data := range predictedData {
 		expectedPostProcData = append(expectedPostProcData,
-			fmt.Sprintf("%s: %.2f", data.Key(), data.Predicted()))
+			tp.NewResult(data.Key(), []tp.Dimension{{Name: cnst.AggregateCountry, Value: data.Key()}}, data.Predictions()))
 	}
 	strategy := NewPostProcessorStrategy()
 
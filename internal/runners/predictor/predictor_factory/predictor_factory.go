@@ -1,32 +1,101 @@
 package predictor_factory
 
 import (
+	"context"
 	"fmt"
 	cnst "playground/internal/constants"
 	"playground/internal/runners/common"
 	pr "playground/internal/runners/predictor/runner"
-	"playground/internal/runners/predictor/strategy/average"
-	"playground/internal/runners/predictor/strategy/linext"
+	"playground/internal/runners/predictor/strategy/auto"
+	"playground/internal/runners/predictor/strategy/curve"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/predictor"
+	"slices"
+	"strconv"
 	"sync"
 )
 
+// models are the registered curve fitting models, auto model selects one of them per key
+// The first model predicts keys having not enough data for the selection
+var models = []predictor.Model{
+	{Name: cnst.LinearExtrapolationPredictorModel, Curve: predictor.LinearExtrapolation, Interval: predictor.LinearExtrapolationInterval},
+	{Name: cnst.AveragePredictorModel, Curve: predictor.Average},
+	{Name: cnst.PowerLawPredictorModel, Curve: predictor.PowerLaw, Interval: predictor.PowerLawInterval},
+	{Name: cnst.LogarithmicPredictorModel, Curve: predictor.Logarithmic, Interval: predictor.LogarithmicInterval},
+}
+
 // NewRunner creates a new data predictor runner to perform predictions on aggregated data
-// According to model parameter
+// According to model parameter, predicts values for each of the days by one of the registered models
+// Auto model scores the registered models on the last holdout LTV days of each key by holdout metric
+// Predictions intervals are estimated at the confidence level, zero confidence level disables them
+// Keys are predicted by the fixed pool of poolSize workers
 func NewRunner(
+	ctx context.Context,
 	wg *sync.WaitGroup,
+	poolSize int,
 	model string,
+	days []uint,
+	holdout uint,
+	holdoutMetric string,
+	confidence float64,
 	aggregateCh t.AggregatorChannel,
-	predictCh t.PredictorChannel) (common.IRunner, error) {
+	predictCh t.PredictorChannel,
+	errorCh t.ErrorChannel) (common.IRunner, error) {
+
+	if confidence < 0 || confidence >= 1 {
+		return nil, cerror.NewConfigError(cnst.CliConfidenceParam, strconv.FormatFloat(confidence, 'g', -1, 64),
+			"invalid confidence level")
+	}
 
 	// General Factory logic, create data predictor according to model parameter
 	switch model {
-	case cnst.LinearExtrapolationPredictorModel:
-		return pr.NewPredictorRunner(wg, aggregateCh, predictCh, linext.NewPredictWorkerStrategy())
-	case cnst.AveragePredictorModel:
-		return pr.NewPredictorRunner(wg, aggregateCh, predictCh, average.NewPredictWorkerStrategy())
+	case cnst.AutoPredictorModel:
+		metric, err := newHoldoutMetric(holdoutMetric)
+		if err != nil {
+			return nil, err
+		}
+		if holdout == 0 {
+			return nil, cerror.NewConfigError(cnst.CliHoldoutParam, "0", "invalid holdout days number")
+		}
+		return pr.NewPredictorRunner(ctx, wg, poolSize, days, aggregateCh, predictCh, errorCh,
+			auto.NewPredictStrategy(models, holdout, metric, confidence))
+	default:
+		selected, err := Models([]string{model})
+		if err != nil {
+			return nil, err
+		}
+		return pr.NewPredictorRunner(ctx, wg, poolSize, days, aggregateCh, predictCh, errorCh, curve.NewPredictStrategy(selected[0], confidence))
+	}
+}
+
+// Models returns the registered models with the provided names, in the names order
+// Empty names mean all registered models, in the registration order
+func Models(names []string) ([]predictor.Model, error) {
+	if len(names) == 0 {
+		return slices.Clone(models), nil
+	}
+
+	selected := make([]predictor.Model, 0, len(names))
+	for _, name := range names {
+		index := slices.IndexFunc(models, func(model predictor.Model) bool { return model.Name == name })
+		if index < 0 {
+			return nil, cerror.NewConfigError(cnst.CliModelParam, name, fmt.Sprintf("%q invalid model parameter", name))
+		}
+		selected = append(selected, models[index])
+	}
+	return selected, nil
+}
+
+// newHoldoutMetric returns auto model candidates scoring metric according to holdout metric parameter
+func newHoldoutMetric(holdoutMetric string) (predictor.Metric, error) {
+	switch holdoutMetric {
+	case cnst.HoldoutMetricMae:
+		return predictor.MeanAbsoluteError, nil
+	case cnst.HoldoutMetricMape:
+		return predictor.MeanAbsolutePercentageError, nil
 	default:
-		return nil, cerror.NewCustomError(fmt.Sprintf("%q invalid model parameter", model))
+		return nil, cerror.NewConfigError(cnst.CliHoldoutMetricParam, holdoutMetric,
+			fmt.Sprintf("%q invalid holdout metric parameter", holdoutMetric))
 	}
 }
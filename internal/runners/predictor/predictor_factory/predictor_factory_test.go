@@ -1,22 +1,28 @@
 package predictor_factory
 
 import (
+	"context"
 	"fmt"
 	cnst "playground/internal/constants"
 	"playground/internal/types"
 	"playground/internal/utils/cerror"
+	"reflect"
 	"sync"
 	"testing"
 )
 
 const (
-	InvalidModelParameter = "PredictSomethingUnpredictable"
+	InvalidModelParameter         = "PredictSomethingUnpredictable"
+	InvalidHoldoutMetricParameter = "ScoreSomethingUnscorable"
 )
 
 func TestNewRunner(t *testing.T) {
 	tests := []struct {
 		name          string
 		model         string
+		holdout       uint
+		holdoutMetric string
+		confidence    float64
 		expectedError bool
 		errorStr      string
 	}{
@@ -34,6 +40,47 @@ func TestNewRunner(t *testing.T) {
 			name:  "AverageParameter",
 			model: cnst.AveragePredictorModel,
 		},
+		{
+			name:  "PowerLawParameter",
+			model: cnst.PowerLawPredictorModel,
+		},
+		{
+			name:  "LogarithmicParameter",
+			model: cnst.LogarithmicPredictorModel,
+		},
+		{
+			name:          "AutoParameter",
+			model:         cnst.AutoPredictorModel,
+			holdout:       cnst.HoldoutDays,
+			holdoutMetric: cnst.HoldoutMetricMape,
+		},
+		{
+			name:          "AutoInvalidHoldoutMetric",
+			model:         cnst.AutoPredictorModel,
+			holdout:       cnst.HoldoutDays,
+			holdoutMetric: InvalidHoldoutMetricParameter,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid holdout metric parameter", InvalidHoldoutMetricParameter)).Error(),
+		},
+		{
+			name:          "AutoZeroHoldout",
+			model:         cnst.AutoPredictorModel,
+			holdoutMetric: cnst.HoldoutMetricMae,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError("invalid holdout days number").Error(),
+		},
+		{
+			name:       "ConfidenceParameter",
+			model:      cnst.LinearExtrapolationPredictorModel,
+			confidence: 0.95,
+		},
+		{
+			name:          "InvalidConfidenceParameter",
+			model:         cnst.LinearExtrapolationPredictorModel,
+			confidence:    1,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError("invalid confidence level").Error(),
+		},
 	}
 
 	for _, testCase := range tests {
@@ -43,9 +90,10 @@ func TestNewRunner(t *testing.T) {
 			wg := &sync.WaitGroup{}
 			aggregateCh := types.NewAggregatorChannel(0)
 			predictCh := types.NewPredictorChannel(0)
+			errorCh := types.NewErrorChannel(0)
 
 			/* ACT */
-			_, err := NewRunner(wg, testCase.model, aggregateCh, predictCh)
+			_, err := NewRunner(context.Background(), wg, 1, testCase.model, []uint{cnst.PredictForNDay}, testCase.holdout, testCase.holdoutMetric, testCase.confidence, aggregateCh, predictCh, errorCh)
 
 			/* ASSERT */
 			// Assert expected error string
@@ -60,3 +108,53 @@ func TestNewRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestModels(t *testing.T) {
+	tests := []struct {
+		name          string
+		names         []string
+		expectedNames []string
+		expectedError bool
+		errorStr      string
+	}{
+		{
+			name:  "AllModels",
+			names: nil,
+			expectedNames: []string{cnst.LinearExtrapolationPredictorModel, cnst.AveragePredictorModel,
+				cnst.PowerLawPredictorModel, cnst.LogarithmicPredictorModel},
+		},
+		{
+			name:          "SelectedModelsOrder",
+			names:         []string{cnst.LogarithmicPredictorModel, cnst.LinearExtrapolationPredictorModel},
+			expectedNames: []string{cnst.LogarithmicPredictorModel, cnst.LinearExtrapolationPredictorModel},
+		},
+		{
+			name:          "AutoIsNotCurveModel",
+			names:         []string{cnst.AutoPredictorModel},
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid model parameter", cnst.AutoPredictorModel)).Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			result, err := Models(testCase.names)
+
+			/* ASSERT */
+			if (err != nil) != testCase.expectedError {
+				t.Fatalf("Models() : expected error %v, got %v", testCase.expectedError, err)
+			}
+			if err != nil && err.Error() != testCase.errorStr {
+				t.Fatalf("Models() : expected error string [%s], got [%s]", testCase.errorStr, err.Error())
+			}
+			names := make([]string, 0, len(result))
+			for _, model := range result {
+				names = append(names, model.Name)
+			}
+			if len(names) != len(testCase.expectedNames) || (len(names) != 0 && !reflect.DeepEqual(names, testCase.expectedNames)) {
+				t.Fatalf("Models() exp: %v\ngot: %v", testCase.expectedNames, names)
+			}
+		})
+	}
+}
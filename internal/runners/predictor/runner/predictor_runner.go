@@ -1,49 +1,86 @@
 package runner
 
 import (
+	"context"
 	log "github.com/sirupsen/logrus"
+	cnst "playground/internal/constants"
 	t "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/progress"
+	"playground/internal/utils/shard"
+	"strconv"
 	"sync"
 )
 
-// inputWorkerChanMap map to store input aggregated data channels for
-// Each unique predictor worker
-type inputWorkerChanMap map[string]t.AggregatorChannel
+// keyAccumulator represents key accumulator and the number of aggregated records added to it
+type keyAccumulator struct {
+	accumulator t.PredictAccumulator
+	samples     int
+}
+
+// keyAccumulatorMap map to store accumulators of the keys owned by a pool worker
+type keyAccumulatorMap map[string]*keyAccumulator
 
 // predictorRunner represents a data predictor backed by a prediction strategy
 type predictorRunner struct {
+	ctx          context.Context
 	wg           *sync.WaitGroup
+	poolSize     int
+	days         []uint
 	aggregatorCh t.AggregatorChannel
 	predictorCh  t.PredictorChannel
-	prStrategy   t.PredictWorkerStrategy
+	errorCh      t.ErrorChannel
+	prStrategy   t.PredictStrategy
 }
 
 // NewPredictorRunner initializes and returns predictorRunner
-// Returns error if some of wg, aggregatorCh, predictorCh, prStrategy is nil
+// Keys without finite prediction are reported to errorCh and skipped, errorCh isn't closed by the runner
+// Cancelled ctx stops reading, the predictions of the data received so far are sent before predictorCh is closed
+// Keys are sharded across the fixed pool of poolSize workers, each one owns accumulators of its keys
+// Returns error if some of ctx, wg, aggregatorCh, predictorCh, errorCh, prStrategy is nil, days are empty or pool size is less than 1
 func NewPredictorRunner(
+	ctx context.Context,
 	wg *sync.WaitGroup,
+	poolSize int,
+	days []uint,
 	aggregatorCh t.AggregatorChannel,
 	predictorCh t.PredictorChannel,
-	prStrategy t.PredictWorkerStrategy) (*predictorRunner, error) {
+	errorCh t.ErrorChannel,
+	prStrategy t.PredictStrategy) (*predictorRunner, error) {
 
+	if ctx == nil {
+		return nil, cerror.NewConfigError("context", "", "invalid context")
+	}
 	if wg == nil {
-		return nil, cerror.NewCustomError("invalid wait group")
+		return nil, cerror.NewConfigError("wait group", "", "invalid wait group")
+	}
+	if poolSize < 1 {
+		return nil, cerror.NewConfigError("pool size", strconv.Itoa(poolSize), "invalid pool size")
+	}
+	if len(days) == 0 {
+		return nil, cerror.NewConfigError("prediction days", "", "invalid prediction days")
 	}
 	if aggregatorCh == nil {
-		return nil, cerror.NewCustomError("invalid aggregator channel")
+		return nil, cerror.NewConfigError("aggregator channel", "", "invalid aggregator channel")
 	}
 	if predictorCh == nil {
-		return nil, cerror.NewCustomError("invalid predictor channel")
+		return nil, cerror.NewConfigError("predictor channel", "", "invalid predictor channel")
+	}
+	if errorCh == nil {
+		return nil, cerror.NewConfigError("error channel", "", "invalid error channel")
 	}
 	if prStrategy == nil {
-		return nil, cerror.NewCustomError("invalid predictor strategy worker")
+		return nil, cerror.NewConfigError("predictor strategy", "", "invalid predictor strategy")
 	}
 
 	return &predictorRunner{
+		ctx:          ctx,
 		wg:           wg,
+		poolSize:     poolSize,
+		days:         days,
 		aggregatorCh: aggregatorCh,
 		predictorCh:  predictorCh,
+		errorCh:      errorCh,
 		prStrategy:   prStrategy,
 	}, nil
 }
@@ -53,52 +90,85 @@ func (r *predictorRunner) Run() {
 	defer close(r.predictorCh)
 	defer r.wg.Done()
 
-	// Prepare worker sync and communication attributes
-	workerOutCh := t.NewPredictorChannel(5)
-	workerInChannelMap := make(inputWorkerChanMap)
-	workerWg := &sync.WaitGroup{}
+	// Received aggregated records are reported to the pipeline progress tracker, if any
+	stage := progress.FromContext(r.ctx).Stage(cnst.PredictorStage)
+	defer stage.Finish()
 
-	// Read aggregated data until aggregate channel is open
-	for aggData := range r.aggregatorCh {
-		// Received cancel event
-		if aggData == nil {
+	// Spin up the pool, each worker owns accumulators of a key shard
+	shards := make([]t.AggregatorChannel, r.poolSize)
+	poolWg := &sync.WaitGroup{}
+	for i := range shards {
+		shards[i] = t.NewAggregatorChannel(cnst.PredictorShardChannelBuffer)
+		poolWg.Add(1)
+		go r.runShard(poolWg, shards[i])
+	}
+
+	// Read aggregated data until aggregate channel is open or cancel event received
+read:
+	for {
+		select {
+		case <-r.ctx.Done():
 			log.Warning("predict runner shutdown")
-			r.predictorCh <- nil
+			break read
 
-			// Shutdown all running workers
-			for _, channel := range workerInChannelMap {
-				channel <- nil
-				close(channel)
+		case aggData, ok := <-r.aggregatorCh:
+			if !ok {
+				break read
 			}
+			stage.Inc()
 
-			// Wait until workers stop running
-			workerWg.Wait()
-			log.Warning("predict workers released")
-			return
-		}
-
-		// Spinup new worker in case of unique aggregated data received
-		if _, found := workerInChannelMap[aggData.Key()]; !found {
-			workerInCh := t.NewAggregatorChannel(2)
-			workerWg.Add(1)
-			go r.prStrategy(workerWg, aggData.Key(), workerInCh, workerOutCh)
-			workerInChannelMap[aggData.Key()] = workerInCh
+			// Send aggregated data to key shard worker, cancel event interrupts waiting for it
+			select {
+			case shards[shard.Index(aggData.Key(), len(shards))] <- aggData:
+			case <-r.ctx.Done():
+				log.Warning("predict runner shutdown")
+				break read
+			}
 		}
+	}
 
-		// Send aggregated data to key related worker
-		workerInChannelMap[aggData.Key()] <- aggData
+	// Aggregated data channel closed or cancel event received
+	// Release pool workers, they predict on the data received so far
+	for _, shardCh := range shards {
+		close(shardCh)
 	}
+	poolWg.Wait()
+	log.Debug("predict runner finished work")
+}
 
-	// Aggregated data channel closed
-	// Get workers result and close all workers channels
-	for _, workerInputChannel := range workerInChannelMap {
-		// Release goroutines
-		close(workerInputChannel)
-		tmp := <-workerOutCh
-		r.predictorCh <- tmp
+// runShard accumulates aggregated data of a key shard until shard channel is open
+// Then predicts and sends the predictions of all shard keys
+func (r *predictorRunner) runShard(wg *sync.WaitGroup, shardCh t.AggregatorChannel) {
+	defer wg.Done()
+
+	accumulators := make(keyAccumulatorMap)
+	for aggData := range shardCh {
+		key, found := accumulators[aggData.Key()]
+		if !found {
+			key = &keyAccumulator{accumulator: r.prStrategy()}
+			accumulators[aggData.Key()] = key
+		}
+		key.accumulator.Add(aggData)
+		key.samples++
 	}
 
-	// Wait until workers stop running
-	workerWg.Wait()
-	log.Debug("predict runner finished work")
+	// Predictions are sent in random order, they are ordered by the postprocessor
+	for _, key := range accumulators {
+		predicted := key.accumulator.Predict(r.days)
+		predicted.SetSamples(key.samples)
+
+		// Not enough data to predict, e.g. single non-zero LTV day for linear extrapolation
+		// Keys of cancelled run are expected to lack data, they are skipped silently
+		if !predicted.Finite() {
+			if r.ctx.Err() == nil {
+				r.errorCh <- &cerror.StageError{
+					Stage:  cnst.PredictorStage,
+					Source: predicted.Key(),
+					Reason: "not enough ltv data to predict",
+				}
+			}
+			continue
+		}
+		r.predictorCh <- predicted
+	}
 }
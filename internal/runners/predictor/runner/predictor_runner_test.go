@@ -1,20 +1,34 @@
 package runner
 
 import (
-	"playground/internal/runners/predictor/strategy/linext"
+	c "context"
+	"fmt"
+	"math"
+	cnst "playground/internal/constants"
+	"playground/internal/runners/predictor/strategy/curve"
 	tp "playground/internal/types"
 	"playground/internal/utils/cerror"
+	"playground/internal/utils/predictor"
 	"reflect"
 	s "sync"
 	"testing"
 	"time"
 )
 
+var days = []uint{60}
+
+var linearExtrapolationModel = predictor.Model{Name: cnst.LinearExtrapolationPredictorModel, Curve: predictor.LinearExtrapolation}
+
+const poolSize = 2
+
 type inputParameters struct {
-	wg  *s.WaitGroup
-	aCh tp.AggregatorChannel
-	pCh tp.PredictorChannel
-	pSt tp.PredictWorkerStrategy
+	ctx  c.Context
+	wg   *s.WaitGroup
+	days []uint
+	aCh  tp.AggregatorChannel
+	pCh  tp.PredictorChannel
+	eCh  tp.ErrorChannel
+	pSt  tp.PredictStrategy
 }
 
 type newPredictorResult struct {
@@ -29,28 +43,46 @@ func TestNewPredictorRunner_InvalidInputParams(t *testing.T) {
 		expectedResult newPredictorResult
 		expectedError  bool
 	}{
+		{
+			name:           "noContext",
+			input:          inputParameters{nil, &s.WaitGroup{}, nil, nil, nil, nil, nil},
+			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid context")},
+			expectedError:  true,
+		},
 		{
 			name:           "noWaitGroup",
-			input:          inputParameters{nil, nil, nil, nil},
+			input:          inputParameters{c.Background(), nil, nil, nil, nil, nil, nil},
 			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid wait group")},
 			expectedError:  true,
 		},
+		{
+			name:           "noPredictionDays",
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, nil, nil, nil, nil, nil},
+			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid prediction days")},
+			expectedError:  true,
+		},
 		{
 			name:           "noAggregateChannel",
-			input:          inputParameters{&s.WaitGroup{}, nil, nil, nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, days, nil, nil, nil, nil},
 			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid aggregator channel")},
 			expectedError:  true,
 		},
 		{
 			name:           "noPredictChannel",
-			input:          inputParameters{&s.WaitGroup{}, tp.NewAggregatorChannel(0), nil, nil},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, days, tp.NewAggregatorChannel(0), nil, nil, nil},
 			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid predictor channel")},
 			expectedError:  true,
 		},
+		{
+			name:           "noErrorChannel",
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, days, tp.NewAggregatorChannel(0), tp.NewPredictorChannel(0), nil, nil},
+			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid error channel")},
+			expectedError:  true,
+		},
 		{
 			name:           "noPredictStrategy",
-			input:          inputParameters{&s.WaitGroup{}, tp.NewAggregatorChannel(0), tp.NewPredictorChannel(0), nil},
-			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid predictor strategy worker")},
+			input:          inputParameters{c.Background(), &s.WaitGroup{}, days, tp.NewAggregatorChannel(0), tp.NewPredictorChannel(0), tp.NewErrorChannel(0), nil},
+			expectedResult: newPredictorResult{predictor: nil, err: cerror.NewCustomError("invalid predictor strategy")},
 			expectedError:  true,
 		},
 	}
@@ -59,7 +91,7 @@ func TestNewPredictorRunner_InvalidInputParams(t *testing.T) {
 			/* ARRANGE */
 
 			/* ACT */
-			result, err := NewPredictorRunner(testCase.input.wg, testCase.input.aCh, testCase.input.pCh, testCase.input.pSt)
+			result, err := NewPredictorRunner(testCase.input.ctx, testCase.input.wg, poolSize, testCase.input.days, testCase.input.aCh, testCase.input.pCh, testCase.input.eCh, testCase.input.pSt)
 
 			/* ASSERT */
 			// Assert expected error
@@ -84,17 +116,20 @@ func TestNewPredictorRunner_InvalidInputParams(t *testing.T) {
 	}
 }
 
-func TestNewPredictorRunner_ValidInputParamsLinextWorkerStrategy(t *testing.T) {
+func TestNewPredictorRunner_ValidInputParamsLinextStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
+		days,
 		tp.NewAggregatorChannel(0),
 		tp.NewPredictorChannel(0),
-		linext.NewPredictWorkerStrategy(),
+		tp.NewErrorChannel(0),
+		curve.NewPredictStrategy(linearExtrapolationModel, 0),
 	}
 
 	/* ACT */
-	result, err := NewPredictorRunner(in.wg, in.aCh, in.pCh, in.pSt)
+	result, err := NewPredictorRunner(in.ctx, in.wg, poolSize, in.days, in.aCh, in.pCh, in.eCh, in.pSt)
 	// Assert unexpected error
 	if err != nil {
 		t.Fatalf("NewPredictor() : expected error string [%v], got [%v]", nil, err)
@@ -106,13 +141,16 @@ func TestNewPredictorRunner_ValidInputParamsLinextWorkerStrategy(t *testing.T) {
 	}
 }
 
-func TestNewPredictorRunner_RunWithLinextWorkerStrategy(t *testing.T) {
+func TestNewPredictorRunner_RunWithLinextStrategy(t *testing.T) {
 	/* ARRANGE */
 	in := inputParameters{
+		c.Background(),
 		&s.WaitGroup{},
+		days,
 		tp.NewAggregatorChannel(0),
 		tp.NewPredictorChannel(0),
-		linext.NewPredictWorkerStrategy(),
+		tp.NewErrorChannel(0),
+		curve.NewPredictStrategy(linearExtrapolationModel, 0),
 	}
 	// Prepare aggregated data
 	aggregated := []*tp.AggregatedData{
@@ -125,7 +163,7 @@ func TestNewPredictorRunner_RunWithLinextWorkerStrategy(t *testing.T) {
 	}
 
 	in.wg.Add(1)
-	predictor, _ := NewPredictorRunner(in.wg, in.aCh, in.pCh, in.pSt)
+	predictor, _ := NewPredictorRunner(in.ctx, in.wg, poolSize, in.days, in.aCh, in.pCh, in.eCh, in.pSt)
 
 	/* ACT */
 	// Mock aggregated streamer
@@ -168,30 +206,36 @@ func TestNewPredictorRunner_RunWithLinextWorkerStrategy(t *testing.T) {
 	}
 }
 
-func TestNewPredictorRunner_RunWithLinextWorkerStrategyAndCancelEvent(t *testing.T) {
+func TestNewPredictorRunner_RunWithLinextStrategyAndCancelEvent(t *testing.T) {
 	/* ARRANGE */
+	ctx, cancel := c.WithCancel(c.Background())
 	in := inputParameters{
+		ctx,
 		&s.WaitGroup{},
+		days,
 		tp.NewAggregatorChannel(0),
 		tp.NewPredictorChannel(0),
-		linext.NewPredictWorkerStrategy(),
+		tp.NewErrorChannel(0),
+		curve.NewPredictStrategy(linearExtrapolationModel, 0),
 	}
-	// Prepare aggregated data and cancel event
+	// Prepare aggregated data, FR has not enough data and isn't predicted
 	aggregated := []*tp.AggregatedData{
 		tp.NewAggregatedData("JP", tp.LtvCollection{2, 4, 6, 8, 10, 0, 0}),
 		tp.NewAggregatedData("US", tp.LtvCollection{3, 6, 9, 0, 0, 0, 0}),
-		nil,
+		tp.NewAggregatedData("FR", tp.LtvCollection{5, 0, 0, 0, 0, 0, 0}),
 	}
-	predictor, _ := NewPredictorRunner(in.wg, in.aCh, in.pCh, in.pSt)
+	// Workers predict on the data they received before cancel event, some of them might receive nothing
+	expectedPredictedData := map[string]float64{"JP": 120, "US": 180}
+	predictor, _ := NewPredictorRunner(in.ctx, in.wg, poolSize, in.days, in.aCh, in.pCh, in.eCh, in.pSt)
 	in.wg.Add(1)
 
 	/* ACT */
-	// Mock aggregated streamer
+	// Mock aggregated streamer, aggregate channel stays open
 	go func() {
-		defer close(in.aCh)
 		for _, aggData := range aggregated {
 			in.aCh <- aggData
 		}
+		cancel()
 	}()
 	go predictor.Run()
 
@@ -200,19 +244,162 @@ func TestNewPredictorRunner_RunWithLinextWorkerStrategyAndCancelEvent(t *testing
 		select {
 		// Assert expected predicted data
 		case result, ok := <-in.pCh:
-			if ok {
-				// Assert cansel event
-				if result != nil {
-					t.Fatalf("Run() exp: %+v\ngot: %+v", nil, result)
-				}
+			// Assert predictor channel is closed on cancel event
+			if !ok {
+				in.wg.Wait()
 				return
-
-			} else {
-				t.Fatalf("Run() : shouldn't be here")
 			}
+			if expected, found := expectedPredictedData[result.Key()]; !found || expected != result.Predicted() {
+				t.Fatalf("Run() unexpected prediction %s: %v", result.Key(), result.Predicted())
+			}
+			delete(expectedPredictedData, result.Key())
+
+			// Keys without prediction aren't reported on cancel event
+		case err := <-in.eCh:
+			t.Fatalf("Run() unexpected error: %v", err)
+
 			// Assert potential hang situation
 		case <-time.After(1 * time.Second):
 			t.Fatalf("Run() : timeout")
 		}
 	}
 }
+
+func TestNewPredictorRunner_RunWithNotEnoughLtvData(t *testing.T) {
+	averageModel := predictor.Model{Name: cnst.AveragePredictorModel, Curve: predictor.Average}
+	tests := []struct {
+		name  string
+		model predictor.Model
+		ltv   tp.LtvCollection
+	}{
+		// Linear extrapolation needs at least two non-zero LTV days
+		{name: "LinextSingleLtvDay", model: linearExtrapolationModel, ltv: tp.LtvCollection{5, 0, 0, 0, 0, 0, 0}},
+		// No model could be fitted without non-zero LTV days
+		{name: "AverageNoLtvValues", model: averageModel, ltv: tp.LtvCollection{0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			in := inputParameters{
+				c.Background(),
+				&s.WaitGroup{},
+				days,
+				tp.NewAggregatorChannel(0),
+				tp.NewPredictorChannel(0),
+				tp.NewErrorChannel(0),
+				curve.NewPredictStrategy(testCase.model, 0),
+			}
+			// Prepare aggregated data, DE LTV values lie on the line and grow evenly, so both models predict 60
+			aggregated := []*tp.AggregatedData{
+				tp.NewAggregatedData("FR", testCase.ltv),
+				tp.NewAggregatedData("DE", tp.LtvCollection{1, 2, 3, 4, 5, 6, 7}),
+			}
+			expectedPredictedData := map[string]float64{"DE": testCase.model.Curve([]float64{1, 2, 3, 4, 5, 6, 7}, 60)}
+			errorStr := (&cerror.StageError{Stage: cnst.PredictorStage, Source: "FR", Reason: "not enough ltv data to predict"}).Error()
+
+			in.wg.Add(1)
+			predictor, _ := NewPredictorRunner(in.ctx, in.wg, poolSize, in.days, in.aCh, in.pCh, in.eCh, in.pSt)
+
+			/* ACT */
+			// Mock aggregated streamer
+			go func() {
+				defer close(in.aCh)
+				for _, aggData := range aggregated {
+					in.aCh <- aggData
+				}
+			}()
+			go predictor.Run()
+
+			/* ASSERT */
+			reported := false
+			for {
+				select {
+				// Assert expected predicted data
+				case result, ok := <-in.pCh:
+					if ok {
+						value, found := expectedPredictedData[result.Key()]
+						if !found || value != result.Predicted() {
+							t.Fatalf("Run() unexpected predicted data : %+v", result)
+						}
+						delete(expectedPredictedData, result.Key())
+					} else {
+						if !reported || len(expectedPredictedData) != 0 {
+							t.Fatalf("Run() expected error reported and all data sent, reported: %v, left: %+v", reported, expectedPredictedData)
+						}
+						return
+					}
+					// Assert expected error data
+				case err := <-in.eCh:
+					if err.Error() != errorStr {
+						t.Fatalf("Run() : expected error string [%s], got [%s]", errorStr, err.Error())
+					}
+					reported = true
+					// Assert potential hang situation
+				case <-time.After(1 * time.Second):
+					t.Fatalf("Run() : timeout")
+				}
+			}
+		})
+	}
+}
+
+func TestNewPredictorRunner_InvalidPoolSize(t *testing.T) {
+	/* ARRANGE */
+	errorStr := cerror.NewCustomError("invalid pool size").Error()
+
+	/* ACT */
+	result, err := NewPredictorRunner(c.Background(), &s.WaitGroup{}, 0, days, tp.NewAggregatorChannel(0), tp.NewPredictorChannel(0),
+		tp.NewErrorChannel(0), curve.NewPredictStrategy(linearExtrapolationModel, 0))
+
+	/* ASSERT */
+	if result != nil || err == nil || err.Error() != errorStr {
+		t.Fatalf("NewPredictor() : expected error string [%s], got %v [%v]", errorStr, result, err)
+	}
+}
+
+func TestNewPredictorRunner_RunManyKeys(t *testing.T) {
+	/* ARRANGE */
+	in := inputParameters{
+		c.Background(),
+		&s.WaitGroup{},
+		days,
+		tp.NewAggregatorChannel(0),
+		tp.NewPredictorChannel(0),
+		tp.NewErrorChannel(0),
+		curve.NewPredictStrategy(linearExtrapolationModel, 0),
+	}
+	// Keys outnumber the pool workers, each key has a few aggregated records
+	expectedPredictedData := map[string]float64{}
+	aggregated := []*tp.AggregatedData{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("campaign-%d", i%100)
+		aggregated = append(aggregated, tp.NewAggregatedData(key, tp.LtvCollection{1, 2, 3, 4, 5, 6, 7}))
+		expectedPredictedData[key] = 60
+	}
+
+	in.wg.Add(1)
+	predictor, _ := NewPredictorRunner(in.ctx, in.wg, poolSize, in.days, in.aCh, in.pCh, in.eCh, in.pSt)
+
+	/* ACT */
+	go func() {
+		defer close(in.aCh)
+		for _, aggData := range aggregated {
+			in.aCh <- aggData
+		}
+	}()
+	go predictor.Run()
+
+	/* ASSERT */
+	// Every key is predicted once, from the 10 aggregated records of the key
+	for result := range in.pCh {
+		value, found := expectedPredictedData[result.Key()]
+		if !found || math.Abs(value-result.Predicted()) > 1e-9 || result.Samples() != 10 {
+			t.Fatalf("Run() unexpected predicted data : %+v", result)
+		}
+		delete(expectedPredictedData, result.Key())
+	}
+	if len(expectedPredictedData) != 0 {
+		t.Fatalf("Run() expected all keys predicted, left: %+v", expectedPredictedData)
+	}
+}
@@ -0,0 +1,75 @@
+package auto
+
+import (
+	"math"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/predictor"
+)
+
+// autoAccumulator accumulates key related aggregated data and predicts it by the best scored model
+// Records covariance is accumulated for the prediction intervals, unless confidence level is zero
+type autoAccumulator struct {
+	dimensions []string
+	ltv        predictor.LtvAverages
+	covariance predictor.LtvCovariance
+	models     []predictor.Model
+	holdout    int
+	metric     predictor.Metric
+	confidence float64
+}
+
+// Add collects daily non zero LTV values weighted by the aggregated data weight
+func (a *autoAccumulator) Add(aggData *t.AggregatedData) {
+	a.dimensions = aggData.Dimensions()
+	a.ltv.AddWeighted(aggData.Ltv(), aggData.Weight())
+	if a.confidence != 0 {
+		a.covariance.AddWeighted(aggData.Ltv(), aggData.Weight())
+	}
+}
+
+// Predict selects the model for the daily LTV averages and predicts each requested day by it
+// Chosen model name is set on the predicted data
+// Key without non zero LTV values has NaN predictions, so the predictor runner reports and skips it
+func (a *autoAccumulator) Predict(days []uint) *t.PredictedData {
+	averages := a.ltv.Averages()
+	model := a.selectModel(averages)
+	predictions := make([]t.Prediction, 0, len(days))
+	for _, day := range days {
+		predictions = append(predictions, t.Prediction{Day: day, Value: model.Predict(averages, float64(day))})
+	}
+	predicted := t.NewCompositePredictedData(a.dimensions, predictions)
+	predicted.SetModel(model.Name)
+	if a.confidence != 0 {
+		lower, upper := predictor.Intervals(averages, a.covariance.Covariance(&a.ltv), days, a.confidence, model.Curve, model.Interval)
+		predicted.SetIntervals(a.confidence, lower, upper)
+	}
+	return predicted
+}
+
+// selectModel returns the model with the lowest holdout score, the first registered one wins ties
+// The first model is returned if there are not enough days to fit the models or no model has a finite score
+func (a *autoAccumulator) selectModel(averages []float64) predictor.Model {
+	best := a.models[0]
+	if len(averages)-a.holdout < cnst.HoldoutMinFitDays {
+		return best
+	}
+
+	bestScore := math.Inf(1)
+	for _, model := range a.models {
+		if score := predictor.Holdout(averages, a.holdout, model.Curve, a.metric); score < bestScore {
+			best, bestScore = model, score
+		}
+	}
+	return best
+}
+
+// NewPredictStrategy returns auto prediction strategy, each key is predicted by one of the models
+// Models are fitted on the key LTV days without the last holdout ones and scored by metric on them
+// Predictions intervals of the chosen model are estimated at the confidence level, zero confidence level disables them
+// IMPORTANT: Expected len(models) != 0 and holdout > 0
+func NewPredictStrategy(models []predictor.Model, holdout uint, metric predictor.Metric, confidence float64) t.PredictStrategy {
+	return func() t.PredictAccumulator {
+		return &autoAccumulator{models: models, holdout: int(holdout), metric: metric, confidence: confidence}
+	}
+}
@@ -0,0 +1,123 @@
+package auto
+
+import (
+	"math"
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/predictor"
+	"testing"
+)
+
+const Accuracy = 1e-9
+
+var models = []predictor.Model{
+	{Name: cnst.LinearExtrapolationPredictorModel, Curve: predictor.LinearExtrapolation, Interval: predictor.LinearExtrapolationInterval},
+	{Name: cnst.AveragePredictorModel, Curve: predictor.Average},
+	{Name: cnst.PowerLawPredictorModel, Curve: predictor.PowerLaw, Interval: predictor.PowerLawInterval},
+	{Name: cnst.LogarithmicPredictorModel, Curve: predictor.Logarithmic, Interval: predictor.LogarithmicInterval},
+}
+
+// curve returns the curve values of the first days
+func curve(days int, f func(x float64) float64) tp.LtvCollection {
+	ltv := make(tp.LtvCollection, 0, days)
+	for x := 1; x <= days; x++ {
+		ltv = append(ltv, f(float64(x)))
+	}
+	return ltv
+}
+
+func TestAutoAccumulator_Predict(t *testing.T) {
+	tests := []struct {
+		name          string
+		ltv           tp.LtvCollection
+		holdout       uint
+		metric        predictor.Metric
+		expectedModel string
+		expectedValue float64
+	}{
+		{
+			name:          "LinearCurve",
+			ltv:           curve(7, func(x float64) float64 { return x + 5 }),
+			holdout:       2,
+			metric:        predictor.MeanAbsoluteError,
+			expectedModel: cnst.LinearExtrapolationPredictorModel,
+			expectedValue: 65,
+		},
+		{
+			name:          "PowerLawCurve",
+			ltv:           curve(7, func(x float64) float64 { return 2 * math.Pow(x, 0.4) }),
+			holdout:       3,
+			metric:        predictor.MeanAbsoluteError,
+			expectedModel: cnst.PowerLawPredictorModel,
+			expectedValue: 2 * math.Pow(60, 0.4),
+		},
+		{
+			name:          "LogarithmicCurveScoredByMape",
+			ltv:           curve(7, func(x float64) float64 { return 3 + 2*math.Log(x) }),
+			holdout:       2,
+			metric:        predictor.MeanAbsolutePercentageError,
+			expectedModel: cnst.LogarithmicPredictorModel,
+			expectedValue: 3 + 2*math.Log(60),
+		},
+		{
+			name:          "NotEnoughDaysFirstModel",
+			ltv:           tp.LtvCollection{1, 2, 3},
+			holdout:       2,
+			metric:        predictor.MeanAbsoluteError,
+			expectedModel: cnst.LinearExtrapolationPredictorModel,
+			expectedValue: 60,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			accumulator := NewPredictStrategy(models, testCase.holdout, testCase.metric, 0)()
+
+			/* ACT */
+			accumulator.Add(tp.NewAggregatedData("US", testCase.ltv))
+			result := accumulator.Predict([]uint{60})
+
+			/* ASSERT */
+			if result.Key() != "US" || result.Model() != testCase.expectedModel {
+				t.Fatalf("Predict() exp model: %s\ngot: %+v", testCase.expectedModel, result)
+			}
+			if math.Abs(result.Predicted()-testCase.expectedValue) > Accuracy {
+				t.Fatalf("Predict() exp: %v\ngot: %v", testCase.expectedValue, result.Predicted())
+			}
+		})
+	}
+}
+
+func TestAutoAccumulator_PredictNoLtvValues(t *testing.T) {
+	/* ARRANGE */
+	accumulator := NewPredictStrategy(models, 2, predictor.MeanAbsoluteError, 0.9)()
+
+	/* ACT */
+	accumulator.Add(tp.NewAggregatedData("US", tp.LtvCollection{0, 0, 0, 0, 0, 0, 0}))
+	result := accumulator.Predict([]uint{60})
+
+	/* ASSERT */
+	if result.Model() != cnst.LinearExtrapolationPredictorModel || result.Finite() {
+		t.Fatalf("Predict() expected not finite first model prediction, got: %+v", result)
+	}
+}
+
+func TestAutoAccumulator_PredictWithConfidence(t *testing.T) {
+	/* ARRANGE */
+	accumulator := NewPredictStrategy(models, 2, predictor.MeanAbsoluteError, 0.9)()
+
+	/* ACT */
+	accumulator.Add(tp.NewAggregatedData("US", curve(7, func(x float64) float64 { return x + 5 })))
+	accumulator.Add(tp.NewAggregatedData("US", curve(7, func(x float64) float64 { return x + 5 })))
+	result := accumulator.Predict([]uint{60})
+
+	/* ASSERT */
+	// Chosen linear model fits the same records line exactly, so its analytic interval is the predicted value itself
+	prediction := result.Predictions()[0]
+	if result.Model() != cnst.LinearExtrapolationPredictorModel || result.Confidence() != 0.9 ||
+		!(math.Abs(prediction.Lower-65) <= Accuracy) || !(math.Abs(prediction.Upper-65) <= Accuracy) {
+		t.Fatalf("Predict() exp: %s 65 [65, 65] at 0.9\ngot: %s %+v at %v", cnst.LinearExtrapolationPredictorModel,
+			result.Model(), prediction, result.Confidence())
+	}
+}
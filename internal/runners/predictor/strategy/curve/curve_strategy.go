@@ -0,0 +1,49 @@
+package curve
+
+import (
+	t "playground/internal/types"
+	"playground/internal/utils/predictor"
+)
+
+// curveAccumulator accumulates key related aggregated data for prediction by the model curve
+// Records covariance is accumulated for the prediction intervals, unless confidence level is zero
+type curveAccumulator struct {
+	dimensions []string
+	ltv        predictor.LtvAverages
+	covariance predictor.LtvCovariance
+	model      predictor.Model
+	confidence float64
+}
+
+// Add collects daily non zero LTV values weighted by the aggregated data weight
+func (a *curveAccumulator) Add(aggData *t.AggregatedData) {
+	a.dimensions = aggData.Dimensions()
+	a.ltv.AddWeighted(aggData.Ltv(), aggData.Weight())
+	if a.confidence != 0 {
+		a.covariance.AddWeighted(aggData.Ltv(), aggData.Weight())
+	}
+}
+
+// Predict fits the model curve to the daily LTV averages and predicts each requested day
+// Key without non zero LTV values has NaN predictions, so the predictor runner reports and skips it
+func (a *curveAccumulator) Predict(days []uint) *t.PredictedData {
+	averages := a.ltv.Averages()
+	predictions := make([]t.Prediction, 0, len(days))
+	for _, day := range days {
+		predictions = append(predictions, t.Prediction{Day: day, Value: a.model.Predict(averages, float64(day))})
+	}
+	predicted := t.NewCompositePredictedData(a.dimensions, predictions)
+	if a.confidence != 0 {
+		lower, upper := predictor.Intervals(averages, a.covariance.Covariance(&a.ltv), days, a.confidence, a.model.Curve, a.model.Interval)
+		predicted.SetIntervals(a.confidence, lower, upper)
+	}
+	return predicted
+}
+
+// NewPredictStrategy returns prediction strategy of the model, e.g. one of the registered curve fitting models
+// Predictions intervals are estimated at the confidence level, zero confidence level disables them
+func NewPredictStrategy(model predictor.Model, confidence float64) t.PredictStrategy {
+	return func() t.PredictAccumulator {
+		return &curveAccumulator{model: model, confidence: confidence}
+	}
+}
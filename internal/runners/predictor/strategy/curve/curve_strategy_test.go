@@ -0,0 +1,337 @@
+package curve
+
+import (
+	"math"
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/predictor"
+	"reflect"
+	"testing"
+)
+
+const Accuracy = 1e-9
+
+var (
+	linearExtrapolationModel = predictor.Model{Name: cnst.LinearExtrapolationPredictorModel, Curve: predictor.LinearExtrapolation, Interval: predictor.LinearExtrapolationInterval}
+	averageModel             = predictor.Model{Name: cnst.AveragePredictorModel, Curve: predictor.Average}
+	powerLawModel            = predictor.Model{Name: cnst.PowerLawPredictorModel, Curve: predictor.PowerLaw, Interval: predictor.PowerLawInterval}
+	logarithmicModel         = predictor.Model{Name: cnst.LogarithmicPredictorModel, Curve: predictor.Logarithmic, Interval: predictor.LogarithmicInterval}
+)
+
+// withWeight returns aggregated data with the weight set
+func withWeight(aggData *tp.AggregatedData, weight float64) *tp.AggregatedData {
+	aggData.SetWeight(weight)
+	return aggData
+}
+
+// powerLawCurve returns y = a * x^b values of the days, scaled by the provided factor
+func powerLawCurve(days []uint, a, b, scale float64) []float64 {
+	values := make([]float64, 0, len(days))
+	for _, x := range days {
+		values = append(values, scale*a*math.Pow(float64(x), b))
+	}
+	return values
+}
+
+// logarithmicCurve returns y = a + b * ln(x) values of the days, shifted by the provided value
+func logarithmicCurve(days []uint, a, b, shift float64) []float64 {
+	values := make([]float64, 0, len(days))
+	for _, x := range days {
+		values = append(values, a+b*math.Log(float64(x))+shift)
+	}
+	return values
+}
+
+// firstDays returns the first n days numbers
+func firstDays(n uint) []uint {
+	days := make([]uint, 0, n)
+	for x := uint(1); x <= n; x++ {
+		days = append(days, x)
+	}
+	return days
+}
+
+func TestCurveAccumulator_Predict(t *testing.T) {
+	compositeDimensions := []string{"US", "6325253f-ec73-4dd7-a9e2-8bf921119c16"}
+
+	tests := []struct {
+		name       string
+		model      predictor.Model
+		aggregated []*tp.AggregatedData
+		days       []uint
+		dimensions []string
+		expected   []float64
+	}{
+		{
+			name:  "LinextZeroValuesSkipped",
+			model: linearExtrapolationModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", tp.LtvCollection{7, 0, 0, 0, 0, 0, 0}),
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 8, 0, 0, 0, 0, 0}),
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 4, 9, 12, 15, 0, 0}),
+			},
+			days:     []uint{60},
+			expected: []float64{180},
+		},
+		{
+			name:  "LinextMultipleDays",
+			model: linearExtrapolationModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3, 4, 5, 6, 7}),
+			},
+			days:     []uint{30, 90, 180},
+			expected: []float64{30, 90, 180},
+		},
+		{
+			// Daily weighted averages are (3 * x + 5 * x) / 4 = 2 * x, unweighted ones would be 3 * x
+			name:  "LinextWeightedRecords",
+			model: linearExtrapolationModel,
+			aggregated: []*tp.AggregatedData{
+				withWeight(tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3}), 3),
+				tp.NewAggregatedData("US", tp.LtvCollection{5, 10, 15}),
+			},
+			days:     []uint{60},
+			expected: []float64{120},
+		},
+		{
+			name:  "LinextCompositeKey",
+			model: linearExtrapolationModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewCompositeAggregatedData(compositeDimensions, tp.LtvCollection{1, 2, 3, 4, 5, 6, 7}),
+			},
+			days:       []uint{60},
+			dimensions: compositeDimensions,
+			expected:   []float64{60},
+		},
+		{
+			name:  "AverageZeroValuesSkipped",
+			model: averageModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", tp.LtvCollection{7, 0, 0, 0, 0, 0, 0}),
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 8, 0, 0, 0, 0, 0}),
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 4, 9, 12, 15, 0, 0}),
+			},
+			days:     []uint{60},
+			expected: []float64{149.4},
+		},
+		{
+			name:  "AverageVariableLtvLen",
+			model: averageModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", tp.LtvCollection{2, 4}),
+				tp.NewAggregatedData("US", tp.LtvCollection{2, 4, 6, 8, 10, 12, 14, 16, 18, 20}),
+			},
+			days:     []uint{60},
+			expected: []float64{111.8},
+		},
+		{
+			name:  "PowerLawSingleRecord",
+			model: powerLawModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", powerLawCurve(firstDays(7), 2, 0.5, 1)),
+			},
+			days:     []uint{60},
+			expected: powerLawCurve([]uint{60}, 2, 0.5, 1),
+		},
+		{
+			name:  "PowerLawAveragedRecords",
+			model: powerLawModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", powerLawCurve(firstDays(7), 1.2, 0.35, 0.5)),
+				tp.NewAggregatedData("US", powerLawCurve(firstDays(7), 1.2, 0.35, 1.5)),
+			},
+			days:     []uint{30, 60, 180},
+			expected: powerLawCurve([]uint{30, 60, 180}, 1.2, 0.35, 1),
+		},
+		{
+			name:  "PowerLawZeroValuesSkipped",
+			model: powerLawModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", append(powerLawCurve(firstDays(3), 0.8, 0.6, 1), 0, 0, 0, 0)),
+				tp.NewAggregatedData("US", powerLawCurve(firstDays(7), 0.8, 0.6, 1)),
+			},
+			days:     []uint{90},
+			expected: powerLawCurve([]uint{90}, 0.8, 0.6, 1),
+		},
+		{
+			name:  "LogarithmicSingleRecord",
+			model: logarithmicModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", logarithmicCurve(firstDays(7), 3, 2, 0)),
+			},
+			days:     []uint{60},
+			expected: logarithmicCurve([]uint{60}, 3, 2, 0),
+		},
+		{
+			name:  "LogarithmicAveragedRecords",
+			model: logarithmicModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", logarithmicCurve(firstDays(7), 1.5, 0.75, -1)),
+				tp.NewAggregatedData("US", logarithmicCurve(firstDays(7), 1.5, 0.75, 1)),
+			},
+			days:     []uint{30, 60, 180},
+			expected: logarithmicCurve([]uint{30, 60, 180}, 1.5, 0.75, 0),
+		},
+		{
+			name:  "LogarithmicZeroValuesSkipped",
+			model: logarithmicModel,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", append(logarithmicCurve(firstDays(3), 5, 1.25, 0), 0, 0, 0, 0)),
+				tp.NewAggregatedData("US", logarithmicCurve(firstDays(7), 5, 1.25, 0)),
+			},
+			days:     []uint{90},
+			expected: logarithmicCurve([]uint{90}, 5, 1.25, 0),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			accumulator := NewPredictStrategy(testCase.model, 0)()
+			dimensions := testCase.dimensions
+			if dimensions == nil {
+				dimensions = []string{"US"}
+			}
+
+			/* ACT */
+			for _, aggData := range testCase.aggregated {
+				accumulator.Add(aggData)
+			}
+			result := accumulator.Predict(testCase.days)
+
+			/* ASSERT */
+			if !reflect.DeepEqual(result.Dimensions(), dimensions) || len(result.Predictions()) != len(testCase.days) {
+				t.Fatalf("Predict() unexpected prediction: %+v", result)
+			}
+			for i, prediction := range result.Predictions() {
+				if prediction.Day != testCase.days[i] || math.Abs(prediction.Value-testCase.expected[i]) > Accuracy {
+					t.Fatalf("Predict() day %d exp: %v\ngot: %+v", testCase.days[i], testCase.expected[i], prediction)
+				}
+			}
+		})
+	}
+}
+
+func TestCurveAccumulator_PredictNoLtvValues(t *testing.T) {
+	for _, model := range []predictor.Model{linearExtrapolationModel, averageModel, powerLawModel, logarithmicModel} {
+		t.Run(model.Name, func(t *testing.T) {
+			/* ARRANGE */
+			accumulator := NewPredictStrategy(model, 0.9)()
+
+			/* ACT */
+			accumulator.Add(tp.NewAggregatedData("US", tp.LtvCollection{0, 0, 0, 0, 0, 0, 0}))
+			accumulator.Add(tp.NewAggregatedData("US", tp.LtvCollection{0, 0, 0, 0, 0, 0, 0}))
+			result := accumulator.Predict([]uint{30, 60})
+
+			/* ASSERT */
+			if len(result.Predictions()) != 2 || result.Finite() {
+				t.Fatalf("Predict() expected not finite predictions, got: %+v", result)
+			}
+		})
+	}
+}
+
+func TestCurveAccumulator_PredictAnalyticInterval(t *testing.T) {
+	/* ARRANGE */
+	accumulator := NewPredictStrategy(linearExtrapolationModel, 0.95)()
+
+	/* ACT */
+	accumulator.Add(tp.NewAggregatedData("US", tp.LtvCollection{0.5, 2.5, 1.5, 3.5}))
+	accumulator.Add(tp.NewAggregatedData("US", tp.LtvCollection{1.5, 3.5, 2.5, 4.5}))
+	result := accumulator.Predict([]uint{5})
+
+	/* ASSERT */
+	// Averages 1, 3, 2, 4 of the records deviating by the same 0.5 have covariance 0.25 of each days pair
+	// Line 0.8x + 0.5 predicts 4.5 on day 5, its coefficients sum up to 1, so standard error is 0.5, z(0.975) = 1.96
+	prediction := result.Predictions()[0]
+	if result.Confidence() != 0.95 || math.Abs(prediction.Value-4.5) > Accuracy ||
+		!(math.Abs(prediction.Lower-(4.5-0.5*1.959963984540054)) <= Accuracy) || !(math.Abs(prediction.Upper-(4.5+0.5*1.959963984540054)) <= Accuracy) {
+		t.Fatalf("Predict() exp: 4.5 [%v, %v] at 0.95\ngot: %+v at %v",
+			4.5-0.5*1.959963984540054, 4.5+0.5*1.959963984540054, prediction, result.Confidence())
+	}
+}
+
+func TestCurveAccumulator_PredictBootstrappedInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		confidence float64
+		aggregated []*tp.AggregatedData
+		bounded    bool
+	}{
+		{
+			name:       "BootstrappedInterval",
+			confidence: 0.9,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3}),
+				tp.NewAggregatedData("US", tp.LtvCollection{2, 3, 5}),
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 3, 4}),
+				tp.NewAggregatedData("US", tp.LtvCollection{3, 4, 6}),
+			},
+			bounded: true,
+		},
+		{
+			name:       "NoConfidence",
+			confidence: 0,
+			aggregated: []*tp.AggregatedData{
+				tp.NewAggregatedData("US", tp.LtvCollection{1, 2, 3}),
+				tp.NewAggregatedData("US", tp.LtvCollection{2, 3, 5}),
+			},
+			bounded: false,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Average model has no analytic interval, so it is always bootstrapped
+			accumulator := NewPredictStrategy(averageModel, testCase.confidence)()
+
+			/* ACT */
+			for _, aggData := range testCase.aggregated {
+				accumulator.Add(aggData)
+			}
+			result := accumulator.Predict([]uint{60})
+
+			/* ASSERT */
+			prediction := result.Predictions()[0]
+			if result.Confidence() != testCase.confidence {
+				t.Fatalf("Predict() exp confidence: %v\ngot: %v", testCase.confidence, result.Confidence())
+			}
+			if testCase.bounded && !(prediction.Lower < prediction.Value && prediction.Value < prediction.Upper) {
+				t.Fatalf("Predict() exp bounds around the value\ngot: %+v", prediction)
+			}
+			if !testCase.bounded && (prediction.Lower != 0 || prediction.Upper != 0) {
+				t.Fatalf("Predict() exp no bounds\ngot: %+v", prediction)
+			}
+		})
+	}
+}
+
+func TestCurveAccumulator_PredictIntervalNarrowsWithRecords(t *testing.T) {
+	// Keys of 2 and 30000 records having the same daily averages, the larger key predicts its mean more precisely
+	low := powerLawCurve(firstDays(7), 1, 0.5, 0.5)
+	high := powerLawCurve(firstDays(7), 1, 0.5, 1.5)
+	width := func(model predictor.Model, pairs int) float64 {
+		accumulator := NewPredictStrategy(model, 0.95)()
+		for range pairs {
+			accumulator.Add(tp.NewAggregatedData("US", low))
+			accumulator.Add(tp.NewAggregatedData("US", high))
+		}
+		prediction := accumulator.Predict([]uint{60}).Predictions()[0]
+		return prediction.Upper - prediction.Lower
+	}
+
+	for _, model := range []predictor.Model{linearExtrapolationModel, averageModel, powerLawModel, logarithmicModel} {
+		t.Run(model.Name, func(t *testing.T) {
+			/* ACT */
+			few := width(model, 1)
+			many := width(model, 15000)
+
+			/* ASSERT */
+			// Interval width shrinks as 1 / sqrt(records), the correction of the few records spread makes it even narrower
+			if !(few > 0 && many > 0 && many < few/100) {
+				t.Fatalf("Predict() exp interval width of 30000 records less than 1/100 of 2 records one\ngot: %v and %v", many, few)
+			}
+		})
+	}
+}
@@ -32,8 +32,8 @@ func NewPredictorChannel(predictBuffer uint) PredictorChannel {
 	return make(PredictorChannel, predictBuffer)
 }
 
-// PostProcessorChannel is a channel type for prepared for output strings
-type PostProcessorChannel chan string
+// PostProcessorChannel is a channel type for transmitting prepared for output Result instances
+type PostProcessorChannel chan *Result
 
 // NewPostProcessorChannel initializes and returns PostProcessorChannel with a buffer size
 func NewPostProcessorChannel(postProcessorBuffer uint) PostProcessorChannel {
@@ -1,37 +1,125 @@
 package types
 
-import cnst "playground/internal/constants"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"strconv"
+	"strings"
+)
 
 // JsonFileData represents the JSON file records structure
+// LTV data is provided either as LtvN fields (Ltv1, Ltv2, ...) or as an Ltv array
 type JsonFileData struct {
-	CampaignId string  `json:"CampaignId"`
-	Country    string  `json:"Country"`
-	Ltv1       float64 `json:"Ltv1"`
-	Ltv2       float64 `json:"Ltv2"`
-	Ltv3       float64 `json:"Ltv3"`
-	Ltv4       float64 `json:"Ltv4"`
-	Ltv5       float64 `json:"Ltv5"`
-	Ltv6       float64 `json:"Ltv6"`
-	Ltv7       float64 `json:"Ltv7"`
-	Users      int     `json:"Users"`
+	CampaignId string    `json:"CampaignId"`
+	Country    string    `json:"Country"`
+	Ltv        []float64 `json:"Ltv"`
+	Users      int       `json:"Users"`
 }
 
-// LtvCollection represents a LTV (Lifetime Value) data
-type LtvCollection [cnst.LtvLen]float64
+// UnmarshalJSON decodes JSON record, collects LtvN fields into Ltv collection
+// Returns error in cases of mixed Ltv and LtvN fields or missing LtvN field
+func (d *JsonFileData) UnmarshalJSON(data []byte) error {
+	// Decode common fields, alias type prevents UnmarshalJSON recursion
+	type jsonFileData JsonFileData
+	common := jsonFileData{}
+	if err := json.Unmarshal(data, &common); err != nil {
+		return err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	// Collect LtvN fields according to their day number
+	ltvByDay := map[int]float64{}
+	for name, raw := range fields {
+		day, found := LtvFieldDay(name)
+		if !found {
+			continue
+		}
+		var value float64
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return &cerror.ParseError{Column: name, Value: string(raw), Reason: "failed to convert ltv data", Err: err}
+		}
+		ltvByDay[day] = value
+	}
+
+	if len(ltvByDay) != 0 {
+		if common.Ltv != nil {
+			return cerror.NewParseError(cnst.LtvFieldName, fmt.Sprintf("both %q and %q fields provided", cnst.LtvFieldName, cnst.LtvFieldName+"N"))
+		}
+		common.Ltv = make([]float64, len(ltvByDay))
+		for day := 1; day <= len(ltvByDay); day++ {
+			value, found := ltvByDay[day]
+			if !found {
+				return cerror.NewParseError(cnst.LtvFieldName+strconv.Itoa(day), "field is missing")
+			}
+			common.Ltv[day-1] = value
+		}
+	}
+
+	*d = JsonFileData(common)
+	return nil
+}
+
+// LtvFieldDay returns day number of the LtvN field name, case insensitive
+// Returns false if name is not a LtvN field name
+func LtvFieldDay(name string) (int, bool) {
+	if len(name) <= len(cnst.LtvFieldPrefix) || !strings.EqualFold(name[:len(cnst.LtvFieldPrefix)], cnst.LtvFieldPrefix) {
+		return 0, false
+	}
+	digits := name[len(cnst.LtvFieldPrefix):]
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	day, err := strconv.Atoi(digits)
+	if err != nil || day == 0 {
+		return 0, false
+	}
+	return day, true
+}
+
+// LtvCollection represents a LTV (Lifetime Value) data, one value per day
+// Collection length depends on the data source
+type LtvCollection []float64
+
+// Finite returns false if some of the values is NaN or infinity
+func (c LtvCollection) Finite() bool {
+	for _, value := range c {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+	}
+	return true
+}
 
 // Record struct represents a common data type retrieved from data sources
 // That means that all data sources should provide Record data in system
+// Weight is the number of users the record LTV is averaged over
 type Record struct {
 	campaignId, country string
 	ltv                 LtvCollection
+	weight              float64
 }
 
-// NewRecord initializes and returns a new Record struct
+// NewRecord initializes and returns a new single user Record struct
 func NewRecord(campaignId, country string, ltv LtvCollection) *Record {
+	return NewWeightedRecord(campaignId, country, ltv, 1)
+}
+
+// NewWeightedRecord initializes and returns a new Record struct with LTV averaged over weight users
+func NewWeightedRecord(campaignId, country string, ltv LtvCollection, weight float64) *Record {
 	return &Record{
 		campaignId: campaignId,
 		country:    country,
 		ltv:        ltv,
+		weight:     weight,
 	}
 }
 
@@ -39,39 +127,207 @@ func NewRecord(campaignId, country string, ltv LtvCollection) *Record {
 func (r *Record) CampaignId() string { return r.campaignId }
 func (r *Record) Country() string    { return r.country }
 func (r *Record) Ltv() LtvCollection { return r.ltv }
+func (r *Record) Weight() float64    { return r.weight }
 
 // AggregatedData struct represents aggregated data, according to key
+// Key is composed of one or more aggregation dimension values
+// Weight is the record weight the LTV values are accumulated with, 1 unless the record weight is set
 type AggregatedData struct {
-	key string
-	ltv LtvCollection
+	key        string
+	dimensions []string
+	ltv        LtvCollection
+	weight     float64
+}
+
+// keyEscaper escapes key separator in dimension values, so that composite keys never collide
+var keyEscaper = strings.NewReplacer(
+	cnst.AggregateKeyEscape, cnst.AggregateKeyEscape+cnst.AggregateKeyEscape,
+	cnst.AggregateKeySeparator, cnst.AggregateKeyEscape+cnst.AggregateKeySeparator)
+
+// compositeKey joins dimension values to a key, single dimension value is used as is
+func compositeKey(dimensions []string) string {
+	if len(dimensions) == 1 {
+		return dimensions[0]
+	}
+	escaped := make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		escaped = append(escaped, keyEscaper.Replace(dimension))
+	}
+	return strings.Join(escaped, cnst.AggregateKeySeparator)
 }
 
-// NewAggregatedData initializes and returns a new AggregatedData struct
+// NewAggregatedData initializes and returns a new single dimension AggregatedData struct
 func NewAggregatedData(key string, ltv LtvCollection) *AggregatedData {
+	return NewCompositeAggregatedData([]string{key}, ltv)
+}
+
+// NewCompositeAggregatedData initializes and returns a new AggregatedData struct
+// Key is composed of the dimension values
+func NewCompositeAggregatedData(dimensions []string, ltv LtvCollection) *AggregatedData {
 	return &AggregatedData{
-		key: key,
-		ltv: ltv,
+		key:        compositeKey(dimensions),
+		dimensions: dimensions,
+		ltv:        ltv,
+		weight:     1,
 	}
 }
 
 // AggregatedData struct getters
-func (r *AggregatedData) Key() string        { return r.key }
-func (r *AggregatedData) Ltv() LtvCollection { return r.ltv }
+func (r *AggregatedData) Key() string          { return r.key }
+func (r *AggregatedData) Dimensions() []string { return r.dimensions }
+func (r *AggregatedData) Ltv() LtvCollection   { return r.ltv }
+func (r *AggregatedData) Weight() float64      { return r.weight }
+
+// SetWeight sets the weight the LTV values are accumulated with
+func (r *AggregatedData) SetWeight(weight float64) {
+	r.weight = weight
+}
+
+// Prediction represents a predicted value for a specific day
+// Lower and Upper are the prediction interval bounds, zero unless the interval is estimated
+type Prediction struct {
+	Day   uint
+	Value float64
+	Lower float64
+	Upper float64
+}
 
 // PredictedData struct represents predicted data, according to key
+// Contains a prediction for each requested day, in requested days order
+// Samples is the number of aggregated records the prediction is made on
+// Model is the name of the model chosen for the key, empty unless the model is selected per key
+// Confidence is the level of the predictions intervals, zero unless the intervals are estimated
 type PredictedData struct {
-	key       string
-	predicted float64
+	key         string
+	dimensions  []string
+	predictions []Prediction
+	samples     int
+	model       string
+	confidence  float64
 }
 
-// NewPredictedData initializes and returns a new PredictedData struct
-func NewPredictedData(key string, predicted float64) *PredictedData {
+// NewPredictedData initializes and returns a new single dimension PredictedData struct
+func NewPredictedData(key string, predictions []Prediction) *PredictedData {
+	return NewCompositePredictedData([]string{key}, predictions)
+}
+
+// NewCompositePredictedData initializes and returns a new PredictedData struct
+// Key is composed of the dimension values
+func NewCompositePredictedData(dimensions []string, predictions []Prediction) *PredictedData {
 	return &PredictedData{
-		key:       key,
-		predicted: predicted,
+		key:         compositeKey(dimensions),
+		dimensions:  dimensions,
+		predictions: predictions,
 	}
 }
 
 // PredictedData struct getters
-func (r *PredictedData) Key() string        { return r.key }
-func (r *PredictedData) Predicted() float64 { return r.predicted }
+func (r *PredictedData) Key() string               { return r.key }
+func (r *PredictedData) Dimensions() []string      { return r.dimensions }
+func (r *PredictedData) Predictions() []Prediction { return r.predictions }
+func (r *PredictedData) Samples() int              { return r.samples }
+func (r *PredictedData) Model() string             { return r.model }
+func (r *PredictedData) Confidence() float64       { return r.confidence }
+
+// SetSamples sets the number of aggregated records the prediction is made on
+func (r *PredictedData) SetSamples(samples int) {
+	r.samples = samples
+}
+
+// SetModel sets the name of the model chosen for the key
+func (r *PredictedData) SetModel(model string) {
+	r.model = model
+}
+
+// SetIntervals sets the level and the bounds of the predictions intervals, bounds are in the predictions order
+func (r *PredictedData) SetIntervals(confidence float64, lower, upper []float64) {
+	r.confidence = confidence
+	for i := range r.predictions {
+		r.predictions[i].Lower, r.predictions[i].Upper = lower[i], upper[i]
+	}
+}
+
+// Finite returns false if some of the predicted values or the interval bounds is NaN or infinity
+func (r *PredictedData) Finite() bool {
+	for _, prediction := range r.predictions {
+		if !LtvCollection([]float64{prediction.Value, prediction.Lower, prediction.Upper}).Finite() {
+			return false
+		}
+	}
+	return true
+}
+
+// Predicted returns predicted value for the first requested day
+// Returns 0 if there are no predictions
+func (r *PredictedData) Predicted() float64 {
+	if len(r.predictions) == 0 {
+		return 0
+	}
+	return r.predictions[0].Value
+}
+
+// Dimension represents a named aggregation dimension value
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+// Metric represents a named evaluation value, e.g. prediction error
+type Metric struct {
+	Name  string
+	Value float64
+}
+
+// Result struct represents postprocessed predicted data, prepared for output
+// Label is a human readable key representation, dimensions are named key parts
+// Partial result is predicted on the data read before the run was interrupted
+// Model is the name of the model chosen for the key, empty unless the model is selected per key
+// Metrics are the model evaluation values, empty unless the model is evaluated
+// Confidence is the level of the predictions intervals, zero unless the intervals are estimated
+type Result struct {
+	label       string
+	dimensions  []Dimension
+	predictions []Prediction
+	partial     bool
+	model       string
+	metrics     []Metric
+	confidence  float64
+}
+
+// NewResult initializes and returns a new Result struct
+func NewResult(label string, dimensions []Dimension, predictions []Prediction) *Result {
+	return &Result{
+		label:       label,
+		dimensions:  dimensions,
+		predictions: predictions,
+	}
+}
+
+// Result struct getters
+func (r *Result) Label() string             { return r.label }
+func (r *Result) Dimensions() []Dimension   { return r.dimensions }
+func (r *Result) Predictions() []Prediction { return r.predictions }
+func (r *Result) Partial() bool             { return r.partial }
+func (r *Result) Model() string             { return r.model }
+func (r *Result) Metrics() []Metric         { return r.metrics }
+func (r *Result) Confidence() float64       { return r.confidence }
+
+// MarkPartial marks result as predicted on incomplete data
+func (r *Result) MarkPartial() {
+	r.partial = true
+}
+
+// SetModel sets the name of the model chosen for the key
+func (r *Result) SetModel(model string) {
+	r.model = model
+}
+
+// SetMetrics sets the model evaluation values
+func (r *Result) SetMetrics(metrics []Metric) {
+	r.metrics = metrics
+}
+
+// SetConfidence sets the level of the predictions intervals
+func (r *Result) SetConfidence(confidence float64) {
+	r.confidence = confidence
+}
@@ -1,16 +1,22 @@
 package types
 
-import "sync"
-
 // AggregatorStrategy strategy for Record data aggregation algorithm
 type AggregatorStrategy func(record *Record) *AggregatedData
 
-// PredictWorkerStrategy strategy for data prediction algorithm
-type PredictWorkerStrategy func(
-	wg *sync.WaitGroup,
-	key string,
-	inCh AggregatorChannel,
-	outCh PredictorChannel)
+// PredictAccumulator accumulates key related aggregated data and predicts values on it
+// Accumulator is owned by a single predictor pool worker, so it isn't safe for concurrent use
+type PredictAccumulator interface {
+	// Add adds aggregated data of the key
+	Add(aggData *AggregatedData)
+	// Predict predicts key related data for each of the provided days on the data added so far
+	Predict(days []uint) *PredictedData
+}
+
+// PredictStrategy strategy for data prediction algorithm, creates an empty accumulator for a new key
+type PredictStrategy func() PredictAccumulator
+
+// PostProcessorStrategy strategy for PredictedData to Result conversion algorithm
+type PostProcessorStrategy func(predictedData *PredictedData) *Result
 
-// PostProcessorStrategy strategy for PredictedData to string conversion algorithm
-type PostProcessorStrategy func(predictedData *PredictedData) string
+// PredictionOrder strategy for predictions ordering, reports whether prediction a goes before prediction b
+type PredictionOrder func(a, b *PredictedData) bool
@@ -6,15 +6,33 @@ import (
 
 // customError is a type that wraps an error message.
 type customError struct {
-	detail string
+	message string
 }
 
 // NewCustomError creates a new custom error with the given detail.
 func NewCustomError(detail string) customError {
-	return customError{detail: detail}
+	return customError{message: detail}
 }
 
 // Error returns common error message format.
 func (e customError) Error() string {
-	return fmt.Sprintf("error: %s", e.detail)
+	return fmt.Sprintf("error: %s", e.detail())
+}
+
+// detail returns the error message without the common prefix.
+func (e customError) detail() string {
+	return e.message
+}
+
+// detailer is implemented by the package errors, so nested errors are not prefixed twice.
+type detailer interface {
+	detail() string
+}
+
+// detailOf returns err message without the common prefix.
+func detailOf(err error) string {
+	if d, ok := err.(detailer); ok {
+		return d.detail()
+	}
+	return err.Error()
 }
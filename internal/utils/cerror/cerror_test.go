@@ -0,0 +1,101 @@
+package cerror
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestErrors_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		errorStr string
+	}{
+		{
+			name:     "customError",
+			err:      NewCustomError("invalid context"),
+			errorStr: "error: invalid context",
+		},
+		{
+			name:     "parseErrorReasonOnly",
+			err:      NewParseError("", "invalid csv input data len 9"),
+			errorStr: "error: invalid csv input data len 9",
+		},
+		{
+			name:     "parseErrorFullLocation",
+			err:      &ParseError{Source: "data.csv", Line: 4, Column: "Ltv2", Value: "HELLO", Reason: "failed to convert ltv data"},
+			errorStr: `error: "data.csv" line 4 column "Ltv2" value "HELLO": failed to convert ltv data`,
+		},
+		{
+			name:     "configError",
+			err:      NewConfigError("model", "foo", `"foo" invalid model parameter`),
+			errorStr: `error: "foo" invalid model parameter`,
+		},
+		{
+			name:     "stageErrorReason",
+			err:      &StageError{Stage: "csv datasource", Source: "data.csv", Reason: "failed to open csv file"},
+			errorStr: `error: csv datasource "data.csv": failed to open csv file`,
+		},
+		{
+			name:     "stageErrorReasonWithCause",
+			err:      &StageError{Stage: "json datasource", Source: "data.json", Reason: "failed to unmarshal json data", Err: errors.New("unexpected EOF")},
+			errorStr: `error: json datasource "data.json": failed to unmarshal json data: unexpected EOF`,
+		},
+		{
+			name:     "stageErrorNestedParseError",
+			err:      NewStageError("csv datasource", NewParseError("Ltv1", "required csv column is missing")),
+			errorStr: `error: csv datasource: column "Ltv1": required csv column is missing`,
+		},
+		{
+			name:     "stageErrorForeignCause",
+			err:      NewStageError("multi datasource", errors.New("unsupported")),
+			errorStr: "error: multi datasource: unsupported",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			errorStr := testCase.err.Error()
+
+			/* ASSERT */
+			if errorStr != testCase.errorStr {
+				t.Fatalf("Error() : expected error string [%s], got [%s]", testCase.errorStr, errorStr)
+			}
+		})
+	}
+}
+
+func TestErrors_Unwrap(t *testing.T) {
+	/* ARRANGE */
+	_, numErr := strconv.ParseFloat("HELLO", 64)
+	parseErr := &ParseError{Column: "Ltv2", Value: "HELLO", Reason: "failed to convert ltv data", Err: numErr}
+	stageErr := &StageError{Stage: "csv datasource", Source: "data.csv", Err: parseErr}
+	configErr := &ConfigError{Param: "source", Value: "data.csv", Message: `"data.csv" no such file`, Err: os.ErrNotExist}
+
+	/* ACT */
+	var gotParseErr *ParseError
+	var gotNumErr *strconv.NumError
+	var gotStageErr *StageError
+	var gotConfigErr *ConfigError
+	foundParseErr := errors.As(stageErr, &gotParseErr)
+	foundNumErr := errors.As(stageErr, &gotNumErr)
+	foundStageErr := errors.As(stageErr, &gotStageErr)
+	foundConfigErr := errors.As(configErr, &gotConfigErr)
+
+	/* ASSERT */
+	if !foundParseErr || gotParseErr != parseErr {
+		t.Fatalf("errors.As() : expected parse error [%v], got [%v]", parseErr, gotParseErr)
+	}
+	if !foundNumErr || !errors.Is(stageErr, strconv.ErrSyntax) {
+		t.Fatalf("errors.As() : expected strconv.NumError cause, got [%v]", gotNumErr)
+	}
+	if !foundStageErr || gotStageErr.Stage != "csv datasource" {
+		t.Fatalf("errors.As() : expected stage error [%v], got [%v]", stageErr, gotStageErr)
+	}
+	if !foundConfigErr || gotConfigErr.Param != "source" || !errors.Is(configErr, os.ErrNotExist) {
+		t.Fatalf("errors.As() : expected config error [%v], got [%v]", configErr, gotConfigErr)
+	}
+}
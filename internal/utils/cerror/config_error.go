@@ -0,0 +1,30 @@
+package cerror
+
+// ConfigError describes invalid configuration, e.g. unknown parameter value or missing runner dependency.
+// Param names the parameter, Value holds the rejected value, Err holds the underlying cause if any.
+type ConfigError struct {
+	Param   string
+	Value   string
+	Message string
+	Err     error
+}
+
+// NewConfigError creates a new configuration error of the given parameter.
+func NewConfigError(param, value, message string) *ConfigError {
+	return &ConfigError{Param: param, Value: value, Message: message}
+}
+
+// Error returns common error message format.
+func (e *ConfigError) Error() string {
+	return NewCustomError(e.detail()).Error()
+}
+
+// Unwrap returns the underlying cause.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// detail returns the error message.
+func (e *ConfigError) detail() string {
+	return e.Message
+}
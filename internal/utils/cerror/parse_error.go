@@ -0,0 +1,53 @@
+package cerror
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes invalid input data, location fields are filled as far as they are known.
+// Err holds the underlying cause, e.g. *strconv.NumError, and is available via errors.Is/errors.As.
+type ParseError struct {
+	Source string
+	Line   int
+	Column string
+	Value  string
+	Reason string
+	Err    error
+}
+
+// NewParseError creates a new parse error of the given column, empty column means the whole row.
+func NewParseError(column, reason string) *ParseError {
+	return &ParseError{Column: column, Reason: reason}
+}
+
+// Error returns common error message format prefixed by the known location.
+func (e *ParseError) Error() string {
+	return NewCustomError(e.detail()).Error()
+}
+
+// Unwrap returns the underlying cause.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// detail returns the location and the reason of the error.
+func (e *ParseError) detail() string {
+	location := make([]string, 0, 4)
+	if e.Source != "" {
+		location = append(location, fmt.Sprintf("%q", e.Source))
+	}
+	if e.Line > 0 {
+		location = append(location, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Column != "" {
+		location = append(location, fmt.Sprintf("column %q", e.Column))
+	}
+	if e.Value != "" {
+		location = append(location, fmt.Sprintf("value %q", e.Value))
+	}
+	if len(location) == 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(location, " "), e.Reason)
+}
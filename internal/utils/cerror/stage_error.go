@@ -0,0 +1,45 @@
+package cerror
+
+import "fmt"
+
+// StageError describes a failure of the pipeline stage, Stage names the runner.
+// Reason describes the failure, the message of the underlying Err follows it if any.
+type StageError struct {
+	Stage  string
+	Source string
+	Reason string
+	Err    error
+}
+
+// NewStageError creates a new stage error wrapping err.
+func NewStageError(stage string, err error) *StageError {
+	return &StageError{Stage: stage, Err: err}
+}
+
+// Error returns common error message format prefixed by the stage name.
+func (e *StageError) Error() string {
+	return NewCustomError(e.detail()).Error()
+}
+
+// Unwrap returns the underlying cause.
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// detail returns the stage, the source and the reason of the error.
+func (e *StageError) detail() string {
+	stage := e.Stage
+	if e.Source != "" {
+		stage = fmt.Sprintf("%s %q", stage, e.Source)
+	}
+
+	reason := e.Reason
+	if e.Err != nil {
+		if reason == "" {
+			reason = detailOf(e.Err)
+		} else {
+			reason = fmt.Sprintf("%s: %s", reason, detailOf(e.Err))
+		}
+	}
+	return fmt.Sprintf("%s: %s", stage, reason)
+}
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"strings"
+	"sync"
+)
+
+// Collector gathers errors reported by the pipeline runners, it is safe for concurrent use
+// Errors are attributed to the stage they came from, the end of run report groups them by stage
+type Collector struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+// NewCollector initializes and returns an empty Collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Add stores err, nil errors are ignored
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, err)
+}
+
+// Errors returns collected errors in the order they were added
+func (c *Collector) Errors() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]error(nil), c.errors...)
+}
+
+// Len returns the number of collected errors
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errors)
+}
+
+// Err returns collected errors joined into one, nil if there are no errors
+func (c *Collector) Err() error {
+	return errors.Join(c.Errors()...)
+}
+
+// Report returns the end of run report, errors are grouped by stage in order of the first stage error
+// Returns empty string if there are no errors
+func (c *Collector) Report() string {
+	errs := c.Errors()
+	if len(errs) == 0 {
+		return ""
+	}
+
+	stages := make([]string, 0)
+	byStage := map[string][]error{}
+	for _, err := range errs {
+		stage := Stage(err)
+		if _, found := byStage[stage]; !found {
+			stages = append(stages, stage)
+		}
+		byStage[stage] = append(byStage[stage], err)
+	}
+
+	report := &strings.Builder{}
+	fmt.Fprintf(report, "%d pipeline errors", len(errs))
+	for _, stage := range stages {
+		fmt.Fprintf(report, "\n%s (%d):", stage, len(byStage[stage]))
+		for _, err := range byStage[stage] {
+			fmt.Fprintf(report, "\n  %s", err)
+		}
+	}
+	return report.String()
+}
+
+// ExitCode returns the process exit code of the most severe collected error class
+// Configuration errors are the most severe, then parse, interruption, stage and unknown ones
+func (c *Collector) ExitCode() int {
+	code := cnst.ExitOk
+	for _, err := range c.Errors() {
+		if errCode := ExitCode(err); code == cnst.ExitOk || severity(errCode) > severity(code) {
+			code = errCode
+		}
+	}
+	return code
+}
+
+// Stage returns the name of the pipeline stage err came from
+// Row parse errors are reported by data sources, errors of unknown origin are attributed to unknown stage
+func Stage(err error) string {
+	var stageErr *cerror.StageError
+	if errors.As(err, &stageErr) {
+		return stageErr.Stage
+	}
+	var configErr *cerror.ConfigError
+	if errors.As(err, &configErr) {
+		return cnst.ConfigStage
+	}
+	var parseErr *cerror.ParseError
+	if errors.As(err, &parseErr) {
+		return cnst.DataSourceStage
+	}
+	return cnst.UnknownStage
+}
+
+// Fatal returns false for errors which drop a single record or key and let the pipeline continue
+// Errors of aggregator, predictor, backtest and postprocessor stages are such ones, others mean incomplete input or output
+func Fatal(err error) bool {
+	switch Stage(err) {
+	case cnst.AggregatorStage, cnst.PredictorStage, cnst.BacktestStage, cnst.PostProcessorStage:
+		return false
+	default:
+		return true
+	}
+}
+
+// ExitCode returns the process exit code of err class
+// The cause defines the class, e.g. stage error caused by invalid csv header is a parse error
+// Run cancelled by the caller, e.g. on SIGINT, is an interruption
+func ExitCode(err error) int {
+	var configErr *cerror.ConfigError
+	var parseErr *cerror.ParseError
+	var stageErr *cerror.StageError
+	switch {
+	case err == nil:
+		return cnst.ExitOk
+	case errors.As(err, &configErr):
+		return cnst.ExitConfigError
+	case errors.As(err, &parseErr):
+		return cnst.ExitParseError
+	case errors.Is(err, context.Canceled):
+		return cnst.ExitInterrupted
+	case errors.As(err, &stageErr):
+		return cnst.ExitStageError
+	default:
+		return cnst.ExitUnknown
+	}
+}
+
+// severity returns exit code rank, the higher rank wins
+func severity(code int) int {
+	switch code {
+	case cnst.ExitConfigError:
+		return 4
+	case cnst.ExitParseError:
+		return 3
+	case cnst.ExitInterrupted:
+		return 2
+	case cnst.ExitStageError:
+		return 1
+	default:
+		return 0
+	}
+}
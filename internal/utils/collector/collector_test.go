@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"strings"
+	"testing"
+)
+
+func TestCollector_ExitCode(t *testing.T) {
+	parseErr := cerror.NewParseError("Ltv1", "failed to convert ltv data")
+	configErr := cerror.NewConfigError(cnst.CliModelParam, "foo", `"foo" invalid model parameter`)
+	stageErr := &cerror.StageError{Stage: cnst.PredictorStage, Source: "JP", Reason: "not enough ltv data to predict"}
+	tests := []struct {
+		name     string
+		errs     []error
+		expected int
+	}{
+		{name: "noErrors", errs: nil, expected: cnst.ExitOk},
+		{name: "unknownError", errs: []error{errors.New("broken pipe")}, expected: cnst.ExitUnknown},
+		{name: "stageError", errs: []error{stageErr}, expected: cnst.ExitStageError},
+		{name: "parseError", errs: []error{parseErr}, expected: cnst.ExitParseError},
+		{name: "configError", errs: []error{configErr}, expected: cnst.ExitConfigError},
+		{
+			name:     "stageErrorCausedByParseError",
+			errs:     []error{&cerror.StageError{Stage: cnst.CsvDataSourceStage, Err: parseErr}},
+			expected: cnst.ExitParseError,
+		},
+		{
+			name:     "wrappedConfigError",
+			errs:     []error{fmt.Errorf("wrapped: %w", configErr)},
+			expected: cnst.ExitConfigError,
+		},
+		{
+			name:     "interruptedRun",
+			errs:     []error{&cerror.StageError{Stage: cnst.PipelineStage, Reason: "run interrupted", Err: context.Canceled}},
+			expected: cnst.ExitInterrupted,
+		},
+		{
+			name:     "interruptionWinsOverStageError",
+			errs:     []error{stageErr, &cerror.StageError{Stage: cnst.PipelineStage, Reason: "run interrupted", Err: context.Canceled}},
+			expected: cnst.ExitInterrupted,
+		},
+		{
+			name:     "mostSevereErrorWins",
+			errs:     []error{errors.New("broken pipe"), stageErr, parseErr, stageErr},
+			expected: cnst.ExitParseError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			collector := NewCollector()
+			for _, err := range testCase.errs {
+				collector.Add(err)
+			}
+
+			/* ACT */
+			code := collector.ExitCode()
+
+			/* ASSERT */
+			if code != testCase.expected {
+				t.Fatalf("ExitCode() : expected %d, got %d", testCase.expected, code)
+			}
+		})
+	}
+}
+
+func TestCollector_Stage(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+		fatal    bool
+	}{
+		{
+			name:     "stageError",
+			err:      &cerror.StageError{Stage: cnst.PredictorStage, Reason: "not enough ltv data to predict"},
+			expected: cnst.PredictorStage,
+			fatal:    false,
+		},
+		{
+			name:     "backtestStageError",
+			err:      &cerror.StageError{Stage: cnst.BacktestStage, Source: "JP", Reason: "no known ltv values of the backtest days"},
+			expected: cnst.BacktestStage,
+			fatal:    false,
+		},
+		{
+			name:     "datasourceStageError",
+			err:      &cerror.StageError{Stage: cnst.CsvDataSourceStage, Reason: "failed to open csv file"},
+			expected: cnst.CsvDataSourceStage,
+			fatal:    true,
+		},
+		{
+			name:     "rowParseError",
+			err:      cerror.NewParseError("Ltv1", "failed to convert ltv data"),
+			expected: cnst.DataSourceStage,
+			fatal:    true,
+		},
+		{
+			name:     "configError",
+			err:      cerror.NewConfigError(cnst.CliSourceParam, "", "invalid data sources list"),
+			expected: cnst.ConfigStage,
+			fatal:    true,
+		},
+		{
+			name:     "unknownError",
+			err:      errors.New("broken pipe"),
+			expected: cnst.UnknownStage,
+			fatal:    true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			stage := Stage(testCase.err)
+			fatal := Fatal(testCase.err)
+
+			/* ASSERT */
+			if stage != testCase.expected {
+				t.Fatalf("Stage() : expected %q, got %q", testCase.expected, stage)
+			}
+			if fatal != testCase.fatal {
+				t.Fatalf("Fatal() : expected %v, got %v", testCase.fatal, fatal)
+			}
+		})
+	}
+}
+
+func TestCollector_Report(t *testing.T) {
+	/* ARRANGE */
+	collector := NewCollector()
+	predictErrJP := &cerror.StageError{Stage: cnst.PredictorStage, Source: "JP", Reason: "not enough ltv data to predict"}
+	aggregateErr := &cerror.StageError{Stage: cnst.AggregatorStage, Source: "US", Reason: "non-finite ltv data skipped"}
+	predictErrDE := &cerror.StageError{Stage: cnst.PredictorStage, Source: "DE", Reason: "not enough ltv data to predict"}
+	collector.Add(predictErrJP)
+	collector.Add(nil)
+	collector.Add(aggregateErr)
+	collector.Add(predictErrDE)
+	expected := strings.Join([]string{
+		"3 pipeline errors",
+		"predictor (2):",
+		"  " + predictErrJP.Error(),
+		"  " + predictErrDE.Error(),
+		"aggregator (1):",
+		"  " + aggregateErr.Error(),
+	}, "\n")
+
+	/* ACT */
+	report := collector.Report()
+
+	/* ASSERT */
+	if collector.Len() != 3 {
+		t.Fatalf("Len() : expected %d, got %d", 3, collector.Len())
+	}
+	if report != expected {
+		t.Fatalf("Report() : expected\n%s\ngot\n%s", expected, report)
+	}
+	if NewCollector().Report() != "" {
+		t.Fatalf("Report() : expected empty report without errors")
+	}
+}
@@ -0,0 +1,109 @@
+package outfile
+
+import (
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"strings"
+)
+
+// formatExtensions maps output formats to result file extensions
+var formatExtensions = map[string]string{
+	cnst.TextOutputFormat:  ".txt",
+	cnst.CsvOutputFormat:   ".csv",
+	cnst.JsonOutputFormat:  ".json",
+	cnst.JsonlOutputFormat: ".jsonl",
+	cnst.TableOutputFormat: ".txt",
+}
+
+// extensionFormats maps result file extensions to output formats, only unambiguous ones are listed
+var extensionFormats = map[string]string{
+	".csv":   cnst.CsvOutputFormat,
+	".json":  cnst.JsonOutputFormat,
+	".jsonl": cnst.JsonlOutputFormat,
+}
+
+// FormatFromPath returns output format inferred from the file extension
+// Returns false if the extension doesn't define the format
+func FormatFromPath(path string) (string, bool) {
+	format, ok := extensionFormats[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+// ResolvePath returns result file path
+// If path is an existing directory, default file name with format extension is used inside it
+func ResolvePath(path, format string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, cnst.OutputDefaultFileName+formatExtensions[format])
+	}
+	return path
+}
+
+// AtomicFile is a file that appears at its path only once Commit succeeds
+// Data is written to a temporary file in the same directory and renamed on Commit
+type AtomicFile struct {
+	file *os.File
+	path string
+	done bool
+}
+
+// NewAtomicFile creates temporary file next to path and returns AtomicFile
+// Returns error if temporary file can't be created
+func NewAtomicFile(path string) (*AtomicFile, error) {
+	if path == "" {
+		return nil, cerror.NewConfigError("output file path", "", "invalid output file path")
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, &cerror.StageError{Stage: cnst.OutputStage, Source: path, Reason: "failed to create output file", Err: err}
+	}
+	return &AtomicFile{file: file, path: path}, nil
+}
+
+// Write io.Writer implementation, writes data to the temporary file
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	return f.file.Write(p)
+}
+
+// Path returns destination file path
+func (f *AtomicFile) Path() string {
+	return f.path
+}
+
+// Commit flushes temporary file to disk and renames it to destination path
+// Temporary file is removed if any of the steps fails
+func (f *AtomicFile) Commit() error {
+	if f.done {
+		return &cerror.StageError{Stage: cnst.OutputStage, Source: f.path, Reason: "output file already closed"}
+	}
+	f.done = true
+
+	tmpName := f.file.Name()
+	err := f.file.Sync()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, cnst.OutputFileMode)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, f.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return &cerror.StageError{Stage: cnst.OutputStage, Source: f.path, Reason: "failed to write output file", Err: err}
+	}
+	return nil
+}
+
+// Abort closes and removes temporary file, destination path stays untouched
+// Does nothing if the file is already committed or aborted
+func (f *AtomicFile) Abort() {
+	if f.done {
+		return
+	}
+	f.done = true
+	_ = f.file.Close()
+	_ = os.Remove(f.file.Name())
+}
@@ -0,0 +1,128 @@
+package outfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"testing"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedFormat string
+		expectedOk     bool
+	}{
+		{name: "CsvExtension", path: "out/results.csv", expectedFormat: cnst.CsvOutputFormat, expectedOk: true},
+		{name: "JsonExtension", path: "results.json", expectedFormat: cnst.JsonOutputFormat, expectedOk: true},
+		{name: "JsonlUpperCaseExtension", path: "results.JSONL", expectedFormat: cnst.JsonlOutputFormat, expectedOk: true},
+		{name: "UnknownExtension", path: "results.txt"},
+		{name: "NoExtension", path: "results"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Path is prepared in the test case
+
+			/* ACT */
+			format, ok := FormatFromPath(testCase.path)
+
+			/* ASSERT */
+			if format != testCase.expectedFormat || ok != testCase.expectedOk {
+				t.Errorf("Unexpected format, expected: %q %v, got: %q %v", testCase.expectedFormat, testCase.expectedOk, format, ok)
+			}
+		})
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	/* ARRANGE */
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "results.csv")
+
+	/* ACT */
+	dirResult := ResolvePath(dir, cnst.JsonOutputFormat)
+	fileResult := ResolvePath(filePath, cnst.JsonOutputFormat)
+
+	/* ASSERT */
+	if expected := filepath.Join(dir, cnst.OutputDefaultFileName+".json"); dirResult != expected {
+		t.Errorf("Unexpected directory path, expected: %q, got: %q", expected, dirResult)
+	}
+	if fileResult != filePath {
+		t.Errorf("Unexpected file path, expected: %q, got: %q", filePath, fileResult)
+	}
+}
+
+func TestAtomicFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		commit       bool
+		expectedFile bool
+	}{
+		{name: "Commit", commit: true, expectedFile: true},
+		{name: "Abort", commit: false, expectedFile: false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			dir := t.TempDir()
+			path := filepath.Join(dir, "results.csv")
+			file, err := NewAtomicFile(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err = file.Write([]byte("data")); err != nil {
+				t.Fatalf("Unexpected write error: %v", err)
+			}
+
+			/* ACT */
+			// Destination file must not exist before commit
+			if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+				t.Fatalf("Unexpected destination file before commit: %v", statErr)
+			}
+			if testCase.commit {
+				err = file.Commit()
+			} else {
+				file.Abort()
+			}
+
+			/* ASSERT */
+			if err != nil {
+				t.Fatalf("Unexpected commit error: %v", err)
+			}
+			data, readErr := os.ReadFile(path)
+			if (readErr == nil) != testCase.expectedFile {
+				t.Errorf("Unexpected destination file state, expected exists: %v, got error: %v", testCase.expectedFile, readErr)
+			}
+			if testCase.expectedFile && string(data) != "data" {
+				t.Errorf("Unexpected file content, expected: %q, got: %q", "data", string(data))
+			}
+			// Temporary file must be removed in any case
+			expectedEntries := 0
+			if testCase.expectedFile {
+				expectedEntries = 1
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != expectedEntries {
+				t.Errorf("Unexpected directory entries: %v", entries)
+			}
+		})
+	}
+}
+
+func TestNewAtomicFile_InvalidPath(t *testing.T) {
+	/* ARRANGE */
+	path := filepath.Join(t.TempDir(), "missing", "results.csv")
+
+	/* ACT */
+	_, err := NewAtomicFile(path)
+
+	/* ASSERT */
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected not exist error for path %q, got: %v", path, err)
+	}
+}
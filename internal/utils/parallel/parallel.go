@@ -0,0 +1,96 @@
+package parallel
+
+import (
+	"context"
+)
+
+// batch represents a batch of read items and their conversion results
+// done channel is closed once the batch is converted
+type batch[T, R any] struct {
+	items   []T
+	results []R
+	done    chan struct{}
+}
+
+// Map reads items with read until it returns false and converts them with convert in workers goroutines
+// Items are read by a single goroutine and converted in batches of batchSize, so read doesn't need to be safe for concurrent use
+// Converted batches are sent to the returned channel in the read order, the channel is closed once all of them are sent
+// Cancelled ctx stops reading and sending, the returned channel is closed once read isn't called anymore
+func Map[T, R any](ctx context.Context, workers, batchSize int, read func() (T, bool), convert func(T) R) <-chan []R {
+	workers = max(workers, 1)
+	batchSize = max(batchSize, 1)
+
+	// Batches are queued to workers and, in the same order, to the sender
+	jobs := make(chan *batch[T, R], workers)
+	pending := make(chan *batch[T, R], workers)
+	out := make(chan []R, workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				job.results = make([]R, len(job.items))
+				for i, item := range job.items {
+					job.results[i] = convert(item)
+				}
+				close(job.done)
+			}
+		}()
+	}
+
+	// Read items into batches until read reports the end or cancel event received
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+
+		for finished := false; !finished; {
+			items := make([]T, 0, batchSize)
+			for len(items) < batchSize {
+				item, ok := read()
+				if !ok {
+					finished = true
+					break
+				}
+				items = append(items, item)
+			}
+			if len(items) == 0 {
+				return
+			}
+
+			job := &batch[T, R]{items: items, done: make(chan struct{})}
+			select {
+			case pending <- job:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Send converted batches in the read order
+	// Reading goroutine is awaited before the channel is closed, so the caller could release read resources then
+	go func() {
+		defer close(out)
+		defer func() {
+			for range pending {
+			}
+		}()
+		for job := range pending {
+			select {
+			case <-job.done:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- job.results:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
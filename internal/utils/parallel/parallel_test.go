@@ -0,0 +1,73 @@
+package parallel
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// counter returns read function producing numbers from 0 to n - 1
+func counter(n int) func() (int, bool) {
+	next := 0
+	return func() (int, bool) {
+		if next == n {
+			return 0, false
+		}
+		next++
+		return next - 1, true
+	}
+}
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     int
+		workers   int
+		batchSize int
+	}{
+		{name: "noItems", items: 0, workers: 4, batchSize: 8},
+		{name: "singleWorker", items: 100, workers: 1, batchSize: 8},
+		{name: "severalWorkers", items: 1000, workers: 4, batchSize: 8},
+		{name: "partialLastBatch", items: 13, workers: 3, batchSize: 5},
+		{name: "invalidWorkersAndBatchSize", items: 10, workers: 0, batchSize: 0},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			expected := []string{}
+			for i := 0; i < testCase.items; i++ {
+				expected = append(expected, strconv.Itoa(i))
+			}
+
+			/* ACT */
+			results := []string{}
+			for batch := range Map(context.Background(), testCase.workers, testCase.batchSize, counter(testCase.items), strconv.Itoa) {
+				results = append(results, batch...)
+			}
+
+			/* ASSERT */
+			// Results are expected in the read order
+			if !reflect.DeepEqual(results, expected) {
+				t.Fatalf("Map() : expected %v, got %v", expected, results)
+			}
+		})
+	}
+}
+
+func TestMap_Cancel(t *testing.T) {
+	/* ARRANGE */
+	ctx, cancel := context.WithCancel(context.Background())
+	read := func() (int, bool) { return 1, true }
+
+	/* ACT */
+	out := Map(ctx, 2, 4, read, func(i int) int { return i })
+	<-out
+	cancel()
+
+	/* ASSERT */
+	// Endless reading is stopped, the channel is closed
+	for range out {
+	}
+}
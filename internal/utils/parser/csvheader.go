@@ -0,0 +1,102 @@
+package parser
+
+import (
+	cnst "playground/internal/constants"
+	"playground/internal/types"
+	"playground/internal/utils/cerror"
+	"strconv"
+	"strings"
+)
+
+// defaultCsvAliases maps commonly used alternative column names to canonical ones
+var defaultCsvAliases = map[string]string{
+	"campaign_id":  cnst.CsvCampaignIdName,
+	"campaign":     cnst.CsvCampaignIdName,
+	"country_code": cnst.CsvCountryName,
+	"geo":          cnst.CsvCountryName,
+}
+
+// CsvHeader maps Record fields to CSV row positions according to the CSV header
+type CsvHeader struct {
+	names      []string
+	columns    int
+	campaignId int
+	country    int
+	ltv        []int
+}
+
+// NewCsvHeader maps CSV header columns to Record fields by name, case insensitive
+// Aliases map alternative column names to canonical ones and extend default aliases
+// Unknown columns are ignored, LtvN columns are collected in days order
+// Returns error naming the missing or duplicated mapped column
+func NewCsvHeader(header []string, aliases map[string]string) (*CsvHeader, error) {
+	// Merge default and user provided aliases, user provided aliases have priority
+	columnAliases := make(map[string]string, len(defaultCsvAliases)+len(aliases))
+	for alias, name := range defaultCsvAliases {
+		columnAliases[strings.ToLower(alias)] = name
+	}
+	for alias, name := range aliases {
+		columnAliases[strings.ToLower(strings.TrimSpace(alias))] = strings.TrimSpace(name)
+	}
+
+	positions := map[string]int{}
+	mapped := map[string]bool{
+		strings.ToLower(cnst.CsvCampaignIdName): true,
+		strings.ToLower(cnst.CsvCountryName):    true,
+	}
+	ltvPositions := map[int]int{}
+	for position, column := range header {
+		name := strings.TrimSpace(column)
+		if canonical, found := columnAliases[strings.ToLower(name)]; found {
+			name = canonical
+		}
+
+		// Collect LtvN columns according to their day number
+		if day, found := types.LtvFieldDay(name); found {
+			if _, duplicated := ltvPositions[day]; duplicated {
+				return nil, cerror.NewParseError(column, "csv column is duplicated")
+			}
+			ltvPositions[day] = position
+			continue
+		}
+
+		// Only mapped columns are checked, so repeated or blank unknown columns are ignored
+		key := strings.ToLower(name)
+		if !mapped[key] {
+			continue
+		}
+		if _, duplicated := positions[key]; duplicated {
+			return nil, cerror.NewParseError(column, "csv column is duplicated")
+		}
+		positions[key] = position
+	}
+
+	result := &CsvHeader{names: header, columns: len(header)}
+	for _, required := range []struct {
+		name     string
+		position *int
+	}{
+		{cnst.CsvCampaignIdName, &result.campaignId},
+		{cnst.CsvCountryName, &result.country},
+	} {
+		position, found := positions[strings.ToLower(required.name)]
+		if !found {
+			return nil, cerror.NewParseError(required.name, "required csv column is missing")
+		}
+		*required.position = position
+	}
+
+	// LtvN columns should represent continuous days sequence, starting from Ltv1
+	result.ltv = make([]int, 0, len(ltvPositions))
+	for day := 1; day == 1 || day <= len(ltvPositions); day++ {
+		position, found := ltvPositions[day]
+		if !found {
+			return nil, cerror.NewParseError(cnst.LtvFieldName+strconv.Itoa(day), "required csv column is missing")
+		}
+		result.ltv = append(result.ltv, position)
+	}
+	return result, nil
+}
+
+// LtvLen returns the number of LtvN columns
+func (h *CsvHeader) LtvLen() int { return len(h.ltv) }
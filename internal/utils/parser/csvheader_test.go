@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"playground/internal/types"
+	"playground/internal/utils/cerror"
+	"reflect"
+	"testing"
+)
+
+func TestNewCsvHeader_InvalidHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []string
+		aliases  map[string]string
+		errorStr string
+	}{
+		{
+			name:     "missingCampaignIdColumn",
+			header:   []string{"UserId", "Country", "Ltv1"},
+			errorStr: cerror.NewParseError("CampaignId", "required csv column is missing").Error(),
+		},
+		{
+			name:     "missingCountryColumn",
+			header:   []string{"UserId", "CampaignId", "Ltv1"},
+			errorStr: cerror.NewParseError("Country", "required csv column is missing").Error(),
+		},
+		{
+			name:     "missingLtvColumns",
+			header:   []string{"UserId", "CampaignId", "Country"},
+			errorStr: cerror.NewParseError("Ltv1", "required csv column is missing").Error(),
+		},
+		{
+			name:     "missingLtvDayColumn",
+			header:   []string{"UserId", "CampaignId", "Country", "Ltv1", "Ltv3"},
+			errorStr: cerror.NewParseError("Ltv2", "required csv column is missing").Error(),
+		},
+		{
+			name:     "duplicatedColumn",
+			header:   []string{"CampaignId", "Country", "campaign_id", "Ltv1"},
+			errorStr: cerror.NewParseError("campaign_id", "csv column is duplicated").Error(),
+		},
+		{
+			name:     "duplicatedLtvColumn",
+			header:   []string{"CampaignId", "Country", "Ltv1", "day1"},
+			aliases:  map[string]string{"day1": "Ltv1"},
+			errorStr: cerror.NewParseError("day1", "csv column is duplicated").Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+
+			/* ACT */
+			result, err := NewCsvHeader(testCase.header, testCase.aliases)
+
+			/* ASSERT */
+			// Assert expected error
+			if err == nil {
+				t.Fatalf("NewCsvHeader() with args %v: expected error, got %+v", testCase.header, result)
+			}
+
+			// Assert expected error string
+			if err.Error() != testCase.errorStr {
+				t.Fatalf("NewCsvHeader() with args %v: expected error string [%s], got [%s]", testCase.header, testCase.errorStr, err.Error())
+			}
+		})
+	}
+}
+
+func TestNewCsvHeader_ColumnsMapping(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         []string
+		aliases        map[string]string
+		row            []string
+		expectedResult *types.Record
+	}{
+		{
+			name:           "defaultColumnsOrder",
+			header:         []string{"UserId", "CampaignId", "Country", "Ltv1", "Ltv2", "Ltv3"},
+			row:            []string{"1", "id", "JP", "1", "2", "3"},
+			expectedResult: types.NewRecord("id", "JP", types.LtvCollection{1, 2, 3}),
+		},
+		{
+			name:           "reorderedColumnsCaseInsensitive",
+			header:         []string{"ltv2", "COUNTRY", "LTV1", "campaignid"},
+			row:            []string{"2", "JP", "1", "id"},
+			expectedResult: types.NewRecord("id", "JP", types.LtvCollection{1, 2}),
+		},
+		{
+			name:           "unknownColumnsIgnored",
+			header:         []string{"Date", "CampaignId", "Users", "Country", "Ltv1", "Revenue"},
+			row:            []string{"2023-09-14", "id", "12", "JP", "1", "100"},
+			expectedResult: types.NewRecord("id", "JP", types.LtvCollection{1}),
+		},
+		{
+			name:           "duplicatedUnknownColumnsIgnored",
+			header:         []string{"note", "CampaignId", "note", "Country", "Ltv1", "", ""},
+			row:            []string{"a", "id", "b", "JP", "1", "", ""},
+			expectedResult: types.NewRecord("id", "JP", types.LtvCollection{1}),
+		},
+		{
+			name:           "defaultAliases",
+			header:         []string{"user_id", "campaign_id", "country_code", "Ltv1"},
+			row:            []string{"1", "id", "JP", "1"},
+			expectedResult: types.NewRecord("id", "JP", types.LtvCollection{1}),
+		},
+		{
+			name:           "configuredAliases",
+			header:         []string{"campaign_uuid", "GEO", "revenue_d1", "revenue_d2"},
+			aliases:        map[string]string{"Campaign_UUID": "CampaignId", "revenue_d1": "Ltv1", "revenue_d2": "Ltv2"},
+			row:            []string{"id", "JP", "1", "2"},
+			expectedResult: types.NewRecord("id", "JP", types.LtvCollection{1, 2}),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			header, err := NewCsvHeader(testCase.header, testCase.aliases)
+			if err != nil {
+				t.Fatalf("NewCsvHeader() with args %v: unexpected error [%s]", testCase.header, err.Error())
+			}
+
+			/* ACT */
+			result, err := NewRecordFromCsvStrings(testCase.row, header)
+
+			/* ASSERT */
+			// Assert unexpected error
+			if err != nil {
+				t.Fatalf("NewRecordFromCsvStrings() with args %v: unexpected error [%s]", testCase.row, err.Error())
+			}
+
+			// Assert result
+			if !reflect.DeepEqual(result, testCase.expectedResult) {
+				t.Fatalf("NewRecordFromCsvStrings() exp: %+v\ngot: %+v", testCase.expectedResult, result)
+			}
+		})
+	}
+}
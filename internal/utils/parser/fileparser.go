@@ -2,39 +2,45 @@ package parser
 
 import (
 	"fmt"
-	cnst "playground/internal/constants"
 	"playground/internal/types"
 	"playground/internal/utils/cerror"
 	"strconv"
 )
 
-// NewRecordFromCsvStrings creates a new Record from a slice of CSV strings.
-// Returns error in cases of invalid slice length or data conversion failures
-func NewRecordFromCsvStrings(row []string) (*types.Record, error) {
-	if len(row) != cnst.CsvDataLen {
-		return nil, cerror.NewCustomError(fmt.Sprintf("invalid csv input data len %d", len(row)))
+// NewRecordFromCsvStrings creates a new Record from a slice of CSV strings, mapped by CSV header.
+// Returns parse error in cases of invalid slice length or data conversion failures, naming the failed column and value
+func NewRecordFromCsvStrings(row []string, header *CsvHeader) (*types.Record, error) {
+	if len(row) != header.columns {
+		return nil, cerror.NewParseError("", fmt.Sprintf("invalid csv input data len %d", len(row)))
 	}
 
-	ltvs := types.LtvCollection{}
-	for i, val := range row[cnst.CsvLtv1Position:] {
-		ltv, err := strconv.ParseFloat(val, 64)
+	ltvs := make(types.LtvCollection, len(header.ltv))
+	for i, position := range header.ltv {
+		ltv, err := strconv.ParseFloat(row[position], 64)
 		if err != nil {
-			return nil, cerror.NewCustomError(fmt.Sprintf("failed to convert ltv data"))
+			return nil, &cerror.ParseError{
+				Column: header.names[position],
+				Value:  row[position],
+				Reason: "failed to convert ltv data",
+				Err:    err,
+			}
 		}
 		ltvs[i] = ltv
 	}
-	return types.NewRecord(row[cnst.CsvCampaignIdPosition], row[cnst.CsvCountryPosition], ltvs), nil
+	return types.NewRecord(row[header.campaignId], row[header.country], ltvs), nil
 }
 
 // NewRecordFromJsonStruct creates a new Record from a JSON struct.
 func NewRecordFromJsonStruct(jsonData *types.JsonFileData) *types.Record {
-	return types.NewRecord(jsonData.CampaignId, jsonData.Country, types.LtvCollection{
-		jsonData.Ltv1,
-		jsonData.Ltv2,
-		jsonData.Ltv3,
-		jsonData.Ltv4,
-		jsonData.Ltv5,
-		jsonData.Ltv6,
-		jsonData.Ltv7,
-	})
+	return types.NewRecord(jsonData.CampaignId, jsonData.Country, jsonData.Ltv)
+}
+
+// NewRecordPerUserFromJsonStruct creates a new Record from a JSON struct with LTV normalized per user.
+// JSON cohort LTV is a sum over Users, so each value is divided by the number of users, kept as the record weight
+func NewRecordPerUserFromJsonStruct(jsonData *types.JsonFileData) *types.Record {
+	ltvs := make(types.LtvCollection, len(jsonData.Ltv))
+	for i, ltv := range jsonData.Ltv {
+		ltvs[i] = ltv / float64(jsonData.Users)
+	}
+	return types.NewWeightedRecord(jsonData.CampaignId, jsonData.Country, ltvs, float64(jsonData.Users))
 }
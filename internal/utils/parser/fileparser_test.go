@@ -1,7 +1,6 @@
 package parser
 
 import (
-	cnst "playground/internal/constants"
 	"playground/internal/types"
 	"playground/internal/utils/cerror"
 	"reflect"
@@ -10,33 +9,54 @@ import (
 
 const (
 	UserIdStr     = "123"
+	UserIdName    = "userId"
 	CampaignIdStr = "81855ad8-681d-4d86-91e9-1e00167939cb"
 	CountryStr    = "BY"
 
+	Ltv1Name  = "ltv1"
 	Ltv1Str   = "1.5499697874482206"
 	Ltv1Float = 1.5499697874482206
 
+	Ltv2Name  = "ltv2"
 	Ltv2Str   = "2.252663605698363"
 	Ltv2Float = 2.252663605698363
 
+	Ltv3Name  = "ltv3"
 	Ltv3Str   = "2.2986363323452683"
 	Ltv3Float = 2.2986363323452683
 
+	Ltv4Name  = "ltv4"
 	Ltv4Str   = "2.8840086432719603"
 	Ltv4Float = 2.8840086432719603
 
+	Ltv5Name  = "ltv5"
 	Ltv5Str   = "3.696001808588305"
 	Ltv5Float = 3.696001808588305
 
+	Ltv6Name  = "ltv6"
 	Ltv6Str   = "5.714436511023778"
 	Ltv6Float = 5.714436511023778
 
+	Ltv7Name  = "ltv7"
 	Ltv7Str   = "9.7414418135349954"
 	Ltv7Float = 9.7414418135349954
 
 	Users = 93
 )
 
+var (
+	Header     = []string{"UserId", "CampaignId", "Country", "Ltv1", "Ltv2", "Ltv3", "Ltv4", "Ltv5", "Ltv6", "Ltv7"}
+	LongHeader = append(Header[:len(Header):len(Header)], "Ltv8", "Ltv9", "Ltv10", "Ltv11", "Ltv12", "Ltv13", "Ltv14")
+)
+
+func mustNewCsvHeader(t *testing.T, header []string) *CsvHeader {
+	result, err := NewCsvHeader(header, nil)
+	if err != nil {
+		t.Fatalf("NewCsvHeader() with args %v: unexpected error [%s]", header, err.Error())
+	}
+	return result
+}
+
 func TestNewRecordFromCsvStrings_InvalidInputData(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -61,52 +81,52 @@ func TestNewRecordFromCsvStrings_InvalidInputData(t *testing.T) {
 		},
 		{
 			name:           "ltv1ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, cnst.CsvLtv1Name, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Name, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[3], Value: Ltv1Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 		{
 			name:           "ltv2ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, cnst.CsvLtv2Name, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Name, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[4], Value: Ltv2Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 		{
 			name:           "ltv3ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, cnst.CsvLtv3Name, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Name, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[5], Value: Ltv3Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 		{
 			name:           "ltv4ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, cnst.CsvLtv4Name, Ltv5Str, Ltv6Str, Ltv7Str},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Name, Ltv5Str, Ltv6Str, Ltv7Str},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[6], Value: Ltv4Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 		{
 			name:           "ltv5ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, cnst.CsvLtv5Name, Ltv6Str, Ltv7Str},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Name, Ltv6Str, Ltv7Str},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[7], Value: Ltv5Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 		{
 			name:           "ltv6ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, cnst.CsvLtv6Name, Ltv7Str},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Name, Ltv7Str},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[8], Value: Ltv6Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 		{
 			name:           "ltv7ParseFail",
-			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, cnst.CsvLtv7Name},
+			input:          []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Name},
 			expectedResult: nil,
 			expectedError:  true,
-			errorStr:       cerror.NewCustomError("failed to convert ltv data").Error(),
+			errorStr:       (&cerror.ParseError{Column: Header[9], Value: Ltv7Name, Reason: "failed to convert ltv data"}).Error(),
 		},
 	}
 
@@ -115,7 +135,7 @@ func TestNewRecordFromCsvStrings_InvalidInputData(t *testing.T) {
 			/* ARRANGE */
 
 			/* ACT */
-			result, err := NewRecordFromCsvStrings(testCase.input)
+			result, err := NewRecordFromCsvStrings(testCase.input, mustNewCsvHeader(t, Header))
 
 			/* ASSERT */
 			// Assert expected error
@@ -139,11 +159,11 @@ func TestNewRecordFromCsvStrings_InvalidInputData(t *testing.T) {
 func TestNewRecordFromCsvStrings_ValidInputData(t *testing.T) {
 	/* ARRANGE */
 	input := []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str}
-	invalidUserIdInput := []string{cnst.CsvUserIdName, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str}
+	invalidUserIdInput := []string{UserIdName, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str}
 
 	/* ACT */
-	result, normalInputErr := NewRecordFromCsvStrings(input)
-	expectedResult, invalidUserIdInputErr := NewRecordFromCsvStrings(invalidUserIdInput)
+	result, normalInputErr := NewRecordFromCsvStrings(input, mustNewCsvHeader(t, Header))
+	expectedResult, invalidUserIdInputErr := NewRecordFromCsvStrings(invalidUserIdInput, mustNewCsvHeader(t, Header))
 
 	/* ASSERT */
 	// Assert expected error
@@ -164,8 +184,8 @@ func TestNewRecordFromJsonStruct(t *testing.T) {
 	/* ARRANGE */
 	json := types.JsonFileData{
 		CampaignId: CampaignIdStr, Country: CountryStr,
-		Ltv1: Ltv1Float, Ltv2: Ltv2Float, Ltv3: Ltv3Float, Ltv4: Ltv4Float,
-		Ltv5: Ltv5Float, Ltv6: Ltv6Float, Ltv7: Ltv7Float, Users: Users,
+		Ltv:   []float64{Ltv1Float, Ltv2Float, Ltv3Float, Ltv4Float, Ltv5Float, Ltv6Float, Ltv7Float},
+		Users: Users,
 	}
 	expected := types.NewRecord(CampaignIdStr, CountryStr, types.LtvCollection{
 		Ltv1Float, Ltv2Float, Ltv3Float, Ltv4Float, Ltv5Float, Ltv6Float, Ltv7Float})
@@ -179,3 +199,49 @@ func TestNewRecordFromJsonStruct(t *testing.T) {
 		t.Errorf("NewRecordFromCsvStrings() exp: %+v\ngot: %+v", expected, result)
 	}
 }
+
+func TestNewRecordPerUserFromJsonStruct(t *testing.T) {
+	/* ARRANGE */
+	json := types.JsonFileData{
+		CampaignId: CampaignIdStr, Country: CountryStr,
+		Ltv:   []float64{2, 5, 9},
+		Users: 2,
+	}
+	expected := types.NewWeightedRecord(CampaignIdStr, CountryStr, types.LtvCollection{1, 2.5, 4.5}, 2)
+
+	/* ACT */
+	result := NewRecordPerUserFromJsonStruct(&json)
+
+	/* ASSERT */
+	// Assert result
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("NewRecordPerUserFromJsonStruct() exp: %+v\ngot: %+v", expected, result)
+	}
+	// Assert input data is left untouched
+	if !reflect.DeepEqual(json.Ltv, []float64{2, 5, 9}) {
+		t.Errorf("NewRecordPerUserFromJsonStruct() unexpected input modification: %+v", json.Ltv)
+	}
+}
+
+func TestNewRecordFromCsvStrings_LongLtvCollection(t *testing.T) {
+	/* ARRANGE */
+	input := []string{UserIdStr, CampaignIdStr, CountryStr, Ltv1Str, Ltv2Str, Ltv3Str, Ltv4Str, Ltv5Str, Ltv6Str, Ltv7Str,
+		Ltv7Str, Ltv7Str, Ltv7Str, Ltv7Str, Ltv7Str, Ltv7Str, Ltv7Str}
+	expected := types.NewRecord(CampaignIdStr, CountryStr, types.LtvCollection{
+		Ltv1Float, Ltv2Float, Ltv3Float, Ltv4Float, Ltv5Float, Ltv6Float, Ltv7Float,
+		Ltv7Float, Ltv7Float, Ltv7Float, Ltv7Float, Ltv7Float, Ltv7Float, Ltv7Float})
+
+	/* ACT */
+	result, err := NewRecordFromCsvStrings(input, mustNewCsvHeader(t, LongHeader))
+
+	/* ASSERT */
+	// Assert expected error
+	if err != nil {
+		t.Fatalf("NewRecordFromCsvStrings() with args %v: expected error %v, got %v", input, nil, err)
+	}
+
+	// Assert result
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("NewRecordFromCsvStrings() exp: %+v\ngot: %+v", expected, result)
+	}
+}
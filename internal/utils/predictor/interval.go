@@ -0,0 +1,266 @@
+package predictor
+
+import (
+	"math"
+	"math/rand/v2"
+	cnst "playground/internal/constants"
+	"slices"
+)
+
+// Interval predicts the day value bounds at the confidence level from the daily values and their sampling covariance,
+// day numbers start from 1
+// Interval is the confidence interval of the key mean LTV prediction, that is how precisely the key records determine it
+// It narrows as the key gets more records and includes neither the records spread nor the curve model error
+// Returns NaN bounds if the data isn't enough to estimate the interval
+type Interval func(data []float64, covariance [][]float64, day, confidence float64) (float64, float64)
+
+// LinearExtrapolationInterval returns the least squares line confidence interval of the day value
+func LinearExtrapolationInterval(data []float64, covariance [][]float64, day, confidence float64) (float64, float64) {
+	center, margin := lineMargin(data, covariance, identity, identity, one, day, confidence)
+	return center - margin, center + margin
+}
+
+// PowerLawInterval returns the power law curve confidence interval of the day value
+// Interval is estimated for the ln(y) line, so it isn't symmetric around the predicted value
+// IMPORTANT: Expected positive data values
+func PowerLawInterval(data []float64, covariance [][]float64, day, confidence float64) (float64, float64) {
+	center, margin := lineMargin(data, covariance, math.Log, math.Log, reciprocal, math.Log(day), confidence)
+	return math.Exp(center - margin), math.Exp(center + margin)
+}
+
+// LogarithmicInterval returns the logarithmic curve confidence interval of the day value
+func LogarithmicInterval(data []float64, covariance [][]float64, day, confidence float64) (float64, float64) {
+	center, margin := lineMargin(data, covariance, math.Log, identity, one, math.Log(day), confidence)
+	return center - margin, center + margin
+}
+
+// lineMargin fits the line through the transformed points and returns its value at x and the half width
+// of its confidence interval, the daily values covariance is propagated to the line value by the delta method
+func lineMargin(data []float64, covariance [][]float64, transformX, transformY, derivativeY func(float64) float64, x, confidence float64) (float64, float64) {
+	// line(x) = sum(ci * yi), ci = 1/n + (x - mean(x)) * (xi - mean(x)) / sum((xi - mean(x))^2)
+	// se^2 = sum(gi * gj * cov(i, j)), gi = ci * transformY'(value i)
+	// margin = z * se
+
+	count := len(data)
+	m, b := leastSquares(data, transformX, transformY)
+
+	var sumX float64
+	for i := range data {
+		sumX += transformX(float64(i + 1))
+	}
+	meanX := sumX / float64(count)
+
+	var sxx float64
+	for i := range data {
+		xi := transformX(float64(i + 1))
+		sxx += (xi - meanX) * (xi - meanX)
+	}
+	gradient := make([]float64, 0, count)
+	for i, value := range data {
+		xi := transformX(float64(i + 1))
+		gradient = append(gradient, (1/float64(count)+(x-meanX)*(xi-meanX)/sxx)*derivativeY(value))
+	}
+
+	var variance float64
+	for i := range gradient {
+		for j := range gradient {
+			variance += gradient[i] * gradient[j] * covariance[i][j]
+		}
+	}
+	return m*x + b, normalQuantile((1+confidence)/2) * math.Sqrt(variance)
+}
+
+// one is the identity derivative
+func one(float64) float64 {
+	return 1
+}
+
+// reciprocal is the natural logarithm derivative
+func reciprocal(value float64) float64 {
+	return 1 / value
+}
+
+// normalQuantile returns the p quantile of the standard normal distribution
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// LtvCovariance accumulates the daily weighted cross products of the records LTV values, zero values are skipped
+// It estimates the sampling covariance of the LtvAverages daily averages of the same records without keeping them,
+// so the memory and time it takes depend on the days number only
+// Zero value is ready to use
+type LtvCovariance struct {
+	records int
+	moments [][]crossMoments
+}
+
+// crossMoments holds the sums over records having both days values non zero, w is the record weight
+// weights is sum(w^2), sums is sum(w^2 * first day value), products is sum(w^2 * first day value * second day value)
+type crossMoments struct {
+	weights  float64
+	sums     float64
+	products float64
+}
+
+// AddWeighted adds LTV values of a single aggregated record with the provided weight, zero values are skipped
+func (c *LtvCovariance) AddWeighted(ltv []float64, weight float64) {
+	// Extend collected data up to the longest received ltv collection
+	if len(ltv) > len(c.moments) {
+		for i := range c.moments {
+			c.moments[i] = append(c.moments[i], make([]crossMoments, len(ltv)-len(c.moments))...)
+		}
+		for len(c.moments) < len(ltv) {
+			c.moments = append(c.moments, make([]crossMoments, len(ltv)))
+		}
+	}
+
+	c.records++
+	squared := weight * weight
+	for i, first := range ltv {
+		if first == 0 {
+			continue
+		}
+		for j, second := range ltv {
+			if second == 0 {
+				continue
+			}
+			c.moments[i][j].weights += squared
+			c.moments[i][j].sums += squared * first
+			c.moments[i][j].products += squared * first * second
+		}
+	}
+}
+
+// Covariance returns the sampling covariance matrix of the averages, in the LtvAverages.Averages order
+// Records are taken as independent draws of the key, the covariance is the sandwich estimate of the weighted averages
+// Returns NaN covariance if there are fewer than 2 records, the records spread is unknown then
+// IMPORTANT: Expected the same records added to both averages and the covariance
+func (c *LtvCovariance) Covariance(averages *LtvAverages) [][]float64 {
+	// cov(i, j) = n / (n - 1) * sum(w^2 * (vi - avg(i)) * (vj - avg(j))) / (sum(w of day i) * sum(w of day j))
+
+	days := make([]int, 0, len(averages.weights))
+	for i, weight := range averages.weights {
+		if weight != 0 {
+			days = append(days, i)
+		}
+	}
+	correction := math.NaN()
+	if c.records > 1 {
+		correction = float64(c.records) / float64(c.records-1)
+	}
+
+	covariance := make([][]float64, 0, len(days))
+	for _, i := range days {
+		row := make([]float64, 0, len(days))
+		averageI := averages.sums[i] / averages.weights[i]
+		for _, j := range days {
+			averageJ := averages.sums[j] / averages.weights[j]
+			moments := c.moments[i][j]
+			sum := moments.products - averageJ*moments.sums - averageI*c.moments[j][i].sums + averageI*averageJ*moments.weights
+			row = append(row, correction*sum/(averages.weights[i]*averages.weights[j]))
+		}
+		covariance = append(covariance, row)
+	}
+	return covariance
+}
+
+// Bootstrap resamples the daily values from their sampling distribution, the normal one of the provided covariance,
+// and predicts the days by the curve on each resample
+// Returns the percentile bounds of the finite resampled predictions at the confidence level, NaN if there are none
+func Bootstrap(data []float64, covariance [][]float64, days []float64, confidence float64, curve Curve) ([]float64, []float64) {
+	factor := cholesky(covariance)
+	rng := rand.New(rand.NewPCG(cnst.BootstrapSeed, cnst.BootstrapSeed))
+
+	noise := make([]float64, len(data))
+	resample := make([]float64, len(data))
+	predicted := make([][]float64, len(days))
+	for range cnst.BootstrapResamples {
+		for i := range noise {
+			noise[i] = rng.NormFloat64()
+		}
+		for i, value := range data {
+			resample[i] = value
+			for j, coefficient := range factor[i] {
+				resample[i] += coefficient * noise[j]
+			}
+		}
+		for i, day := range days {
+			if value := curve(resample, day); !math.IsNaN(value) && !math.IsInf(value, 0) {
+				predicted[i] = append(predicted[i], value)
+			}
+		}
+	}
+
+	lower := make([]float64, 0, len(days))
+	upper := make([]float64, 0, len(days))
+	for _, values := range predicted {
+		slices.Sort(values)
+		lower = append(lower, quantile(values, (1-confidence)/2))
+		upper = append(upper, quantile(values, (1+confidence)/2))
+	}
+	return lower, upper
+}
+
+// cholesky returns the lower triangular factor L of the covariance, covariance = L * L^T
+// Daily LTV values are strongly correlated, so the covariance is often singular, dependent days get zero columns then
+func cholesky(covariance [][]float64) [][]float64 {
+	// Pivots below the tolerance relative to the day variance are rounding errors of a singular covariance
+	const tolerance = 1e-12
+
+	factor := make([][]float64, 0, len(covariance))
+	for i := range covariance {
+		row := make([]float64, i+1)
+		factor = append(factor, row)
+		for j := range row {
+			sum := covariance[i][j]
+			for k := 0; k < j; k++ {
+				sum -= row[k] * factor[j][k]
+			}
+			switch {
+			case i == j && sum > tolerance*covariance[i][i]:
+				row[j] = math.Sqrt(sum)
+			case i == j && math.IsNaN(sum):
+				row[j] = math.NaN()
+			case i != j && factor[j][j] != 0:
+				row[j] = sum / factor[j][j]
+			}
+		}
+	}
+	return factor
+}
+
+// quantile returns the q quantile of the sorted values, linearly interpolated between the closest ranks
+// Returns NaN if there are no values
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := q * float64(len(sorted)-1)
+	below := int(math.Floor(rank))
+	if below+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[below] + (rank-float64(below))*(sorted[below+1]-sorted[below])
+}
+
+// Intervals returns the lower and upper confidence bounds of the days predictions at the confidence level
+// Analytic interval of the averages is used if it's provided, the averages are bootstrapped otherwise
+// IMPORTANT: Expected covariance of the averages, see LtvCovariance.Covariance
+func Intervals(averages []float64, covariance [][]float64, days []uint, confidence float64, curve Curve, interval Interval) ([]float64, []float64) {
+	dayValues := make([]float64, 0, len(days))
+	for _, day := range days {
+		dayValues = append(dayValues, float64(day))
+	}
+	if interval == nil {
+		return Bootstrap(averages, covariance, dayValues, confidence, curve)
+	}
+
+	lower := make([]float64, 0, len(days))
+	upper := make([]float64, 0, len(days))
+	for _, day := range dayValues {
+		low, up := interval(averages, covariance, day, confidence)
+		lower = append(lower, low)
+		upper = append(upper, up)
+	}
+	return lower, upper
+}
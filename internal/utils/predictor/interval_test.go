@@ -0,0 +1,358 @@
+package predictor
+
+import (
+	"math"
+	"testing"
+)
+
+// diagonal returns the covariance of independent daily values having the same variance
+func diagonal(days int, variance float64) [][]float64 {
+	covariance := make([][]float64, 0, days)
+	for i := 0; i < days; i++ {
+		row := make([]float64, days)
+		row[i] = variance
+		covariance = append(covariance, row)
+	}
+	return covariance
+}
+
+// correlated returns the covariance of perfectly correlated daily values having the same variance
+func correlated(days int, variance float64) [][]float64 {
+	covariance := make([][]float64, 0, days)
+	for i := 0; i < days; i++ {
+		row := make([]float64, 0, days)
+		for j := 0; j < days; j++ {
+			row = append(row, variance)
+		}
+		covariance = append(covariance, row)
+	}
+	return covariance
+}
+
+func TestIntervalModels(t *testing.T) {
+	tests := []struct {
+		name          string
+		interval      Interval
+		data          []float64
+		covariance    [][]float64
+		day           float64
+		expectedLower float64
+		expectedUpper float64
+	}{
+		// Line 0.8x + 0.5 predicts 4.5 on day 5, line coefficients of the days are -0.5, 0, 0.5, 1
+		// Independent days of variance 0.25 give standard error sqrt(0.25 * 1.5), z(0.975) = 1.959964
+		{
+			name:          "linearExtrapolationIndependentDays",
+			interval:      LinearExtrapolationInterval,
+			data:          []float64{1, 3, 2, 4},
+			covariance:    diagonal(4, 0.25),
+			day:           5,
+			expectedLower: 4.5 - math.Sqrt(0.375)*1.959963984540054,
+			expectedUpper: 4.5 + math.Sqrt(0.375)*1.959963984540054,
+		},
+		// Line coefficients sum up to 1, so perfectly correlated days give the daily standard error 0.5
+		{
+			name:          "linearExtrapolationCorrelatedDays",
+			interval:      LinearExtrapolationInterval,
+			data:          []float64{1, 3, 2, 4},
+			covariance:    correlated(4, 0.25),
+			day:           5,
+			expectedLower: 4.5 - 0.5*1.959963984540054,
+			expectedUpper: 4.5 + 0.5*1.959963984540054,
+		},
+		{
+			name:          "linearExtrapolationNoSpread",
+			interval:      LinearExtrapolationInterval,
+			data:          []float64{1, 2, 3, 4, 5, 6, 7},
+			covariance:    diagonal(7, 0),
+			day:           60,
+			expectedLower: 60,
+			expectedUpper: 60,
+		},
+		{
+			name:          "powerLawNoSpread",
+			interval:      PowerLawInterval,
+			data:          curve(7, func(x float64) float64 { return 2 * math.Sqrt(x) }),
+			covariance:    diagonal(7, 0),
+			day:           64,
+			expectedLower: 16,
+			expectedUpper: 16,
+		},
+		// ln(y) daily variance is the relative variance of y, constant curve is scaled by exp(-+z * 0.1)
+		{
+			name:          "powerLawCorrelatedDays",
+			interval:      PowerLawInterval,
+			data:          []float64{2, 2, 2},
+			covariance:    correlated(3, 0.04),
+			day:           30,
+			expectedLower: 2 * math.Exp(-0.1*1.959963984540054),
+			expectedUpper: 2 * math.Exp(0.1*1.959963984540054),
+		},
+		{
+			name:          "logarithmicNoSpread",
+			interval:      LogarithmicInterval,
+			data:          curve(7, func(x float64) float64 { return 3 + 2*math.Log(x) }),
+			covariance:    diagonal(7, 0),
+			day:           60,
+			expectedLower: 3 + 2*math.Log(60),
+			expectedUpper: 3 + 2*math.Log(60),
+		},
+		{
+			name:          "unknownSpread",
+			interval:      LinearExtrapolationInterval,
+			data:          []float64{1, 2, 3},
+			covariance:    correlated(3, math.NaN()),
+			day:           60,
+			expectedLower: math.NaN(),
+			expectedUpper: math.NaN(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			lower, upper := testCase.interval(testCase.data, testCase.covariance, testCase.day, 0.95)
+
+			/* ASSERT */
+			if math.IsNaN(testCase.expectedLower) {
+				if !math.IsNaN(lower) || !math.IsNaN(upper) {
+					t.Fatalf("Interval() exp: [NaN, NaN] got: [%v, %v]", lower, upper)
+				}
+				return
+			}
+			if !(math.Abs(lower-testCase.expectedLower) <= Accuracy) || !(math.Abs(upper-testCase.expectedUpper) <= Accuracy) {
+				t.Fatalf("Interval() exp: [%v, %v] got: [%v, %v]", testCase.expectedLower, testCase.expectedUpper, lower, upper)
+			}
+		})
+	}
+}
+
+func TestNormalQuantile(t *testing.T) {
+	// Reference values of the standard normal distribution table
+	tests := []struct {
+		p        float64
+		expected float64
+	}{
+		{p: 0.5, expected: 0},
+		{p: 0.95, expected: 1.644854},
+		{p: 0.975, expected: 1.959964},
+		{p: 0.995, expected: 2.575829},
+	}
+
+	for _, testCase := range tests {
+		result := normalQuantile(testCase.p)
+		if math.Abs(result-testCase.expected) > 1e-6 {
+			t.Errorf("normalQuantile() : p %v expected %v got %v", testCase.p, testCase.expected, result)
+		}
+	}
+}
+
+func TestLtvCovariance(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  [][]float64
+		weights  []float64
+		expected [][]float64
+	}{
+		// Averages are 2 and 4, sums of squared deviations are 2, 8 and of the cross deviations 4
+		// Divided by the days weights 2 * 2 and multiplied by the correction 2 / (2 - 1)
+		{
+			name:     "unweightedRecords",
+			records:  [][]float64{{1, 2}, {3, 6}},
+			weights:  []float64{1, 1},
+			expected: [][]float64{{1, 2}, {2, 4}},
+		},
+		// Averages are (1 + 3 * 3) / 4 = 2.5 and (2 + 3 * 10) / 4 = 8, deviations -1.5, 0.5 and -6, 2
+		// Weighted squared sums are 1 * 2.25 + 9 * 0.25 = 4.5, 36 + 9 * 4 = 72 and cross 9 + 9 * 1 = 18, weights are 4 * 4
+		{
+			name:     "weightedRecords",
+			records:  [][]float64{{1, 2}, {3, 10}},
+			weights:  []float64{1, 3},
+			expected: [][]float64{{2 * 4.5 / 16, 2 * 18.0 / 16}, {2 * 18.0 / 16, 2 * 72.0 / 16}},
+		},
+		// Second day is known from the second record only, the first day is skipped as it has no values
+		{
+			name:     "zeroValuesSkipped",
+			records:  [][]float64{{0, 1, 0}, {0, 3, 2}},
+			weights:  []float64{1, 1},
+			expected: [][]float64{{1, 0}, {0, 0}},
+		},
+		{
+			name:     "singleRecord",
+			records:  [][]float64{{1, 2}},
+			weights:  []float64{1},
+			expected: [][]float64{{math.NaN(), math.NaN()}, {math.NaN(), math.NaN()}},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			averages := LtvAverages{}
+			covariance := LtvCovariance{}
+
+			/* ACT */
+			for i, record := range testCase.records {
+				averages.AddWeighted(record, testCase.weights[i])
+				covariance.AddWeighted(record, testCase.weights[i])
+			}
+			result := covariance.Covariance(&averages)
+
+			/* ASSERT */
+			if len(result) != len(testCase.expected) {
+				t.Fatalf("Covariance() : expected %v, got %v", testCase.expected, result)
+			}
+			for i, row := range testCase.expected {
+				for j, expected := range row {
+					if math.IsNaN(expected) != math.IsNaN(result[i][j]) || math.Abs(result[i][j]-expected) > Accuracy {
+						t.Fatalf("Covariance() : expected %v, got %v", testCase.expected, result)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLtvCovariance_MoreRecords(t *testing.T) {
+	/* ARRANGE */
+	// Same records repeated have the same averages and spread, more of them determine the averages more precisely
+	covariance := func(repeat int) [][]float64 {
+		averages := LtvAverages{}
+		covariance := LtvCovariance{}
+		for range repeat {
+			for _, record := range [][]float64{{1, 2}, {3, 6}} {
+				averages.AddWeighted(record, 1)
+				covariance.AddWeighted(record, 1)
+			}
+		}
+		return covariance.Covariance(&averages)
+	}
+
+	/* ACT */
+	few := covariance(1)
+	many := covariance(100)
+
+	/* ASSERT */
+	// Variance of the averages is the records variance over the records number, 200 / 199 over 200 records
+	if !(math.Abs(many[0][0]-1.0/199) <= Accuracy) || !(many[1][1] < few[1][1]) {
+		t.Fatalf("Covariance() : expected variance shrinking with the records number, got %v and %v", few, many)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	data := []float64{1, 3, 2, 4}
+	days := []float64{10, 20}
+
+	t.Run("noSpread", func(t *testing.T) {
+		/* ACT */
+		lower, upper := Bootstrap(data, diagonal(4, 0), days, 0.9, LinearExtrapolation)
+
+		/* ASSERT */
+		for i, day := range days {
+			predicted := LinearExtrapolation(data, day)
+			if !(math.Abs(lower[i]-predicted) <= Accuracy) || !(math.Abs(upper[i]-predicted) <= Accuracy) {
+				t.Fatalf("Bootstrap() exp: [%v, %v] got: [%v, %v]", predicted, predicted, lower[i], upper[i])
+			}
+		}
+	})
+
+	t.Run("closeToAnalyticInterval", func(t *testing.T) {
+		/* ARRANGE */
+		// Linear curve of normal daily values is normal, so the bootstrap approximates the analytic interval
+		covariance := [][]float64{{0.25, 0.2, 0.2, 0.2}, {0.2, 0.36, 0.3, 0.3}, {0.2, 0.3, 0.49, 0.4}, {0.2, 0.3, 0.4, 0.64}}
+
+		/* ACT */
+		lower, upper := Bootstrap(data, covariance, days, 0.9, LinearExtrapolation)
+
+		/* ASSERT */
+		for i, day := range days {
+			expectedLower, expectedUpper := LinearExtrapolationInterval(data, covariance, day, 0.9)
+			width := expectedUpper - expectedLower
+			if !(math.Abs(lower[i]-expectedLower) <= 0.05*width) || !(math.Abs(upper[i]-expectedUpper) <= 0.05*width) {
+				t.Fatalf("Bootstrap() day %v exp about: [%v, %v] got: [%v, %v]", day, expectedLower, expectedUpper, lower[i], upper[i])
+			}
+		}
+	})
+
+	t.Run("unknownSpread", func(t *testing.T) {
+		/* ACT */
+		lower, upper := Bootstrap(data, correlated(4, math.NaN()), days, 0.9, LinearExtrapolation)
+
+		/* ASSERT */
+		if !math.IsNaN(lower[0]) || !math.IsNaN(upper[0]) {
+			t.Fatalf("Bootstrap() exp: [NaN, NaN] got: [%v, %v]", lower[0], upper[0])
+		}
+	})
+}
+
+func TestCholesky(t *testing.T) {
+	tests := []struct {
+		name       string
+		covariance [][]float64
+	}{
+		{name: "independentDays", covariance: diagonal(3, 4)},
+		{name: "correlatedDays", covariance: [][]float64{{4, 2, 2}, {2, 5, 3}, {2, 3, 6}}},
+		{name: "singularCovariance", covariance: correlated(3, 4)},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			factor := cholesky(testCase.covariance)
+
+			/* ASSERT */
+			// Factor times its transpose is the covariance
+			for i, row := range testCase.covariance {
+				for j, expected := range row {
+					var value float64
+					for k := 0; k <= min(i, j); k++ {
+						value += factor[i][k] * factor[j][k]
+					}
+					if !(math.Abs(value-expected) <= Accuracy) {
+						t.Fatalf("cholesky() : expected L * L^T %v, got factor %v", testCase.covariance, factor)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	averages := []float64{1, 3, 2, 4}
+	covariance := correlated(4, 0.25)
+
+	tests := []struct {
+		name          string
+		interval      Interval
+		expectedLower float64
+		expectedUpper float64
+	}{
+		{
+			name:          "analyticInterval",
+			interval:      LinearExtrapolationInterval,
+			expectedLower: 4.5 - 0.5*1.959963984540054,
+			expectedUpper: 4.5 + 0.5*1.959963984540054,
+		},
+		// Perfectly correlated days shift the line by the same normal value, its percentiles are the analytic bounds
+		{
+			name:          "noAnalyticInterval",
+			interval:      nil,
+			expectedLower: 4.5 - 0.5*1.959963984540054,
+			expectedUpper: 4.5 + 0.5*1.959963984540054,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			lower, upper := Intervals(averages, covariance, []uint{5}, 0.95, LinearExtrapolation, testCase.interval)
+
+			/* ASSERT */
+			// Bootstrap bounds are estimated on the limited resamples number, 5% of the interval width is close enough
+			width := testCase.expectedUpper - testCase.expectedLower
+			if !(math.Abs(lower[0]-testCase.expectedLower) <= 0.05*width) || !(math.Abs(upper[0]-testCase.expectedUpper) <= 0.05*width) {
+				t.Fatalf("Intervals() exp: [%v, %v] got: [%v, %v]", testCase.expectedLower, testCase.expectedUpper, lower[0], upper[0])
+			}
+		})
+	}
+}
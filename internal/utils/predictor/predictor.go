@@ -1,13 +1,35 @@
 package predictor
 
+import "math"
+
 func LinearExtrapolation(data []float64, day float64) float64 {
+	m, b := leastSquares(data, identity, identity)
+	return m*day + b
+}
+
+// PowerLaw fits y = a * x^b curve as the line ln(y) = ln(a) + b * ln(x) and predicts the day value
+// IMPORTANT: Expected positive data values
+func PowerLaw(data []float64, day float64) float64 {
+	b, lnA := leastSquares(data, math.Log, math.Log)
+	return math.Exp(lnA) * math.Pow(day, b)
+}
+
+// Logarithmic fits y = a + b * ln(x) curve as the line in ln(x) and predicts the day value
+func Logarithmic(data []float64, day float64) float64 {
+	b, a := leastSquares(data, math.Log, identity)
+	return a + b*math.Log(day)
+}
+
+// leastSquares fits the line y = m * x + b through the transformed points, x is the day number starting from 1
+func leastSquares(data []float64, transformX, transformY func(float64) float64) (float64, float64) {
 	// m = [counted_values * sum(x * y) - sum(x) * sum(y)] / [counted_values * sum(x * x) - sum(x) * sum(x)]
 	// b = sum(y) - m * sum(y)
 	// y = m * x + b
 
 	var sumX, sumY, sumXY, sumXX float64
-	for i, y := range data {
-		x := float64(i + 1)
+	for i, value := range data {
+		x := transformX(float64(i + 1))
+		y := transformY(value)
 		sumX += x
 		sumY += y
 		sumXY += x * y
@@ -17,12 +39,148 @@ func LinearExtrapolation(data []float64, day float64) float64 {
 	m := (count*sumXY - sumX*sumY) / (count*sumXX - sumX*sumX)
 	b := (sumY - m*sumX) / count
 
-	return m*day + b
+	return m, b
+}
+
+// identity keeps the value untransformed
+func identity(value float64) float64 {
+	return value
 }
 
-// IMPORTANT: Expected len(data) != 0
+// Average extends the mean daily growth of the data to the day, returns NaN if there is no data
 func Average(data []float64, day float64) float64 {
 	dataLen := len(data)
+	if dataLen == 0 {
+		return math.NaN()
+	}
 	delta := (data[dataLen-1] - data[0]) / float64(dataLen)
 	return data[dataLen-1] + delta*float64(day-float64(dataLen-1))
 }
+
+// LtvAverages accumulates daily weighted sums of non zero LTV values and the sums of the values weights
+// Zero value is ready to use
+type LtvAverages struct {
+	sums    []float64
+	weights []float64
+}
+
+// Add adds LTV values of a single aggregated record with weight 1, zero values are skipped
+func (a *LtvAverages) Add(ltv []float64) {
+	a.AddWeighted(ltv, 1)
+}
+
+// AddWeighted adds LTV values of a single aggregated record with the provided weight, zero values are skipped
+func (a *LtvAverages) AddWeighted(ltv []float64, weight float64) {
+	// Extend collected data up to the longest received ltv collection
+	for len(a.sums) < len(ltv) {
+		a.sums = append(a.sums, 0)
+		a.weights = append(a.weights, 0)
+	}
+
+	for i, value := range ltv {
+		if value == 0 {
+			continue
+		}
+		a.sums[i] += value * weight
+		a.weights[i] += weight
+	}
+}
+
+// Averages returns weighted averages of the days having non zero values, in the days order
+func (a *LtvAverages) Averages() []float64 {
+	return a.AveragesUntil(len(a.sums))
+}
+
+// AveragesUntil returns weighted averages of the first days having non zero values, in the days order
+func (a *LtvAverages) AveragesUntil(days int) []float64 {
+	days = min(days, len(a.sums))
+	averages := make([]float64, 0, days)
+	for i, sum := range a.sums[:days] {
+		if a.weights[i] != 0 {
+			averages = append(averages, sum/a.weights[i])
+		}
+	}
+	return averages
+}
+
+// Average returns the weighted average of the day, day numbers start from 1
+// Returns false if the day has no non zero values
+func (a *LtvAverages) Average(day int) (float64, bool) {
+	if day < 1 || day > len(a.sums) || a.weights[day-1] == 0 {
+		return 0, false
+	}
+	return a.sums[day-1] / a.weights[day-1], true
+}
+
+// Curve predicts the day value from the daily values, day numbers start from 1
+type Curve func(data []float64, day float64) float64
+
+// Model is a named prediction curve
+// Interval is the analytic confidence interval of the curve, nil if the curve has none and the interval is bootstrapped
+type Model struct {
+	Name     string
+	Curve    Curve
+	Interval Interval
+}
+
+// Predict predicts the day value by the model curve, returns NaN if there are no daily values to fit the curve on
+func (m Model) Predict(data []float64, day float64) float64 {
+	if len(data) == 0 {
+		return math.NaN()
+	}
+	return m.Curve(data, day)
+}
+
+// Metric scores predicted values against the actual ones, lower score is better
+type Metric func(actual, predicted []float64) float64
+
+// MeanAbsoluteError returns the mean of absolute prediction errors
+// IMPORTANT: Expected len(actual) == len(predicted) != 0
+func MeanAbsoluteError(actual, predicted []float64) float64 {
+	var sum float64
+	for i := range actual {
+		sum += math.Abs(predicted[i] - actual[i])
+	}
+	return sum / float64(len(actual))
+}
+
+// MeanAbsolutePercentageError returns the mean of absolute prediction errors relative to the actual values, in percents
+// IMPORTANT: Expected len(actual) == len(predicted) != 0
+func MeanAbsolutePercentageError(actual, predicted []float64) float64 {
+	var sum float64
+	for i := range actual {
+		sum += math.Abs((predicted[i] - actual[i]) / actual[i])
+	}
+	return 100 * sum / float64(len(actual))
+}
+
+// RootMeanSquaredError returns the square root of the mean of squared prediction errors
+// IMPORTANT: Expected len(actual) == len(predicted) != 0
+func RootMeanSquaredError(actual, predicted []float64) float64 {
+	var sum float64
+	for i := range actual {
+		sum += (predicted[i] - actual[i]) * (predicted[i] - actual[i])
+	}
+	return math.Sqrt(sum / float64(len(actual)))
+}
+
+// MeanError returns the mean of prediction errors, positive bias means overestimation
+// IMPORTANT: Expected len(actual) == len(predicted) != 0
+func MeanError(actual, predicted []float64) float64 {
+	var sum float64
+	for i := range actual {
+		sum += predicted[i] - actual[i]
+	}
+	return sum / float64(len(actual))
+}
+
+// Holdout fits the curve on the data without the last holdout values and scores its predictions of them
+// IMPORTANT: Expected 0 < holdout < len(data)
+func Holdout(data []float64, holdout int, curve Curve, metric Metric) float64 {
+	train, actual := data[:len(data)-holdout], data[len(data)-holdout:]
+	predicted := make([]float64, 0, holdout)
+	for i := range actual {
+		predicted = append(predicted, curve(train, float64(len(train)+i+1)))
+	}
+	return metric(actual, predicted)
+}
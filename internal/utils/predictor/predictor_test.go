@@ -2,6 +2,7 @@ package predictor
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -54,4 +55,253 @@ func TestAverage(t *testing.T) {
 			t.Errorf("Average() : input %v expected %v got %v", testCase.data, testCase.expected, result)
 		}
 	}
+	if result := Average(nil, 60); !math.IsNaN(result) {
+		t.Errorf("Average() : empty input expected NaN got %v", result)
+	}
+}
+
+// curve returns the first days values of the provided curve, day numbers start from 1
+func curve(days int, f func(x float64) float64) []float64 {
+	data := make([]float64, 0, days)
+	for i := 1; i <= days; i++ {
+		data = append(data, f(float64(i)))
+	}
+	return data
+}
+
+func TestPowerLaw(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		day      float64
+		expected float64
+	}{
+		{
+			name:     "squareRoot",
+			data:     curve(7, func(x float64) float64 { return 2 * math.Sqrt(x) }),
+			day:      60,
+			expected: 2 * math.Sqrt(60),
+		},
+		{
+			name:     "flattening",
+			data:     curve(7, func(x float64) float64 { return 1.5 * math.Pow(x, 0.3) }),
+			day:      90,
+			expected: 1.5 * math.Pow(90, 0.3),
+		},
+		{
+			name:     "linear",
+			data:     []float64{1, 2, 3, 4, 5, 6, 7},
+			day:      100,
+			expected: 100,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			result := PowerLaw(testCase.data, testCase.day)
+
+			/* ASSERT */
+			if math.Abs(result-testCase.expected) > Accuracy {
+				t.Fatalf("PowerLaw() : input %v expected %v got %v", testCase.data, testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestLogarithmic(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		day      float64
+		expected float64
+	}{
+		{
+			name:     "increasing",
+			data:     curve(7, func(x float64) float64 { return 3 + 2*math.Log(x) }),
+			day:      60,
+			expected: 3 + 2*math.Log(60),
+		},
+		{
+			name:     "decreasing",
+			data:     curve(10, func(x float64) float64 { return 10 - 0.5*math.Log(x) }),
+			day:      180,
+			expected: 10 - 0.5*math.Log(180),
+		},
+		{
+			name:     "constant",
+			data:     []float64{4, 4, 4, 4},
+			day:      30,
+			expected: 4,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			result := Logarithmic(testCase.data, testCase.day)
+
+			/* ASSERT */
+			if math.Abs(result-testCase.expected) > Accuracy {
+				t.Fatalf("Logarithmic() : input %v expected %v got %v", testCase.data, testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestLtvAverages(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     [][]float64
+		expected []float64
+	}{
+		{name: "noData", data: nil, expected: []float64{}},
+		{name: "zeroValuesSkipped", data: [][]float64{{7, 0, 0}, {1, 8, 0}, {1, 4, 9}}, expected: []float64{3, 6, 9}},
+		{name: "differentLength", data: [][]float64{{2}, {4, 6}}, expected: []float64{3, 6}},
+		{name: "emptyDaysSkipped", data: [][]float64{{2, 0, 4}}, expected: []float64{2, 4}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			averages := LtvAverages{}
+
+			/* ACT */
+			for _, ltv := range testCase.data {
+				averages.Add(ltv)
+			}
+			result := averages.Averages()
+
+			/* ASSERT */
+			if !reflect.DeepEqual(result, testCase.expected) {
+				t.Fatalf("Averages() : expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestLtvAverages_AddWeighted(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     [][]float64
+		weights  []float64
+		expected []float64
+	}{
+		// Cohort of 3 users weighs three times as much as a single user
+		{name: "weightedAverages", data: [][]float64{{1, 2}, {5, 6}}, weights: []float64{3, 1}, expected: []float64{2, 3}},
+		{name: "zeroValuesSkipped", data: [][]float64{{1, 0}, {4, 6}}, weights: []float64{2, 1}, expected: []float64{2, 6}},
+		{name: "unitWeights", data: [][]float64{{1, 2}, {3, 4}}, weights: []float64{1, 1}, expected: []float64{2, 3}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			averages := LtvAverages{}
+
+			/* ACT */
+			for i, ltv := range testCase.data {
+				averages.AddWeighted(ltv, testCase.weights[i])
+			}
+			result := averages.Averages()
+
+			/* ASSERT */
+			if !reflect.DeepEqual(result, testCase.expected) {
+				t.Fatalf("Averages() : expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestLtvAverages_Days(t *testing.T) {
+	/* ARRANGE */
+	averages := LtvAverages{}
+	averages.Add([]float64{2, 0, 4, 8})
+	averages.Add([]float64{4, 0, 6})
+
+	/* ACT */
+	until := averages.AveragesUntil(3)
+	untilAll := averages.AveragesUntil(10)
+	first, firstFound := averages.Average(1)
+	_, emptyFound := averages.Average(2)
+	last, lastFound := averages.Average(4)
+	_, outFound := averages.Average(5)
+
+	/* ASSERT */
+	if !reflect.DeepEqual(until, []float64{3, 5}) || !reflect.DeepEqual(untilAll, []float64{3, 5, 8}) {
+		t.Fatalf("AveragesUntil() : expected [3 5] and [3 5 8], got %v and %v", until, untilAll)
+	}
+	if !firstFound || first != 3 || !lastFound || last != 8 {
+		t.Fatalf("Average() : expected 3 and 8, got %v %v and %v %v", first, firstFound, last, lastFound)
+	}
+	if emptyFound || outFound {
+		t.Fatalf("Average() : expected empty and out of range days not found, got %v and %v", emptyFound, outFound)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name         string
+		actual       []float64
+		predicted    []float64
+		expectedMae  float64
+		expectedMape float64
+		expectedRmse float64
+		expectedBias float64
+	}{
+		{name: "exact", actual: []float64{1, 2, 3}, predicted: []float64{1, 2, 3}, expectedMae: 0, expectedMape: 0, expectedRmse: 0, expectedBias: 0},
+		{name: "overAndUnder", actual: []float64{10, 20}, predicted: []float64{12, 15}, expectedMae: 3.5, expectedMape: 22.5, expectedRmse: math.Sqrt(14.5), expectedBias: -1.5},
+		{name: "negativeActual", actual: []float64{-4}, predicted: []float64{-2}, expectedMae: 2, expectedMape: 50, expectedRmse: 2, expectedBias: 2},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			mae := MeanAbsoluteError(testCase.actual, testCase.predicted)
+			mape := MeanAbsolutePercentageError(testCase.actual, testCase.predicted)
+			rmse := RootMeanSquaredError(testCase.actual, testCase.predicted)
+			bias := MeanError(testCase.actual, testCase.predicted)
+
+			/* ASSERT */
+			if math.Abs(mae-testCase.expectedMae) > Accuracy {
+				t.Errorf("MeanAbsoluteError() : expected %v, got %v", testCase.expectedMae, mae)
+			}
+			if math.Abs(mape-testCase.expectedMape) > Accuracy {
+				t.Errorf("MeanAbsolutePercentageError() : expected %v, got %v", testCase.expectedMape, mape)
+			}
+			if math.Abs(rmse-testCase.expectedRmse) > Accuracy {
+				t.Errorf("RootMeanSquaredError() : expected %v, got %v", testCase.expectedRmse, rmse)
+			}
+			if math.Abs(bias-testCase.expectedBias) > Accuracy {
+				t.Errorf("MeanError() : expected %v, got %v", testCase.expectedBias, bias)
+			}
+		})
+	}
+}
+
+func TestHoldout(t *testing.T) {
+	linear := []float64{1, 2, 3, 4, 5, 6, 7}
+	tests := []struct {
+		name     string
+		data     []float64
+		holdout  int
+		curve    Curve
+		expected float64
+	}{
+		{name: "exactFit", data: linear, holdout: 2, curve: LinearExtrapolation, expected: 0},
+		{name: "powerLawCurve", data: curve(7, func(x float64) float64 { return 2 * math.Sqrt(x) }), holdout: 3, curve: PowerLaw, expected: 0},
+		// Constant curve predicts 5 for the held out 6 and 7
+		{name: "constantCurve", data: linear, holdout: 2, curve: func(data []float64, day float64) float64 { return data[len(data)-1] }, expected: 1.5},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ACT */
+			result := Holdout(testCase.data, testCase.holdout, testCase.curve, MeanAbsoluteError)
+
+			/* ASSERT */
+			if math.Abs(result-testCase.expected) > Accuracy {
+				t.Fatalf("Holdout() : expected %v, got %v", testCase.expected, result)
+			}
+		})
+	}
 }
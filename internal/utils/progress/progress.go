@@ -0,0 +1,100 @@
+package progress
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// trackerKey is the context key of the progress tracker
+type trackerKey struct{}
+
+// Stage represents progress of a single pipeline stage: the number of processed items and whether it finished
+// nil Stage ignores updates, so runners could report progress without a tracker, it is safe for concurrent use
+type Stage struct {
+	name      string
+	unit      string
+	processed atomic.Uint64
+	finished  atomic.Bool
+}
+
+// Inc increments the number of processed items
+func (s *Stage) Inc() {
+	if s != nil {
+		s.processed.Add(1)
+	}
+}
+
+// Finish marks stage as finished
+func (s *Stage) Finish() {
+	if s != nil {
+		s.finished.Store(true)
+	}
+}
+
+// Stage getters
+func (s *Stage) Name() string      { return s.name }
+func (s *Stage) Unit() string      { return s.unit }
+func (s *Stage) Processed() uint64 { return s.processed.Load() }
+func (s *Stage) Finished() bool    { return s.finished.Load() }
+
+// String returns stage progress representation, e.g. "datasource: 100 records"
+func (s *Stage) String() string {
+	return fmt.Sprintf("%s: %d %s", s.name, s.Processed(), s.unit)
+}
+
+// Tracker holds progress of the pipeline stages in the pipeline order
+// Stages are added before the run, then Tracker is safe for concurrent use
+type Tracker struct {
+	stages []*Stage
+}
+
+// NewTracker initializes and returns an empty Tracker
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Add adds stage with the name, unit names the items the stage processes
+func (t *Tracker) Add(name string, unit string) *Stage {
+	stage := &Stage{name: name, unit: unit}
+	t.stages = append(t.stages, stage)
+	return stage
+}
+
+// Stage returns stage with the name, nil if there is no such stage or the tracker is nil
+func (t *Tracker) Stage(name string) *Stage {
+	if t == nil {
+		return nil
+	}
+	for _, stage := range t.stages {
+		if stage.name == name {
+			return stage
+		}
+	}
+	return nil
+}
+
+// Running returns the first stage in the pipeline order which isn't finished, nil if all of them finished
+// Upstream stage still running usually holds up the downstream ones
+func (t *Tracker) Running() *Stage {
+	if t == nil {
+		return nil
+	}
+	for _, stage := range t.stages {
+		if !stage.Finished() {
+			return stage
+		}
+	}
+	return nil
+}
+
+// NewContext returns ctx copy carrying the tracker, nil tracker hides the parent one
+func NewContext(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, trackerKey{}, t)
+}
+
+// FromContext returns ctx tracker, nil if there is no tracker
+func FromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
@@ -0,0 +1,65 @@
+package progress
+
+import (
+	"context"
+	cnst "playground/internal/constants"
+	"testing"
+)
+
+func TestTracker_Running(t *testing.T) {
+	tests := []struct {
+		name     string
+		finished []string
+		expected string
+	}{
+		{name: "noneFinished", finished: nil, expected: cnst.DataSourceStage},
+		{name: "upstreamFinished", finished: []string{cnst.DataSourceStage}, expected: cnst.AggregatorStage},
+		{name: "downstreamFinished", finished: []string{cnst.AggregatorStage}, expected: cnst.DataSourceStage},
+		{name: "allFinished", finished: []string{cnst.DataSourceStage, cnst.AggregatorStage}, expected: ""},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			tracker := NewTracker()
+			tracker.Add(cnst.DataSourceStage, "records")
+			tracker.Add(cnst.AggregatorStage, "records")
+			for _, name := range testCase.finished {
+				tracker.Stage(name).Finish()
+			}
+
+			/* ACT */
+			running := tracker.Running()
+
+			/* ASSERT */
+			name := ""
+			if running != nil {
+				name = running.Name()
+			}
+			if name != testCase.expected {
+				t.Fatalf("Running() : expected %q, got %q", testCase.expected, name)
+			}
+		})
+	}
+}
+
+func TestTracker_Context(t *testing.T) {
+	/* ARRANGE */
+	tracker := NewTracker()
+	tracker.Add(cnst.DataSourceStage, "records")
+	ctx := NewContext(context.Background(), tracker)
+
+	/* ACT */
+	stage := FromContext(ctx).Stage(cnst.DataSourceStage)
+	stage.Inc()
+	stage.Inc()
+	// Hidden tracker and unknown stage ignore updates
+	FromContext(NewContext(ctx, nil)).Stage(cnst.DataSourceStage).Inc()
+	FromContext(ctx).Stage(cnst.PredictorStage).Inc()
+	FromContext(context.Background()).Stage(cnst.DataSourceStage).Finish()
+
+	/* ASSERT */
+	if stage.String() != "datasource: 2 records" || stage.Finished() {
+		t.Fatalf("Stage() : unexpected progress %s, finished %v", stage, stage.Finished())
+	}
+}
@@ -0,0 +1,131 @@
+package rejects
+
+import (
+	"encoding/csv"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"strconv"
+	"sync"
+)
+
+// rejectsHeader is the header of the rejects file
+var rejectsHeader = []string{"file", "line", "column", "reason"}
+
+// Handler decides what happens to invalid input rows according to the on-error mode
+// In fail mode the row error aborts the run, in skip mode the row is dropped,
+// in quarantine mode the row is dropped and described in the rejects file
+// Skipped rows above maxErrors abort the run, zero maxErrors means no limit
+// nil Handler behaves as fail mode, it is safe for concurrent use
+type Handler struct {
+	mode        string
+	maxErrors   uint
+	rejectsPath string
+
+	mu     sync.Mutex
+	count  uint
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewHandler initializes and returns Handler
+// Returns error if mode is unknown
+func NewHandler(mode string, maxErrors uint, rejectsPath string) (*Handler, error) {
+	switch mode {
+	case cnst.OnErrorFail, cnst.OnErrorSkip:
+	case cnst.OnErrorQuarantine:
+		if rejectsPath == "" {
+			return nil, cerror.NewConfigError(cnst.CliRejectsParam, "", "invalid rejects file path")
+		}
+	default:
+		return nil, cerror.NewConfigError(cnst.CliOnErrorParam, mode, fmt.Sprintf("%q invalid on-error parameter", mode))
+	}
+	return &Handler{mode: mode, maxErrors: maxErrors, rejectsPath: rejectsPath}, nil
+}
+
+// Handle processes invalid row error
+// Returns nil if the row should be skipped and reading continued, otherwise error to abort the run with
+func (h *Handler) Handle(rowErr *cerror.ParseError) error {
+	if h == nil || h.mode == cnst.OnErrorFail {
+		return rowErr
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count++
+	if h.maxErrors > 0 && h.count > h.maxErrors {
+		log.Warning(rowErr.Error())
+		return &cerror.ParseError{
+			Reason: fmt.Sprintf("%d invalid rows exceed %q threshold", h.count, cnst.CliMaxErrorsParam),
+			Err:    rowErr,
+		}
+	}
+
+	if h.mode == cnst.OnErrorQuarantine {
+		if err := h.reject(rowErr); err != nil {
+			return err
+		}
+	}
+	log.Debug(rowErr.Error())
+	return nil
+}
+
+// reject writes row error description to the rejects file, file is created on the first reject
+func (h *Handler) reject(rowErr *cerror.ParseError) error {
+	if h.writer == nil {
+		file, err := os.Create(h.rejectsPath)
+		if err != nil {
+			return &cerror.StageError{Stage: cnst.OutputStage, Source: h.rejectsPath, Reason: "failed to create rejects file", Err: err}
+		}
+		h.file = file
+		h.writer = csv.NewWriter(file)
+		if err = h.writer.Write(rejectsHeader); err != nil {
+			return &cerror.StageError{Stage: cnst.OutputStage, Source: h.rejectsPath, Reason: "failed to write rejects file", Err: err}
+		}
+	}
+
+	line := ""
+	if rowErr.Line > 0 {
+		line = strconv.Itoa(rowErr.Line)
+	}
+	if err := h.writer.Write([]string{rowErr.Source, line, rowErr.Column, rowErr.Reason}); err != nil {
+		return &cerror.StageError{Stage: cnst.OutputStage, Source: h.rejectsPath, Reason: "failed to write rejects file", Err: err}
+	}
+	return nil
+}
+
+// Count returns the number of skipped rows
+func (h *Handler) Count() uint {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Close flushes and closes the rejects file if it was created
+func (h *Handler) Close() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return nil
+	}
+	h.writer.Flush()
+	err := h.writer.Error()
+	if closeErr := h.file.Close(); err == nil {
+		err = closeErr
+	}
+	h.file, h.writer = nil, nil
+	if err != nil {
+		return &cerror.StageError{Stage: cnst.OutputStage, Source: h.rejectsPath, Reason: "failed to write rejects file", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package rejects
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"testing"
+)
+
+func rowError(line int) *cerror.ParseError {
+	return &cerror.ParseError{Source: "data.csv", Line: line, Column: "Ltv2", Reason: "failed to convert ltv data"}
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		rejectsPath string
+		errorStr    string
+	}{
+		{name: "FailMode", mode: cnst.OnErrorFail},
+		{name: "SkipMode", mode: cnst.OnErrorSkip},
+		{name: "QuarantineMode", mode: cnst.OnErrorQuarantine, rejectsPath: cnst.DefaultRejectsFile},
+		{
+			name:     "QuarantineModeWithoutRejectsFile",
+			mode:     cnst.OnErrorQuarantine,
+			errorStr: cerror.NewCustomError("invalid rejects file path").Error(),
+		},
+		{
+			name:     "InvalidMode",
+			mode:     "ignore",
+			errorStr: cerror.NewCustomError(fmt.Sprintf("%q invalid on-error parameter", "ignore")).Error(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Parameters are prepared in the test case
+
+			/* ACT */
+			handler, err := NewHandler(testCase.mode, 0, testCase.rejectsPath)
+
+			/* ASSERT */
+			if testCase.errorStr != "" {
+				if err == nil || err.Error() != testCase.errorStr {
+					t.Fatalf("NewHandler() expected error [%s], got [%v]", testCase.errorStr, err)
+				}
+				return
+			}
+			if err != nil || handler == nil {
+				t.Fatalf("NewHandler() unexpected error [%v]", err)
+			}
+		})
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       func(rejectsPath string) *Handler
+		rows          int
+		expectedFails int
+		expectedCount uint
+		expectedFile  string
+	}{
+		{
+			name:          "NilHandlerFails",
+			handler:       func(string) *Handler { return nil },
+			rows:          1,
+			expectedFails: 1,
+		},
+		{
+			name: "FailModeFails",
+			handler: func(string) *Handler {
+				h, _ := NewHandler(cnst.OnErrorFail, 0, "")
+				return h
+			},
+			rows:          1,
+			expectedFails: 1,
+		},
+		{
+			name: "SkipModeWithoutLimit",
+			handler: func(string) *Handler {
+				h, _ := NewHandler(cnst.OnErrorSkip, 0, "")
+				return h
+			},
+			rows:          5,
+			expectedCount: 5,
+		},
+		{
+			name: "SkipModeAboveLimitFails",
+			handler: func(string) *Handler {
+				h, _ := NewHandler(cnst.OnErrorSkip, 2, "")
+				return h
+			},
+			rows:          4,
+			expectedFails: 2,
+			expectedCount: 4,
+		},
+		{
+			name: "QuarantineModeWritesRejects",
+			handler: func(rejectsPath string) *Handler {
+				h, _ := NewHandler(cnst.OnErrorQuarantine, 0, rejectsPath)
+				return h
+			},
+			rows:          2,
+			expectedCount: 2,
+			expectedFile: "file,line,column,reason\n" +
+				"data.csv,1,Ltv2,failed to convert ltv data\n" +
+				"data.csv,2,Ltv2,failed to convert ltv data\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			rejectsPath := filepath.Join(t.TempDir(), cnst.DefaultRejectsFile)
+			handler := testCase.handler(rejectsPath)
+
+			/* ACT */
+			fails := 0
+			for line := 1; line <= testCase.rows; line++ {
+				if err := handler.Handle(rowError(line)); err != nil {
+					fails++
+				}
+			}
+			closeErr := handler.Close()
+
+			/* ASSERT */
+			if closeErr != nil {
+				t.Fatalf("Close() unexpected error [%v]", closeErr)
+			}
+			if fails != testCase.expectedFails {
+				t.Errorf("Handle() expected %d fails, got %d", testCase.expectedFails, fails)
+			}
+			if handler.Count() != testCase.expectedCount {
+				t.Errorf("Count() expected %d, got %d", testCase.expectedCount, handler.Count())
+			}
+			data, err := os.ReadFile(rejectsPath)
+			if testCase.expectedFile == "" {
+				if err == nil {
+					t.Errorf("Handle() unexpected rejects file: %q", string(data))
+				}
+				return
+			}
+			if string(data) != testCase.expectedFile {
+				t.Errorf("Handle() rejects file exp: %q\ngot: %q", testCase.expectedFile, string(data))
+			}
+		})
+	}
+}
+
+func TestHandler_HandleFailModeReturnsRowError(t *testing.T) {
+	/* ARRANGE */
+	handler, _ := NewHandler(cnst.OnErrorFail, 0, "")
+	rowErr := rowError(3)
+
+	/* ACT */
+	err := handler.Handle(rowErr)
+
+	/* ASSERT */
+	if err == nil || err.Error() != `error: "data.csv" line 3 column "Ltv2": failed to convert ltv data` {
+		t.Errorf("Handle() unexpected error [%v]", err)
+	}
+}
+
+func TestHandler_HandleRejectsFileNotCreated(t *testing.T) {
+	/* ARRANGE */
+	rejectsPath := filepath.Join(t.TempDir(), "missing", cnst.DefaultRejectsFile)
+	handler, _ := NewHandler(cnst.OnErrorQuarantine, 0, rejectsPath)
+
+	/* ACT */
+	err := handler.Handle(rowError(1))
+
+	/* ASSERT */
+	var stageErr *cerror.StageError
+	if !errors.Is(err, fs.ErrNotExist) || !errors.As(err, &stageErr) || stageErr.Stage != cnst.OutputStage {
+		t.Errorf("Handle() expected not exist rejects file error, got [%v]", err)
+	}
+}
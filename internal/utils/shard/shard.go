@@ -0,0 +1,23 @@
+package shard
+
+// FNV-1a 32-bit hash parameters
+const (
+	offset32 = 2166136261
+	prime32  = 16777619
+)
+
+// Index returns shard index of the key in the [0, shards) range, keys are spread by FNV-1a hash
+// The same key is always mapped to the same shard, so per key order is kept by a shard
+// Returns 0 if shards is less than 2
+func Index(key string, shards int) int {
+	if shards < 2 {
+		return 0
+	}
+	// Hash is calculated in place, the hot path doesn't allocate
+	hash := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+	return int(hash % uint32(shards))
+}
@@ -0,0 +1,56 @@
+package shard
+
+import (
+	"hash/fnv"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		shards int
+	}{
+		{name: "singleShard", key: "US", shards: 1},
+		{name: "noShards", key: "US", shards: 0},
+		{name: "emptyKey", key: "", shards: 4},
+		{name: "countryKey", key: "US", shards: 4},
+		{name: "compositeKey", key: "9566c74d-1003-4c4d-bbbb-0407d1e2c649|JP", shards: 7},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			expected := 0
+			if testCase.shards > 1 {
+				hash := fnv.New32a()
+				hash.Write([]byte(testCase.key))
+				expected = int(hash.Sum32() % uint32(testCase.shards))
+			}
+
+			/* ACT */
+			index := Index(testCase.key, testCase.shards)
+
+			/* ASSERT */
+			if index != expected || index != Index(testCase.key, testCase.shards) {
+				t.Fatalf("Index(%q, %d) : expected %d, got %d", testCase.key, testCase.shards, expected, index)
+			}
+		})
+	}
+}
+
+func TestIndex_Spread(t *testing.T) {
+	/* ARRANGE */
+	keys := []string{"US", "JP", "DE", "TR", "FR", "GB", "CA", "AU", "BR", "IN", "KR", "IT"}
+	used := map[int]bool{}
+
+	/* ACT */
+	for _, key := range keys {
+		used[Index(key, 4)] = true
+	}
+
+	/* ASSERT */
+	if len(used) < 2 {
+		t.Fatalf("Index() : expected keys spread across shards, got %v", used)
+	}
+}
@@ -0,0 +1,124 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"strings"
+)
+
+// Compressed streams are recognized by leading magic bytes
+// bzip2 stream header "BZh" is followed by the block size digit and the first block or the end of stream magic
+var (
+	gzipMagic       = []byte{0x1f, 0x8b}
+	zstdMagic       = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic      = []byte("BZh")
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// bzip2HeaderLen is the length of the bzip2 stream header followed by the block magic
+const bzip2HeaderLen = 10
+
+// compressionSuffixes lists file suffixes peeled off to get the data format extension
+var compressionSuffixes = map[string]bool{
+	cnst.GzipCompression:  true,
+	cnst.GzipCompression2: true,
+	cnst.ZstdCompression:  true,
+	cnst.ZstdCompression2: true,
+	cnst.Bzip2Compression: true,
+}
+
+// Ext returns data format extension of the path, compression suffixes are skipped
+// e.g. "data.csv.gz" has ".csv" extension
+func Ext(path string) string {
+	ext := filepath.Ext(path)
+	for compressionSuffixes[strings.ToLower(ext)] {
+		path = strings.TrimSuffix(path, ext)
+		ext = filepath.Ext(path)
+	}
+	return ext
+}
+
+// Open opens data source for reading
+// Standard input is used if path is "-", closing it is a no-op
+// Compressed data (gzip, zstd, bzip2) is detected by magic bytes and transparently decompressed
+func Open(path string) (io.ReadCloser, error) {
+	var file io.ReadCloser = io.NopCloser(os.Stdin)
+	if path != cnst.StdinSource {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+
+	reader, err := decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, &cerror.StageError{Stage: cnst.DataSourceStage, Reason: "failed to decompress source", Err: err}
+	}
+	return reader, nil
+}
+
+// decompress wraps file in the decompressor matching its magic bytes
+// Uncompressed data is returned as is, closing the result closes the file
+func decompress(file io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(file)
+	magic, err := buffered.Peek(bzip2HeaderLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: gzipReader, closers: []io.Closer{gzipReader, file}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: zstdReader, closers: []io.Closer{zstdReader.IOReadCloser(), file}}, nil
+	case isBzip2(magic):
+		return &readCloser{Reader: bzip2.NewReader(buffered), closers: []io.Closer{file}}, nil
+	default:
+		return &readCloser{Reader: buffered, closers: []io.Closer{file}}, nil
+	}
+}
+
+// isBzip2 reports whether magic is a bzip2 stream header, so plain text starting with "BZh" is read as is
+func isBzip2(magic []byte) bool {
+	if len(magic) < bzip2HeaderLen || !bytes.HasPrefix(magic, bzip2Magic) || magic[3] < '1' || magic[3] > '9' {
+		return false
+	}
+	block := magic[len(bzip2Magic)+1:]
+	return bytes.Equal(block, bzip2BlockMagic) || bytes.Equal(block, bzip2EndMagic)
+}
+
+// readCloser reads from the wrapped reader and closes all closers in order
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes decompressor and underlying file, the first error is returned
+func (r *readCloser) Close() error {
+	var result error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"testing"
+)
+
+const (
+	Content = "CampaignId,Country,Ltv1\n"
+)
+
+// bzip2Content is Content compressed with bzip2, standard library has no bzip2 writer
+var bzip2Content = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x68, 0xcc,
+	0xcc, 0xf9, 0x00, 0x00, 0x02, 0x5d, 0x80, 0x00, 0x10, 0x00, 0x04, 0x20,
+	0x00, 0x08, 0x24, 0x24, 0xa3, 0xd7, 0x20, 0x20, 0x00, 0x22, 0x9a, 0x32,
+	0x03, 0x6a, 0x68, 0xf5, 0x0a, 0x64, 0xc4, 0xc8, 0x32, 0x32, 0x97, 0x25,
+	0x58, 0x24, 0x63, 0x21, 0xe4, 0x0b, 0x41, 0xd7, 0x7d, 0x53, 0xf1, 0x77,
+	0x24, 0x53, 0x85, 0x09, 0x06, 0x8c, 0xcc, 0xcf, 0x90,
+}
+
+func gzipBytes(t *testing.T, data string) []byte {
+	buf := &bytes.Buffer{}
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to compress data [%s]", err.Error())
+	}
+	writer.Close()
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data string) []byte {
+	writer, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd writer [%s]", err.Error())
+	}
+	defer writer.Close()
+	return writer.EncodeAll([]byte(data), nil)
+}
+
+func TestExt(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		expectedExt string
+	}{
+		{name: "PlainFile", path: "data/test_data.csv", expectedExt: cnst.CsvDataSource},
+		{name: "GzipFile", path: "test_data.csv.gz", expectedExt: cnst.CsvDataSource},
+		{name: "ZstdFile", path: "test_data.json.zst", expectedExt: cnst.JsonDataSource},
+		{name: "Bzip2UpperCaseFile", path: "test_data.jsonl.BZ2", expectedExt: cnst.JsonlDataSource},
+		{name: "SeveralCompressionSuffixes", path: "test_data.csv.gz.zstd", expectedExt: cnst.CsvDataSource},
+		{name: "CompressionSuffixOnly", path: "test_data.gz", expectedExt: ""},
+		{name: "Stdin", path: cnst.StdinSource, expectedExt: ""},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Path is prepared in the test case
+
+			/* ACT */
+			ext := Ext(testCase.path)
+
+			/* ASSERT */
+			if ext != testCase.expectedExt {
+				t.Errorf("Ext() exp: %q, got: %q", testCase.expectedExt, ext)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileName      string
+		data          []byte
+		plain         bool
+		expectedError bool
+	}{
+		{name: "PlainFile", fileName: "data.csv", data: []byte(Content), plain: true},
+		{name: "EmptyFile", fileName: "data.csv", data: []byte{}, plain: true},
+		{name: "PlainFileBzip2Prefix", fileName: "data.csv", data: []byte("BZh,Country,Ltv1\n"), plain: true},
+		{name: "PlainFileBzip2HeaderPrefix", fileName: "data.csv", data: []byte("BZh9,Country,Ltv1\n"), plain: true},
+		{name: "GzipFile", fileName: "data.csv.gz", data: gzipBytes(t, Content)},
+		{name: "ZstdFile", fileName: "data.csv.zst", data: zstdBytes(t, Content)},
+		{name: "Bzip2File", fileName: "data.csv.bz2", data: bzip2Content},
+		{name: "MisnamedGzipFile", fileName: "data.csv", data: gzipBytes(t, Content)},
+		{name: "CorruptedGzipFile", fileName: "data.csv.gz", data: []byte{0x1f, 0x8b, 0x00}, expectedError: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			path := filepath.Join(t.TempDir(), testCase.fileName)
+			if err := os.WriteFile(path, testCase.data, 0644); err != nil {
+				t.Fatalf("Failed to create file [%s]", err.Error())
+			}
+
+			/* ACT */
+			reader, err := Open(path)
+
+			/* ASSERT */
+			if (err != nil) != testCase.expectedError {
+				t.Fatalf("Open() expected error %v, got %v", testCase.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			defer reader.Close()
+
+			expected := Content
+			if testCase.plain {
+				expected = string(testCase.data)
+			}
+			if data, err := io.ReadAll(reader); err != nil || string(data) != expected {
+				t.Errorf("Open() unexpected content: %q, error: %v", string(data), err)
+			}
+		})
+	}
+}
+
+func TestOpen_MissingFile(t *testing.T) {
+	/* ARRANGE */
+	path := filepath.Join(t.TempDir(), "missing.csv")
+
+	/* ACT */
+	_, err := Open(path)
+
+	/* ASSERT */
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open() expected not exist error for missing file, got: %v", err)
+	}
+}
+
+func TestOpen_TruncatedCompressedFile(t *testing.T) {
+	/* ARRANGE */
+	path := filepath.Join(t.TempDir(), "data.csv.gz")
+	if err := os.WriteFile(path, gzipBytes(t, Content)[:5], 0o600); err != nil {
+		t.Fatalf("Failed to write file [%s]", err.Error())
+	}
+
+	/* ACT */
+	_, err := Open(path)
+
+	/* ASSERT */
+	var stageErr *cerror.StageError
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &stageErr) || stageErr.Stage != cnst.DataSourceStage {
+		t.Errorf("Open() expected decompress error caused by unexpected EOF, got: %v", err)
+	}
+}
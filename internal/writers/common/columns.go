@@ -0,0 +1,110 @@
+package common
+
+import (
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"strconv"
+)
+
+// Header returns result column names
+// Dimension names are followed by one column per predicted day and one column per metric
+// Result with predictions intervals has lower and upper bound columns after each day column
+// Result with the chosen model has extra model column, partial result has extra partial column
+func Header(result *t.Result) []string {
+	header := make([]string, 0, len(result.Dimensions())+3*len(result.Predictions())+len(result.Metrics())+2)
+	for _, dimension := range result.Dimensions() {
+		header = append(header, dimension.Name)
+	}
+	for _, prediction := range result.Predictions() {
+		day := cnst.OutputDayColumnPrefix + strconv.FormatUint(uint64(prediction.Day), 10)
+		header = append(header, day)
+		if result.Confidence() != 0 {
+			header = append(header, day+cnst.OutputLowerSuffix, day+cnst.OutputUpperSuffix)
+		}
+	}
+	for _, metric := range result.Metrics() {
+		header = append(header, metric.Name)
+	}
+	if result.Model() != "" {
+		header = append(header, cnst.OutputModelColumn)
+	}
+	if result.Partial() {
+		header = append(header, cnst.OutputPartialColumn)
+	}
+	return header
+}
+
+// Row returns result column values in Header order
+// Predicted and metric values are formatted with precision digits, -1 means the smallest exact representation
+func Row(result *t.Result, precision int) []string {
+	row := make([]string, 0, len(result.Dimensions())+3*len(result.Predictions())+len(result.Metrics())+2)
+	for _, dimension := range result.Dimensions() {
+		row = append(row, dimension.Value)
+	}
+	for _, prediction := range result.Predictions() {
+		row = append(row, strconv.FormatFloat(prediction.Value, 'f', precision, 64))
+		if result.Confidence() != 0 {
+			row = append(row, strconv.FormatFloat(prediction.Lower, 'f', precision, 64),
+				strconv.FormatFloat(prediction.Upper, 'f', precision, 64))
+		}
+	}
+	for _, metric := range result.Metrics() {
+		row = append(row, strconv.FormatFloat(metric.Value, 'f', precision, 64))
+	}
+	if result.Model() != "" {
+		row = append(row, result.Model())
+	}
+	if result.Partial() {
+		row = append(row, strconv.FormatBool(true))
+	}
+	return row
+}
+
+// JsonPrediction represents JSON output structure of a single day prediction
+// Interval bounds are omitted unless the predictions intervals are estimated
+type JsonPrediction struct {
+	Day   uint     `json:"day"`
+	Value float64  `json:"value"`
+	Lower *float64 `json:"lower,omitempty"`
+	Upper *float64 `json:"upper,omitempty"`
+}
+
+// JsonResult represents JSON output structure of a result, partial flag is omitted for complete results
+// Model is omitted unless it is chosen per key, predictions and metrics are omitted if there are none
+// Confidence level is omitted unless the predictions intervals are estimated
+type JsonResult struct {
+	Dimensions  map[string]string  `json:"dimensions"`
+	Predictions []JsonPrediction   `json:"predictions,omitempty"`
+	Confidence  float64            `json:"confidence,omitempty"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+	Model       string             `json:"model,omitempty"`
+	Partial     bool               `json:"partial,omitempty"`
+}
+
+// NewJsonResult converts result to JSON output structure
+func NewJsonResult(result *t.Result) JsonResult {
+	jsonResult := JsonResult{
+		Dimensions:  make(map[string]string, len(result.Dimensions())),
+		Predictions: make([]JsonPrediction, 0, len(result.Predictions())),
+		Confidence:  result.Confidence(),
+		Model:       result.Model(),
+		Partial:     result.Partial(),
+	}
+	for _, dimension := range result.Dimensions() {
+		jsonResult.Dimensions[dimension.Name] = dimension.Value
+	}
+	for _, prediction := range result.Predictions() {
+		jsonPrediction := JsonPrediction{Day: prediction.Day, Value: prediction.Value}
+		if result.Confidence() != 0 {
+			jsonPrediction.Lower, jsonPrediction.Upper = &prediction.Lower, &prediction.Upper
+		}
+		jsonResult.Predictions = append(jsonResult.Predictions, jsonPrediction)
+	}
+	if len(result.Metrics()) != 0 {
+		jsonResult.Metrics = make(map[string]float64, len(result.Metrics()))
+		for _, metric := range result.Metrics() {
+			jsonResult.Metrics[metric.Name] = metric.Value
+		}
+	}
+	return jsonResult
+}
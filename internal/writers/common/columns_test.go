@@ -0,0 +1,151 @@
+package common
+
+import (
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"reflect"
+	"testing"
+)
+
+// withModel returns result with the chosen model set
+func withModel(result *tp.Result, model string) *tp.Result {
+	result.SetModel(model)
+	return result
+}
+
+// withMetrics returns result with the metrics set
+func withMetrics(result *tp.Result, metrics []tp.Metric) *tp.Result {
+	result.SetMetrics(metrics)
+	return result
+}
+
+// withConfidence returns result with the predictions intervals confidence level set
+func withConfidence(result *tp.Result, confidence float64) *tp.Result {
+	result.SetConfidence(confidence)
+	return result
+}
+
+// bound returns pointer to the interval bound value
+func bound(value float64) *float64 {
+	return &value
+}
+
+// partial returns result marked partial
+func partial(result *tp.Result) *tp.Result {
+	result.MarkPartial()
+	return result
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		name           string
+		result         *tp.Result
+		precision      int
+		expectedHeader []string
+		expectedRow    []string
+		expectedJson   JsonResult
+	}{
+		{
+			name:           "SingleDimension",
+			result:         tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, []tp.Prediction{{Day: 60, Value: 1.2345}}),
+			precision:      2,
+			expectedHeader: []string{cnst.AggregateCountry, "day60"},
+			expectedRow:    []string{"JP", "1.23"},
+			expectedJson: JsonResult{
+				Dimensions:  map[string]string{cnst.AggregateCountry: "JP"},
+				Predictions: []JsonPrediction{{Day: 60, Value: 1.2345}},
+			},
+		},
+		{
+			name: "MultipleDimensionsExactPrecision",
+			result: tp.NewResult("JP <9566c74d>",
+				[]tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}, {Name: cnst.AggregateCampaign, Value: "9566c74d"}},
+				[]tp.Prediction{{Day: 7, Value: 1.2345}, {Day: 30, Value: 10}}),
+			precision:      -1,
+			expectedHeader: []string{cnst.AggregateCountry, cnst.AggregateCampaign, "day7", "day30"},
+			expectedRow:    []string{"JP", "9566c74d", "1.2345", "10"},
+			expectedJson: JsonResult{
+				Dimensions:  map[string]string{cnst.AggregateCountry: "JP", cnst.AggregateCampaign: "9566c74d"},
+				Predictions: []JsonPrediction{{Day: 7, Value: 1.2345}, {Day: 30, Value: 10}},
+			},
+		},
+		{
+			name:           "PartialResult",
+			result:         partial(tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, []tp.Prediction{{Day: 60, Value: 1.2345}})),
+			precision:      2,
+			expectedHeader: []string{cnst.AggregateCountry, "day60", cnst.OutputPartialColumn},
+			expectedRow:    []string{"JP", "1.23", "true"},
+			expectedJson: JsonResult{
+				Dimensions:  map[string]string{cnst.AggregateCountry: "JP"},
+				Predictions: []JsonPrediction{{Day: 60, Value: 1.2345}},
+				Partial:     true,
+			},
+		},
+		{
+			name:           "ChosenModelPartialResult",
+			result:         partial(withModel(tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, []tp.Prediction{{Day: 60, Value: 1.2345}}), cnst.PowerLawPredictorModel)),
+			precision:      2,
+			expectedHeader: []string{cnst.AggregateCountry, "day60", cnst.OutputModelColumn, cnst.OutputPartialColumn},
+			expectedRow:    []string{"JP", "1.23", cnst.PowerLawPredictorModel, "true"},
+			expectedJson: JsonResult{
+				Dimensions:  map[string]string{cnst.AggregateCountry: "JP"},
+				Predictions: []JsonPrediction{{Day: 60, Value: 1.2345}},
+				Model:       cnst.PowerLawPredictorModel,
+				Partial:     true,
+			},
+		},
+		{
+			name: "IntervalResult",
+			result: withConfidence(tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}},
+				[]tp.Prediction{{Day: 7, Value: 1.2345, Lower: 1, Upper: 1.5}, {Day: 30, Value: 10, Lower: 8.125, Upper: 12}}), 0.9),
+			precision:      2,
+			expectedHeader: []string{cnst.AggregateCountry, "day7", "day7_lower", "day7_upper", "day30", "day30_lower", "day30_upper"},
+			expectedRow:    []string{"JP", "1.23", "1.00", "1.50", "10.00", "8.12", "12.00"},
+			expectedJson: JsonResult{
+				Dimensions: map[string]string{cnst.AggregateCountry: "JP"},
+				Predictions: []JsonPrediction{
+					{Day: 7, Value: 1.2345, Lower: bound(1), Upper: bound(1.5)},
+					{Day: 30, Value: 10, Lower: bound(8.125), Upper: bound(12)},
+				},
+				Confidence: 0.9,
+			},
+		},
+		{
+			name: "MetricsResult",
+			result: withModel(withMetrics(tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, nil),
+				[]tp.Metric{{Name: cnst.MetricMae, Value: 0.125}, {Name: cnst.MetricBias, Value: -0.5}}), cnst.LinearExtrapolationPredictorModel),
+			precision:      2,
+			expectedHeader: []string{cnst.AggregateCountry, cnst.MetricMae, cnst.MetricBias, cnst.OutputModelColumn},
+			expectedRow:    []string{"JP", "0.12", "-0.50", cnst.LinearExtrapolationPredictorModel},
+			expectedJson: JsonResult{
+				Dimensions:  map[string]string{cnst.AggregateCountry: "JP"},
+				Predictions: []JsonPrediction{},
+				Metrics:     map[string]float64{cnst.MetricMae: 0.125, cnst.MetricBias: -0.5},
+				Model:       cnst.LinearExtrapolationPredictorModel,
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			// Result is prepared in the test case
+
+			/* ACT */
+			header := Header(testCase.result)
+			row := Row(testCase.result, testCase.precision)
+			jsonResult := NewJsonResult(testCase.result)
+
+			/* ASSERT */
+			if !reflect.DeepEqual(header, testCase.expectedHeader) {
+				t.Errorf("Unexpected header, expected: %v, got: %v", testCase.expectedHeader, header)
+			}
+			if !reflect.DeepEqual(row, testCase.expectedRow) {
+				t.Errorf("Unexpected row, expected: %v, got: %v", testCase.expectedRow, row)
+			}
+			if !reflect.DeepEqual(jsonResult, testCase.expectedJson) {
+				t.Errorf("Unexpected json result, expected: %v, got: %v", testCase.expectedJson, jsonResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package common
+
+import t "playground/internal/types"
+
+// IWriter writes postprocessed results to the output in a writer specific format
+// Flush should be called once all results are written
+type IWriter interface {
+	Write(result *t.Result) error
+	Flush() error
+}
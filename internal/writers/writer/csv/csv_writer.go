@@ -0,0 +1,41 @@
+package csv
+
+import (
+	"encoding/csv"
+	"io"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/writers/common"
+)
+
+// csvWriter represents a CSV results writer, header is taken from the first result
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// NewWriter initializes and returns csvWriter
+// Returns error if out is nil
+func NewWriter(out io.Writer) (*csvWriter, error) {
+	if out == nil {
+		return nil, cerror.NewConfigError("output writer", "", "invalid output writer")
+	}
+	return &csvWriter{writer: csv.NewWriter(out)}, nil
+}
+
+// Write interface implementation, writes header before the first result
+func (w *csvWriter) Write(result *t.Result) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(common.Header(result)); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	return w.writer.Write(common.Row(result, -1))
+}
+
+// Flush interface implementation, flushes buffered CSV data
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,55 @@
+package json
+
+import (
+	"encoding/json"
+	"io"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/writers/common"
+)
+
+// jsonWriter represents a JSON results writer, results are streamed as a single JSON array
+type jsonWriter struct {
+	out     io.Writer
+	written int
+}
+
+// NewWriter initializes and returns jsonWriter
+// Returns error if out is nil
+func NewWriter(out io.Writer) (*jsonWriter, error) {
+	if out == nil {
+		return nil, cerror.NewConfigError("output writer", "", "invalid output writer")
+	}
+	return &jsonWriter{out: out}, nil
+}
+
+// Write interface implementation, opens JSON array before the first result
+func (w *jsonWriter) Write(result *t.Result) error {
+	data, err := json.Marshal(common.NewJsonResult(result))
+	if err != nil {
+		return err
+	}
+
+	delimiter := ","
+	if w.written == 0 {
+		delimiter = "["
+	}
+	if _, err = io.WriteString(w.out, delimiter); err != nil {
+		return err
+	}
+	if _, err = w.out.Write(data); err != nil {
+		return err
+	}
+	w.written++
+	return nil
+}
+
+// Flush interface implementation, closes JSON array
+func (w *jsonWriter) Flush() error {
+	closing := "]\n"
+	if w.written == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(w.out, closing)
+	return err
+}
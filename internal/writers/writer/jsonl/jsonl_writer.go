@@ -0,0 +1,33 @@
+package jsonl
+
+import (
+	"encoding/json"
+	"io"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/writers/common"
+)
+
+// jsonlWriter represents a JSON Lines results writer, one JSON object per line
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+// NewWriter initializes and returns jsonlWriter
+// Returns error if out is nil
+func NewWriter(out io.Writer) (*jsonlWriter, error) {
+	if out == nil {
+		return nil, cerror.NewConfigError("output writer", "", "invalid output writer")
+	}
+	return &jsonlWriter{encoder: json.NewEncoder(out)}, nil
+}
+
+// Write interface implementation, encoder terminates each object with a new line
+func (w *jsonlWriter) Write(result *t.Result) error {
+	return w.encoder.Encode(common.NewJsonResult(result))
+}
+
+// Flush interface implementation, jsonl writer has nothing to flush
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
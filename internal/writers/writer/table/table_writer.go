@@ -0,0 +1,50 @@
+package table
+
+import (
+	"fmt"
+	"io"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"playground/internal/writers/common"
+	"strings"
+	"text/tabwriter"
+)
+
+// tableWriter represents a fixed-width table results writer
+// Columns width is calculated on Flush, according to the widest value
+type tableWriter struct {
+	writer        *tabwriter.Writer
+	headerWritten bool
+}
+
+// NewWriter initializes and returns tableWriter
+// Returns error if out is nil
+func NewWriter(out io.Writer) (*tableWriter, error) {
+	if out == nil {
+		return nil, cerror.NewConfigError("output writer", "", "invalid output writer")
+	}
+	return &tableWriter{writer: tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)}, nil
+}
+
+// Write interface implementation, writes header before the first result
+func (w *tableWriter) Write(result *t.Result) error {
+	if !w.headerWritten {
+		if err := w.writeRow(common.Header(result)); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	return w.writeRow(common.Row(result, cnst.OutputValuePrecision))
+}
+
+// writeRow writes tab separated row, tabwriter aligns columns
+func (w *tableWriter) writeRow(row []string) error {
+	_, err := fmt.Fprintln(w.writer, strings.Join(row, "\t"))
+	return err
+}
+
+// Flush interface implementation, writes aligned table
+func (w *tableWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,54 @@
+package text
+
+import (
+	"fmt"
+	"io"
+	cnst "playground/internal/constants"
+	t "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"strings"
+)
+
+// textWriter represents a human readable results writer, one "label: values" line per result
+type textWriter struct {
+	out io.Writer
+}
+
+// NewWriter initializes and returns textWriter
+// Returns error if out is nil
+func NewWriter(out io.Writer) (*textWriter, error) {
+	if out == nil {
+		return nil, cerror.NewConfigError("output writer", "", "invalid output writer")
+	}
+	return &textWriter{out: out}, nil
+}
+
+// Write interface implementation, predicted values are printed as columns, one column per day
+// Predicted value is followed by its [lower, upper] interval, if any
+// Values are followed by name=value metrics, the chosen model in parentheses, if any, and by partial mark for partial result
+func (w *textWriter) Write(result *t.Result) error {
+	values := make([]string, 0, len(result.Predictions())+len(result.Metrics())+2)
+	for _, prediction := range result.Predictions() {
+		value := fmt.Sprintf("%.*f", cnst.OutputValuePrecision, prediction.Value)
+		if result.Confidence() != 0 {
+			value += fmt.Sprintf(" [%.*f, %.*f]", cnst.OutputValuePrecision, prediction.Lower, cnst.OutputValuePrecision, prediction.Upper)
+		}
+		values = append(values, value)
+	}
+	for _, metric := range result.Metrics() {
+		values = append(values, fmt.Sprintf("%s=%.*f", metric.Name, cnst.OutputValuePrecision, metric.Value))
+	}
+	if result.Model() != "" {
+		values = append(values, "("+result.Model()+")")
+	}
+	if result.Partial() {
+		values = append(values, cnst.OutputPartialMark)
+	}
+	_, err := fmt.Fprintf(w.out, "%s: %s\n", result.Label(), strings.Join(values, " "))
+	return err
+}
+
+// Flush interface implementation, text writer has nothing to flush
+func (w *textWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package writer_factory
+
+import (
+	"fmt"
+	"io"
+	cnst "playground/internal/constants"
+	"playground/internal/utils/cerror"
+	"playground/internal/writers/common"
+	"playground/internal/writers/writer/csv"
+	"playground/internal/writers/writer/json"
+	"playground/internal/writers/writer/jsonl"
+	"playground/internal/writers/writer/table"
+	"playground/internal/writers/writer/text"
+)
+
+// NewWriter creates a new results writer to out
+// According to output format parameter
+func NewWriter(format string, out io.Writer) (common.IWriter, error) {
+	// General Factory logic, create results writer according to format parameter
+	switch format {
+	case cnst.TextOutputFormat:
+		return text.NewWriter(out)
+	case cnst.CsvOutputFormat:
+		return csv.NewWriter(out)
+	case cnst.JsonOutputFormat:
+		return json.NewWriter(out)
+	case cnst.JsonlOutputFormat:
+		return jsonl.NewWriter(out)
+	case cnst.TableOutputFormat:
+		return table.NewWriter(out)
+	default:
+		return nil, cerror.NewConfigError(cnst.CliOutputFormat, format, fmt.Sprintf("%q invalid output format parameter", format))
+	}
+}
@@ -0,0 +1,279 @@
+package writer_factory
+
+import (
+	"bytes"
+	"fmt"
+	cnst "playground/internal/constants"
+	tp "playground/internal/types"
+	"playground/internal/utils/cerror"
+	"testing"
+)
+
+const (
+	InvalidOutputFormatParameter = "output_something"
+)
+
+var (
+	formats = []string{cnst.TextOutputFormat, cnst.CsvOutputFormat, cnst.JsonOutputFormat, cnst.JsonlOutputFormat, cnst.TableOutputFormat}
+	results = []*tp.Result{
+		tp.NewResult("JP <9566c74d>",
+			[]tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}, {Name: cnst.AggregateCampaign, Value: "9566c74d"}},
+			[]tp.Prediction{{Day: 7, Value: 1.234}, {Day: 60, Value: 10.5}}),
+		tp.NewResult("US <6325253f>",
+			[]tp.Dimension{{Name: cnst.AggregateCountry, Value: "US"}, {Name: cnst.AggregateCampaign, Value: "6325253f"}},
+			[]tp.Prediction{{Day: 7, Value: 100}, {Day: 60, Value: 1000.126}}),
+	}
+	partialResult = func() *tp.Result {
+		result := tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, []tp.Prediction{{Day: 7, Value: 1.234}})
+		result.MarkPartial()
+		return result
+	}()
+	metricsResult = func() *tp.Result {
+		result := tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, nil)
+		result.SetMetrics([]tp.Metric{{Name: cnst.MetricMae, Value: 0.5}, {Name: cnst.MetricBias, Value: -0.25}})
+		result.SetModel(cnst.AveragePredictorModel)
+		return result
+	}()
+	intervalResult = func() *tp.Result {
+		result := tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}},
+			[]tp.Prediction{{Day: 7, Value: 1.234, Lower: 1, Upper: 1.5}, {Day: 60, Value: 10.5, Lower: 8, Upper: 13.256}})
+		result.SetConfidence(0.9)
+		return result
+	}()
+	modelResult = func() *tp.Result {
+		result := tp.NewResult("JP", []tp.Dimension{{Name: cnst.AggregateCountry, Value: "JP"}}, []tp.Prediction{{Day: 7, Value: 1.234}})
+		result.SetModel(cnst.LogarithmicPredictorModel)
+		return result
+	}()
+)
+
+func TestNewWriter(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		expectedError bool
+		errorStr      string
+	}{
+		{
+			name:          "InvalidOutputFormatParameter",
+			format:        InvalidOutputFormatParameter,
+			expectedError: true,
+			errorStr:      cerror.NewCustomError(fmt.Sprintf("%q invalid output format parameter", InvalidOutputFormatParameter)).Error(),
+		},
+		{
+			name:   "TextOutputFormatParameter",
+			format: cnst.TextOutputFormat,
+		},
+		{
+			name:   "CsvOutputFormatParameter",
+			format: cnst.CsvOutputFormat,
+		},
+		{
+			name:   "JsonOutputFormatParameter",
+			format: cnst.JsonOutputFormat,
+		},
+		{
+			name:   "JsonlOutputFormatParameter",
+			format: cnst.JsonlOutputFormat,
+		},
+		{
+			name:   "TableOutputFormatParameter",
+			format: cnst.TableOutputFormat,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			out := &bytes.Buffer{}
+
+			/* ACT */
+			writer, err := NewWriter(testCase.format, out)
+
+			/* ASSERT */
+			if testCase.expectedError {
+				if err == nil || err.Error() != testCase.errorStr {
+					t.Errorf("Unexpected error, expected: %v, got: %v", testCase.errorStr, err)
+				}
+				return
+			}
+			if err != nil || writer == nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewWriter_InvalidOutputWriter(t *testing.T) {
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			/* ARRANGE */
+			expectedErrorStr := cerror.NewCustomError("invalid output writer").Error()
+
+			/* ACT */
+			_, err := NewWriter(format, nil)
+
+			/* ASSERT */
+			if err == nil || err.Error() != expectedErrorStr {
+				t.Errorf("Unexpected error, expected: %v, got: %v", expectedErrorStr, err)
+			}
+		})
+	}
+}
+
+func TestWriter_Write(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		results  []*tp.Result
+		expected string
+	}{
+		// Text format
+		{
+			name:     "TextNoResults",
+			format:   cnst.TextOutputFormat,
+			results:  []*tp.Result{},
+			expected: "",
+		},
+		{
+			name:     "TextSingleResult",
+			format:   cnst.TextOutputFormat,
+			results:  results[:1],
+			expected: "JP <9566c74d>: 1.23 10.50\n",
+		},
+		{
+			name:     "TextMultipleResults",
+			format:   cnst.TextOutputFormat,
+			results:  results,
+			expected: "JP <9566c74d>: 1.23 10.50\nUS <6325253f>: 100.00 1000.13\n",
+		},
+		{
+			name:     "TextPartialResult",
+			format:   cnst.TextOutputFormat,
+			results:  []*tp.Result{partialResult},
+			expected: "JP: 1.23 (partial)\n",
+		},
+		{
+			name:     "TextChosenModelResult",
+			format:   cnst.TextOutputFormat,
+			results:  []*tp.Result{modelResult},
+			expected: "JP: 1.23 (log)\n",
+		},
+		{
+			name:     "TextIntervalResult",
+			format:   cnst.TextOutputFormat,
+			results:  []*tp.Result{intervalResult},
+			expected: "JP: 1.23 [1.00, 1.50] 10.50 [8.00, 13.26]\n",
+		},
+		{
+			name:     "TextMetricsResult",
+			format:   cnst.TextOutputFormat,
+			results:  []*tp.Result{metricsResult},
+			expected: "JP: mae=0.50 bias=-0.25 (average)\n",
+		},
+		// CSV format
+		{
+			name:     "CsvNoResults",
+			format:   cnst.CsvOutputFormat,
+			results:  []*tp.Result{},
+			expected: "",
+		},
+		{
+			name:     "CsvSingleResult",
+			format:   cnst.CsvOutputFormat,
+			results:  results[:1],
+			expected: "country,campaign,day7,day60\nJP,9566c74d,1.234,10.5\n",
+		},
+		{
+			name:     "CsvMultipleResults",
+			format:   cnst.CsvOutputFormat,
+			results:  results,
+			expected: "country,campaign,day7,day60\nJP,9566c74d,1.234,10.5\nUS,6325253f,100,1000.126\n",
+		},
+		// JSON format
+		{
+			name:     "JsonNoResults",
+			format:   cnst.JsonOutputFormat,
+			results:  []*tp.Result{},
+			expected: "[]\n",
+		},
+		{
+			name:     "JsonSingleResult",
+			format:   cnst.JsonOutputFormat,
+			results:  results[:1],
+			expected: `[{"dimensions":{"campaign":"9566c74d","country":"JP"},"predictions":[{"day":7,"value":1.234},{"day":60,"value":10.5}]}]` + "\n",
+		},
+		{
+			name:    "JsonMultipleResults",
+			format:  cnst.JsonOutputFormat,
+			results: results,
+			expected: `[{"dimensions":{"campaign":"9566c74d","country":"JP"},"predictions":[{"day":7,"value":1.234},{"day":60,"value":10.5}]},` +
+				`{"dimensions":{"campaign":"6325253f","country":"US"},"predictions":[{"day":7,"value":100},{"day":60,"value":1000.126}]}]` + "\n",
+		},
+		// JSON Lines format
+		{
+			name:     "JsonlNoResults",
+			format:   cnst.JsonlOutputFormat,
+			results:  []*tp.Result{},
+			expected: "",
+		},
+		{
+			name:     "JsonlSingleResult",
+			format:   cnst.JsonlOutputFormat,
+			results:  results[:1],
+			expected: `{"dimensions":{"campaign":"9566c74d","country":"JP"},"predictions":[{"day":7,"value":1.234},{"day":60,"value":10.5}]}` + "\n",
+		},
+		{
+			name:    "JsonlMultipleResults",
+			format:  cnst.JsonlOutputFormat,
+			results: results,
+			expected: `{"dimensions":{"campaign":"9566c74d","country":"JP"},"predictions":[{"day":7,"value":1.234},{"day":60,"value":10.5}]}` + "\n" +
+				`{"dimensions":{"campaign":"6325253f","country":"US"},"predictions":[{"day":7,"value":100},{"day":60,"value":1000.126}]}` + "\n",
+		},
+		// Table format
+		{
+			name:     "TableNoResults",
+			format:   cnst.TableOutputFormat,
+			results:  []*tp.Result{},
+			expected: "",
+		},
+		{
+			name:     "TableSingleResult",
+			format:   cnst.TableOutputFormat,
+			results:  results[:1],
+			expected: "country  campaign  day7  day60\nJP       9566c74d  1.23  10.50\n",
+		},
+		{
+			name:     "TableMultipleResults",
+			format:   cnst.TableOutputFormat,
+			results:  results,
+			expected: "country  campaign  day7    day60\nJP       9566c74d  1.23    10.50\nUS       6325253f  100.00  1000.13\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			/* ARRANGE */
+			out := &bytes.Buffer{}
+			writer, err := NewWriter(testCase.format, out)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			/* ACT */
+			for _, result := range testCase.results {
+				if err = writer.Write(result); err != nil {
+					t.Fatalf("Unexpected write error: %v", err)
+				}
+			}
+			if err = writer.Flush(); err != nil {
+				t.Fatalf("Unexpected flush error: %v", err)
+			}
+
+			/* ASSERT */
+			if out.String() != testCase.expected {
+				t.Errorf("Unexpected output, expected: %q, got: %q", testCase.expected, out.String())
+			}
+		})
+	}
+}